@@ -2,10 +2,14 @@ package configuration
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	tables "product-test/database"
 	fx "product-test/functions"
 	adt "product-test/repo-adaptor"
+	"product-test/shared"
+	"product-test/storage"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -46,16 +50,114 @@ type PortalContext struct {
 
 //RepositoryContext context of repository
 type RepositoryContext struct {
-	Config  RepositoryConfiguration
-	Adaptor adt.RepositoryAdaptor
-	DB      *gorm.DB
-	Log     *zap.Logger
+	Config     RepositoryConfiguration
+	Adaptor    adt.RepositoryAdaptor
+	DB         *gorm.DB
+	Products   tables.ProductRepository
+	Categories tables.CategoryRepository
+	Variants   tables.VariantRepository
+	Stock      tables.StockRepository
+	LowStock   tables.LowStockRepository
+	Warehouses tables.WarehouseRepository
+	Reserve    tables.ReservationRepository
+	Prices     tables.PriceRepository
+	Currency   tables.CurrencyRepository
+	Promotions tables.PromotionRepository
+	Carts      tables.CartRepository
+	Orders     tables.OrderRepository
+	Wallets    tables.WalletRepository
+	Users      tables.UserRepository
+	Tokens     tables.RefreshTokenRepository
+	APIKeys    tables.APIKeyRepository
+	Tenants    TenantRepositories
+	JWT        fx.JWTKeys
+	Storage    storage.Storage
+	IDGen      fx.IDGenerator
+	Log        *zap.Logger
 }
 
+//TenantRepositories ctx with the catalog, stock, promotion, cart, order and wallet repositories narrowed
+//to tenant, they see none of the rows of the other tenants
+type TenantRepositories func(ctx RepositoryContext, tenant string) RepositoryContext
+
 //RepositoryConfiguration configuration collection for repositories
 type RepositoryConfiguration struct {
-	App AppConfig
-	DB  DBConfig
+	App     AppConfig
+	DB      DBConfig
+	ID      IDConfig
+	Storage StorageConfig
+	Reserve ReservationConfig
+	Mail    MailConfig
+	Price   PriceConfig
+	Auth    AuthConfig
+	Tenant  TenantConfig
+}
+
+//StorageConfig uploaded file storage, Backend is local or s3, MaxImageSize in bytes
+type StorageConfig struct {
+	Backend      string
+	LocalPath    string
+	PublicURL    string
+	S3Endpoint   string
+	S3Region     string
+	S3Bucket     string
+	S3AccessKey  string
+	S3SecretKey  string
+	S3PathStyle  bool
+	MaxImageSize int
+}
+
+//ReservationConfig stock reservation, TTL used when the request has none, MaxTTL upper limit of a requested ttl,
+//SweepInterval how often stale reservations are expired, MaxOpen active reservations a customer may hold
+type ReservationConfig struct {
+	TTL           time.Duration
+	MaxTTL        time.Duration
+	SweepInterval time.Duration
+	MaxOpen       int
+}
+
+//PriceConfig ScheduleInterval how often due price schedules are applied,
+//Currency ISO 4217 code of products created without one
+type PriceConfig struct {
+	ScheduleInterval time.Duration
+	Currency         string
+}
+
+//AuthConfig jwt authentication, KeysPath holds <kid>.pem private and <kid>.pub.pem public keys,
+//Kid names the key signing new tokens, keys of older kids only verify.
+//APIKeySecret keys the hmac of stored api keys, changing it invalidates every api key
+type AuthConfig struct {
+	KeysPath     string
+	Kid          string
+	Issuer       string
+	AccessTTL    time.Duration
+	RefreshTTL   time.Duration
+	APIKeySecret string
+}
+
+//TenantConfig how the tenant of a request is found, Domain set (e.g. shop.example.com) takes the tenant
+//from the subdomain (acme.shop.example.com), else the Header is read
+type TenantConfig struct {
+	Header string
+	Domain string
+}
+
+//MailConfig smtp server used for notifications, Host empty disables mail.
+//AlertTo receives the low stock alerts checked every LowStockInterval
+type MailConfig struct {
+	Host             string
+	Port             int
+	Username         string
+	Password         string
+	From             string
+	AlertTo          []string
+	LowStockInterval time.Duration
+}
+
+//IDConfig record id generation, Generator is one of ulid, uuidv7 or snowflake
+type IDConfig struct {
+	Generator string
+	Node      int
 }
 
 //EnvDBConfig database configuration which to be extract from env vars
@@ -71,6 +173,7 @@ type DBConfig struct {
 	ConnectTimeOut int
 	MaxOpenConn    int
 	MaxIdleConn    int
+	AutoMigrate    bool
 }
 
 // func GetPortalConfiguration() (PortalConfiguration, error) {
@@ -148,6 +251,53 @@ func GetRepositoryConfiguration() (RepositoryConfiguration, error) {
 			ConnectTimeOut: fx.EnvInt("DB_CONNECT_TIMEOUT"),
 			MaxOpenConn:    fx.EnvInt("DB_MAX_OPEN_CONN"),
 			MaxIdleConn:    fx.EnvInt("DB_MAX_IDLE_CONN"),
+			AutoMigrate:    fx.EnvBool("DB_AUTO_MIGRATE"),
+		},
+		ID: IDConfig{
+			Generator: fx.EnvString("ID_GENERATOR"),
+			Node:      fx.EnvInt("ID_NODE"),
+		},
+		Storage: StorageConfig{
+			Backend:      fx.EnvString("STORAGE_BACKEND"),
+			LocalPath:    fx.EnvString("STORAGE_LOCAL_PATH"),
+			PublicURL:    fx.EnvString("STORAGE_PUBLIC_URL"),
+			S3Endpoint:   fx.EnvString("S3_ENDPOINT"),
+			S3Region:     fx.EnvString("S3_REGION"),
+			S3Bucket:     fx.EnvString("S3_BUCKET"),
+			S3AccessKey:  fx.EnvString("S3_ACCESS_KEY"),
+			S3SecretKey:  fx.EnvString("S3_SECRET_KEY"),
+			S3PathStyle:  fx.EnvBool("S3_PATH_STYLE"),
+			MaxImageSize: fx.EnvInt("IMAGE_MAX_SIZE"),
+		},
+		Mail: MailConfig{
+			Host:             fx.EnvString("MAIL_HOST"),
+			Port:             fx.EnvInt("MAIL_PORT"),
+			Username:         fx.EnvString("MAIL_USERNAME"),
+			Password:         fx.EnvString("MAIL_PASSWORD"),
+			From:             fx.EnvString("MAIL_FROM"),
+			LowStockInterval: time.Duration(fx.EnvInt("LOW_STOCK_INTERVAL")) * time.Second,
+		},
+		Reserve: ReservationConfig{
+			TTL:           time.Duration(fx.EnvInt("RESERVATION_TTL")) * time.Second,
+			MaxTTL:        time.Duration(fx.EnvInt("RESERVATION_MAX_TTL")) * time.Second,
+			SweepInterval: time.Duration(fx.EnvInt("RESERVATION_SWEEP_INTERVAL")) * time.Second,
+			MaxOpen:       fx.EnvInt("RESERVATION_MAX_OPEN"),
+		},
+		Price: PriceConfig{
+			ScheduleInterval: time.Duration(fx.EnvInt("PRICE_SCHEDULE_INTERVAL")) * time.Second,
+			Currency:         fx.EnvString("DEFAULT_CURRENCY"),
+		},
+		Auth: AuthConfig{
+			KeysPath:     fx.EnvString("JWT_KEYS_PATH"),
+			Kid:          fx.EnvString("JWT_KID"),
+			Issuer:       fx.EnvString("JWT_ISSUER"),
+			AccessTTL:    time.Duration(fx.EnvInt("JWT_ACCESS_TTL")) * time.Second,
+			RefreshTTL:   time.Duration(fx.EnvInt("JWT_REFRESH_TTL")) * time.Second,
+			APIKeySecret: fx.EnvString("API_KEY_SECRET"),
+		},
+		Tenant: TenantConfig{
+			Header: fx.EnvString("TENANT_HEADER"),
+			Domain: fx.EnvString("TENANT_DOMAIN"),
 		},
 	}
 
@@ -176,8 +326,98 @@ func GetRepositoryConfiguration() (RepositoryConfiguration, error) {
 		cfg.DB.MaxIdleConn = 10
 	}
 
+	//default id generator
+	if cfg.ID.Generator == "" {
+		cfg.ID.Generator = fx.IDGeneratorULID
+	}
+
+	//default storage, files below ./uploads/ served by this service under /images
+	if cfg.Storage.Backend == "" {
+		cfg.Storage.Backend = "local"
+	}
+	if cfg.Storage.LocalPath == "" {
+		cfg.Storage.LocalPath = "./uploads/"
+	}
+	if cfg.Storage.PublicURL == "" && cfg.Storage.Backend == "local" {
+		cfg.Storage.PublicURL = "/images"
+	}
+
+	//default maximum image upload 5MB
+	if cfg.Storage.MaxImageSize == 0 {
+		cfg.Storage.MaxImageSize = 5 << 20
+	}
+
+	//default reservation 15 minutes, at most a day, swept every minute, 10 open per customer
+	if cfg.Reserve.TTL == 0 {
+		cfg.Reserve.TTL = 15 * time.Minute
+	}
+	if cfg.Reserve.MaxTTL == 0 {
+		cfg.Reserve.MaxTTL = 24 * time.Hour
+	}
+	if cfg.Reserve.SweepInterval == 0 {
+		cfg.Reserve.SweepInterval = time.Minute
+	}
+	if cfg.Reserve.MaxOpen == 0 {
+		cfg.Reserve.MaxOpen = 10
+	}
+
+	//price schedules applied every minute
+	if cfg.Price.ScheduleInterval == 0 {
+		cfg.Price.ScheduleInterval = time.Minute
+	}
+
+	//default currency, prices used to be whole rupiah
+	if cfg.Price.Currency == "" {
+		cfg.Price.Currency = "IDR"
+	}
+	currency, err := shared.Currency(cfg.Price.Currency)
+	if err != nil {
+		return RepositoryConfiguration{}, fmt.Errorf("DEFAULT_CURRENCY %w", err)
+	}
+	cfg.Price.Currency = currency
+
+	//jwt keys below ./keys/, access tokens live 15 minutes and refresh tokens 30 days
+	if cfg.Auth.KeysPath == "" {
+		cfg.Auth.KeysPath = "./keys/"
+	}
+	if cfg.Auth.Kid == "" {
+		return RepositoryConfiguration{}, fmt.Errorf("JWT_KID is required")
+	}
+	if cfg.Auth.Issuer == "" {
+		cfg.Auth.Issuer = cfg.App.Name
+	}
+	if cfg.Auth.AccessTTL == 0 {
+		cfg.Auth.AccessTTL = 15 * time.Minute
+	}
+	if cfg.Auth.RefreshTTL == 0 {
+		cfg.Auth.RefreshTTL = 30 * 24 * time.Hour
+	}
+
+	//api key hmac secret, no default so keys can't be forged with a known one
+	if len(cfg.Auth.APIKeySecret) < 32 {
+		return RepositoryConfiguration{}, fmt.Errorf("API_KEY_SECRET of at least 32 characters is required")
+	}
+
+	//tenant header, the subdomain is only used when TENANT_DOMAIN is set
+	if cfg.Tenant.Header == "" {
+		cfg.Tenant.Header = "X-Tenant-ID"
+	}
+	cfg.Tenant.Domain = strings.ToLower(strings.Trim(cfg.Tenant.Domain, ". "))
+
+	//mail defaults, alerts checked every 5 minutes
+	if cfg.Mail.Port == 0 {
+		cfg.Mail.Port = 25
+	}
+	if cfg.Mail.LowStockInterval == 0 {
+		cfg.Mail.LowStockInterval = 5 * time.Minute
+	}
+	for _, to := range strings.Split(fx.EnvString("LOW_STOCK_ALERT_TO"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			cfg.Mail.AlertTo = append(cfg.Mail.AlertTo, to)
+		}
+	}
+
 	//load location
-	var err error
 	cfg.App.Location, err = time.LoadLocation(cfg.App.Timezone)
 	if err != nil {
 		return RepositoryConfiguration{}, fmt.Errorf("can't load location %s (%w)", cfg.App.Timezone, err)
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//APIKeyMemory in-memory APIKeyRepository
+type APIKeyMemory struct {
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+func NewAPIKeyMemoryRepository() *APIKeyMemory {
+	return &APIKeyMemory{keys: map[string]APIKey{}}
+}
+
+func (r *APIKeyMemory) Create(k *APIKey, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[id]; ok {
+		return fmt.Errorf("duplicate api key id %s", id)
+	}
+	k.IDKey = id
+	stored := *k
+	stored.Scopes = sortedSet(k.Scopes)
+	r.keys[id] = stored
+	return nil
+}
+
+func (r *APIKeyMemory) GetByID(id string) (APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	k, ok := r.keys[id]
+	if !ok {
+		return APIKey{}, gorm.ErrRecordNotFound
+	}
+	return copyAPIKey(k), nil
+}
+
+func (r *APIKeyMemory) List(tenant string) ([]APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := []APIKey{}
+	for _, k := range r.keys {
+		if k.TenantID == tenant {
+			keys = append(keys, copyAPIKey(k))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedDate.Equal(keys[j].CreatedDate) {
+			return keys[i].CreatedDate.After(keys[j].CreatedDate)
+		}
+		return keys[i].IDKey > keys[j].IDKey
+	})
+	return keys, nil
+}
+
+func (r *APIKeyMemory) Revoke(id string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if stored.RevokedAt == nil {
+		stored.RevokedAt = &revokedAt
+		r.keys[id] = stored
+	}
+	return nil
+}
+
+func (r *APIKeyMemory) Touch(id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[id]
+	if ok {
+		stored.LastUsedAt = &usedAt
+		r.keys[id] = stored
+	}
+	return nil
+}
+
+func copyAPIKey(k APIKey) APIKey {
+	k.Scopes = append([]string{}, k.Scopes...)
+	return k
+}
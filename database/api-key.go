@@ -0,0 +1,126 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//APIKey key of a service caller, KeyHash is the hmac of its secret, the key itself is never stored
+type APIKey struct {
+	IDKey       string     `gorm:"column:id_key;type:varchar(36)"`
+	TenantID    string     `gorm:"column:tenant_id;type:varchar(36)"`
+	Name        string     `gorm:"column:name;type:varchar(50)"`
+	KeyHash     string     `gorm:"column:key_hash;type:char(64)"`
+	CreatedBy   *string    `gorm:"column:created_by;type:varchar(36)"`
+	ExpiresAt   *time.Time `gorm:"column:expires_datetime"`
+	LastUsedAt  *time.Time `gorm:"column:last_used_datetime"`
+	RevokedAt   *time.Time `gorm:"column:revoked_datetime"`
+	CreatedDate time.Time  `gorm:"column:created_datetime"`
+
+	Scopes []string `gorm:"-"`
+}
+
+//APIKeyRepository api key storage, missing keys are reported as gorm.ErrRecordNotFound, keys are read with their scopes
+type APIKeyRepository interface {
+	Create(k *APIKey, newID func() (string, error)) error
+	GetByID(id string) (APIKey, error)
+	List(tenant string) ([]APIKey, error)
+	Revoke(id string, revokedAt time.Time) error
+	Touch(id string, usedAt time.Time) error
+}
+
+//APIKeyGorm postgres APIKeyRepository
+type APIKeyGorm struct {
+	DB *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return APIKeyGorm{DB: db}
+}
+
+//Create inserts the key with its scopes
+func (r APIKeyGorm) Create(k *APIKey, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	k.IDKey = id
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("api_key").Create(k).Error; err != nil {
+			return err
+		}
+		for _, scope := range k.Scopes {
+			sql := "insert into api_key_scope (id_key, scope) values (?, ?) on conflict do nothing"
+			if err := tx.Exec(sql, k.IDKey, scope).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r APIKeyGorm) GetByID(id string) (APIKey, error) {
+	k := APIKey{}
+	if err := r.DB.Table("api_key").Where("id_key=?", id).Take(&k).Error; err != nil {
+		return APIKey{}, err
+	}
+	keys, err := r.withScopes([]APIKey{k})
+	if err != nil {
+		return APIKey{}, err
+	}
+	return keys[0], nil
+}
+
+//List every key of the tenant newest first, revoked and expired keys included
+func (r APIKeyGorm) List(tenant string) ([]APIKey, error) {
+	keys := []APIKey{}
+	if err := r.DB.Table("api_key").Where("tenant_id=?", tenant).Order("created_datetime desc, id_key desc").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return r.withScopes(keys)
+}
+
+//Revoke revokes the key, revoking it again keeps the first revoked_datetime
+func (r APIKeyGorm) Revoke(id string, revokedAt time.Time) error {
+	sql := "update api_key set revoked_datetime=coalesce(revoked_datetime, ?) where id_key=?"
+	result := r.DB.Exec(sql, revokedAt, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+//Touch records the last use of the key
+func (r APIKeyGorm) Touch(id string, usedAt time.Time) error {
+	return r.DB.Exec("update api_key set last_used_datetime=? where id_key=?", usedAt, id).Error
+}
+
+func (r APIKeyGorm) withScopes(keys []APIKey) ([]APIKey, error) {
+	if len(keys) == 0 {
+		return keys, nil
+	}
+	ids := []string{}
+	for _, k := range keys {
+		ids = append(ids, k.IDKey)
+	}
+	rows := []struct {
+		IDKey string `gorm:"column:id_key"`
+		Scope string `gorm:"column:scope"`
+	}{}
+	if err := r.DB.Table("api_key_scope").Where("id_key in ?", ids).Order("id_key, scope").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	scopes := map[string][]string{}
+	for _, row := range rows {
+		scopes[row.IDKey] = append(scopes[row.IDKey], row.Scope)
+	}
+	for i := range keys {
+		keys[i].Scopes = append([]string{}, scopes[keys[i].IDKey]...)
+	}
+	return keys, nil
+}
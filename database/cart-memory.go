@@ -0,0 +1,126 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//CartMemory in-memory CartRepository
+type CartMemory struct {
+	mu    sync.Mutex
+	carts map[string]Cart
+}
+
+func NewCartMemoryRepository() *CartMemory {
+	return &CartMemory{carts: map[string]Cart{}}
+}
+
+func (r *CartMemory) Create(c *Cart, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.IDCart = id
+	c.Items = []CartItem{}
+	r.carts[id] = copyCart(*c)
+	return nil
+}
+
+func (r *CartMemory) GetByID(id string) (Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.carts[id]
+	if !ok {
+		return Cart{}, gorm.ErrRecordNotFound
+	}
+	return copyCart(c), nil
+}
+
+func (r *CartMemory) AddItem(item CartItem) (CartItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.carts[item.IDCart]
+	if !ok {
+		return item, gorm.ErrRecordNotFound
+	}
+	for i := range c.Items {
+		if c.Items[i].IDProduct == item.IDProduct {
+			c.Items[i].Quantity += item.Quantity
+			item = c.Items[i]
+			c.UpdatedDate = item.CreatedDate
+			r.carts[c.IDCart] = c
+			return item, nil
+		}
+	}
+	c.Items = append(c.Items, item)
+	c.UpdatedDate = item.CreatedDate
+	r.carts[c.IDCart] = c
+	return item, nil
+}
+
+func (r *CartMemory) SetItem(item CartItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.carts[item.IDCart]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	c.UpdatedDate = item.CreatedDate
+	for i := range c.Items {
+		if c.Items[i].IDProduct == item.IDProduct {
+			c.Items[i].Quantity = item.Quantity
+			r.carts[c.IDCart] = c
+			return nil
+		}
+	}
+	c.Items = append(c.Items, item)
+	r.carts[c.IDCart] = c
+	return nil
+}
+
+func (r *CartMemory) RemoveItem(idCart, idProduct string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.carts[idCart]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	for i := range c.Items {
+		if c.Items[i].IDProduct == idProduct {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			c.UpdatedDate = now
+			r.carts[idCart] = c
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *CartMemory) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.carts[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.carts, id)
+	return nil
+}
+
+func copyCart(c Cart) Cart {
+	items := make([]CartItem, len(c.Items))
+	copy(items, c.Items)
+	sort.Slice(items, func(i, j int) bool { return items[i].IDProduct < items[j].IDProduct })
+	c.Items = items
+	return c
+}
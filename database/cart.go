@@ -0,0 +1,134 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//Cart shopping cart of the user IDCustomer with its items ordered by product id
+type Cart struct {
+	IDCart      string    `gorm:"column:id_cart;type:varchar(36)"`
+	TenantID    string    `gorm:"column:tenant_id;type:varchar(36)"`
+	IDCustomer  string    `gorm:"column:id_customer;type:varchar(36)"`
+	CreatedDate time.Time `gorm:"column:created_datetime"`
+	UpdatedDate time.Time `gorm:"column:updated_datetime"`
+
+	Items []CartItem `gorm:"-"`
+}
+
+//CartItem quantity of one product in a cart
+type CartItem struct {
+	IDCart      string    `gorm:"column:id_cart;type:varchar(36)"`
+	IDProduct   string    `gorm:"column:id_product;type:varchar(36)"`
+	Quantity    int       `gorm:"column:quantity;type:int"`
+	CreatedDate time.Time `gorm:"column:created_datetime"`
+}
+
+//CartRepository carts and their items, AddItem adds quantity to the item of the product and SetItem
+//replaces it, missing carts or items are reported as gorm.ErrRecordNotFound
+type CartRepository interface {
+	Create(c *Cart, newID func() (string, error)) error
+	GetByID(id string) (Cart, error)
+	AddItem(item CartItem) (CartItem, error)
+	SetItem(item CartItem) error
+	RemoveItem(idCart, idProduct string, now time.Time) error
+	Delete(id string) error
+}
+
+//CartGorm postgres CartRepository
+type CartGorm struct {
+	DB *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return CartGorm{DB: db}
+}
+
+func (r CartGorm) Create(c *Cart, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	c.IDCart = id
+	c.Items = []CartItem{}
+	return r.DB.Table("cart").Create(c).Error
+}
+
+func (r CartGorm) GetByID(id string) (Cart, error) {
+	c := Cart{}
+	if err := r.DB.Table("cart").Where("id_cart=?", id).Take(&c).Error; err != nil {
+		return c, err
+	}
+	c.Items = []CartItem{}
+	err := r.DB.Table("cart_item").Where("id_cart=?", id).Order("id_product").Find(&c.Items).Error
+	return c, err
+}
+
+//AddItem returns the item with its new quantity
+func (r CartGorm) AddItem(item CartItem) (CartItem, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := touchCart(tx, item.IDCart, item.CreatedDate); err != nil {
+			return err
+		}
+		sql := `insert into cart_item (id_cart, id_product, quantity, created_datetime) values (?, ?, ?, ?)
+			on conflict (id_cart, id_product) do update set quantity = cart_item.quantity + excluded.quantity`
+		if err := tx.Exec(sql, item.IDCart, item.IDProduct, item.Quantity, item.CreatedDate).Error; err != nil {
+			return err
+		}
+		return tx.Table("cart_item").Where("id_cart=? and id_product=? and id_cart in (?)", item.IDCart, item.IDProduct,
+			tx.Table("cart").Select("id_cart")).Take(&item).Error
+	})
+	return item, err
+}
+
+func (r CartGorm) SetItem(item CartItem) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := touchCart(tx, item.IDCart, item.CreatedDate); err != nil {
+			return err
+		}
+		sql := `insert into cart_item (id_cart, id_product, quantity, created_datetime) values (?, ?, ?, ?)
+			on conflict (id_cart, id_product) do update set quantity = excluded.quantity`
+		return tx.Exec(sql, item.IDCart, item.IDProduct, item.Quantity, item.CreatedDate).Error
+	})
+}
+
+func (r CartGorm) RemoveItem(idCart, idProduct string, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := touchCart(tx, idCart, now); err != nil {
+			return err
+		}
+		result := tx.Exec("delete from cart_item where id_cart=? and id_product=?", idCart, idProduct)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r CartGorm) Delete(id string) error {
+	result := r.DB.Table("cart").Where("id_cart=?", id).Delete(&Cart{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+//touchCart sets the updated time of the cart, a missing cart is gorm.ErrRecordNotFound
+func touchCart(tx *gorm.DB, id string, now time.Time) error {
+	result := tx.Table("cart").Where("id_cart=?", id).Update("updated_datetime", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
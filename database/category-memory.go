@@ -0,0 +1,132 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+//CategoryMemory in-memory CategoryRepository safe for concurrent use
+type CategoryMemory struct {
+	mu         sync.RWMutex
+	categories map[string]Category
+}
+
+func NewCategoryMemoryRepository() *CategoryMemory {
+	return &CategoryMemory{categories: map[string]Category{}}
+}
+
+func (r *CategoryMemory) Create(c *Category, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var parent *Category
+	if c.ParentID != nil {
+		p, ok := r.categories[*c.ParentID]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		parent = &p
+	}
+	if _, ok := r.categories[id]; ok {
+		return fmt.Errorf("duplicate category id %s", id)
+	}
+	c.IDCategory = id
+	c.Path = categoryPath(parent, id)
+	r.categories[id] = *c
+	return nil
+}
+
+func (r *CategoryMemory) GetByID(id string) (Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.categories[id]
+	if !ok {
+		return Category{}, gorm.ErrRecordNotFound
+	}
+	return c, nil
+}
+
+func (r *CategoryMemory) List() ([]Category, error) {
+	return r.withPrefix("")
+}
+
+func (r *CategoryMemory) Descendants(id string) ([]Category, error) {
+	c, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.withPrefix(c.Path)
+}
+
+func (r *CategoryMemory) withPrefix(prefix string) ([]Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := []Category{}
+	for _, c := range r.categories {
+		if strings.HasPrefix(c.Path, prefix) {
+			categories = append(categories, c)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Path < categories[j].Path })
+	return categories, nil
+}
+
+func (r *CategoryMemory) Update(c *Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.categories[c.IDCategory]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	var parent *Category
+	if c.ParentID != nil {
+		p, ok := r.categories[*c.ParentID]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		if strings.HasPrefix(p.Path, old.Path) {
+			return ErrCategoryCycle
+		}
+		parent = &p
+	}
+	c.Path = categoryPath(parent, c.IDCategory)
+	c.CreatedDate = old.CreatedDate
+
+	for id, child := range r.categories {
+		if strings.HasPrefix(child.Path, old.Path) {
+			child.Path = c.Path + child.Path[len(old.Path):]
+			r.categories[id] = child
+		}
+	}
+	r.categories[c.IDCategory] = *c
+	return nil
+}
+
+func (r *CategoryMemory) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	for _, c := range r.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return ErrCategoryHasChildren
+		}
+	}
+	delete(r.categories, id)
+	return nil
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryCycle       = errors.New("category can't be moved under itself or its descendants")
+	ErrCategoryHasChildren = errors.New("category still has sub categories")
+)
+
+//Category node of the category tree, Path holds the ids from the root down to the category (/root/child/)
+type Category struct {
+	IDCategory  string    `gorm:"column:id_category;type:varchar(36)"`
+	TenantID    string    `gorm:"column:tenant_id;type:varchar(36)"`
+	Name        string    `gorm:"column:name;type:varchar(50)"`
+	ParentID    *string   `gorm:"column:parent_id;type:varchar(36)"`
+	Path        string    `gorm:"column:path;type:text"`
+	CreatedDate time.Time `gorm:"column:created_datetime"`
+	UpdatedDate time.Time `gorm:"column:updated_datetime"`
+}
+
+//CategoryRepository category tree storage, missing categories are reported as gorm.ErrRecordNotFound
+type CategoryRepository interface {
+	Create(c *Category, newID func() (string, error)) error
+	GetByID(id string) (Category, error)
+	List() ([]Category, error)
+	Descendants(id string) ([]Category, error)
+	Update(c *Category) error
+	Delete(id string) error
+}
+
+//categoryPath path of a category placed under parent (nil for a root category)
+func categoryPath(parent *Category, id string) string {
+	if parent == nil {
+		return "/" + id + "/"
+	}
+	return parent.Path + id + "/"
+}
+
+//CategoryGorm postgres CategoryRepository
+type CategoryGorm struct {
+	DB *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return CategoryGorm{DB: db}
+}
+
+func (r CategoryGorm) Create(c *Category, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	var parent *Category
+	if c.ParentID != nil {
+		p, err := r.GetByID(*c.ParentID)
+		if err != nil {
+			return err
+		}
+		parent = &p
+	}
+	c.IDCategory = id
+	c.Path = categoryPath(parent, id)
+
+	return r.DB.Table("category").Create(c).Error
+}
+
+func (r CategoryGorm) GetByID(id string) (Category, error) {
+	c := Category{}
+	err := r.DB.Table("category").Where("id_category=?", id).Take(&c).Error
+	return c, err
+}
+
+//List every category ordered by path so parents come before their children
+func (r CategoryGorm) List() ([]Category, error) {
+	categories := []Category{}
+	err := r.DB.Table("category").Order("path").Find(&categories).Error
+	return categories, err
+}
+
+//Descendants the category itself and everything below it
+func (r CategoryGorm) Descendants(id string) ([]Category, error) {
+	c, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := []Category{}
+	err = r.DB.Table("category").Where("path like ?", c.Path+"%").Order("path").Find(&categories).Error
+	return categories, err
+}
+
+//Update renames and/or moves the category, paths of the whole sub tree follow a move
+func (r CategoryGorm) Update(c *Category) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		repo := CategoryGorm{DB: tx}
+		old, err := repo.GetByID(c.IDCategory)
+		if err != nil {
+			return err
+		}
+
+		var parent *Category
+		if c.ParentID != nil {
+			p, err := repo.GetByID(*c.ParentID)
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(p.Path, old.Path) {
+				return ErrCategoryCycle
+			}
+			parent = &p
+		}
+		c.Path = categoryPath(parent, c.IDCategory)
+
+		values := map[string]interface{}{"name": c.Name, "parent_id": c.ParentID, "updated_datetime": c.UpdatedDate}
+		if err := tx.Table("category").Where("id_category=?", c.IDCategory).Updates(values).Error; err != nil {
+			return err
+		}
+		if c.Path == old.Path {
+			return nil
+		}
+
+		path := gorm.Expr("? || substr(path, ?)", c.Path, len(old.Path)+1)
+		return tx.Table("category").Where("path like ?", old.Path+"%").Update("path", path).Error
+	})
+}
+
+//Delete removes a leaf category, its product assignments are dropped with it
+func (r CategoryGorm) Delete(id string) error {
+	var children int64
+	if err := r.DB.Table("category").Where("parent_id=?", id).Count(&children).Error; err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrCategoryHasChildren
+	}
+
+	result := r.DB.Table("category").Where("id_category=?", id).Delete(&Category{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
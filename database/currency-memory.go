@@ -0,0 +1,99 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+//CurrencyMemory in-memory CurrencyRepository
+type CurrencyMemory struct {
+	mu     sync.Mutex
+	prices map[[2]string]ProductPrice
+	rates  map[[2]string]ExchangeRate
+}
+
+func NewCurrencyMemoryRepository() *CurrencyMemory {
+	return &CurrencyMemory{prices: map[[2]string]ProductPrice{}, rates: map[[2]string]ExchangeRate{}}
+}
+
+func (r *CurrencyMemory) SetPrice(p ProductPrice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prices[[2]string{p.IDProduct, p.Currency}] = p
+	return nil
+}
+
+func (r *CurrencyMemory) DeletePrice(idProduct, currency string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{idProduct, currency}
+	if _, ok := r.prices[key]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.prices, key)
+	return nil
+}
+
+func (r *CurrencyMemory) Prices(ids []string) ([]ProductPrice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	prices := []ProductPrice{}
+	for _, p := range r.prices {
+		if wanted[p.IDProduct] {
+			prices = append(prices, p)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].IDProduct != prices[j].IDProduct {
+			return prices[i].IDProduct < prices[j].IDProduct
+		}
+		return prices[i].Currency < prices[j].Currency
+	})
+	return prices, nil
+}
+
+func (r *CurrencyMemory) SetRate(rate ExchangeRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rates[[2]string{rate.Base, rate.Quote}] = rate
+	return nil
+}
+
+func (r *CurrencyMemory) DeleteRate(base, quote string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{base, quote}
+	if _, ok := r.rates[key]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.rates, key)
+	return nil
+}
+
+func (r *CurrencyMemory) Rates() ([]ExchangeRate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rates := []ExchangeRate{}
+	for _, rate := range r.rates {
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Base != rates[j].Base {
+			return rates[i].Base < rates[j].Base
+		}
+		return rates[i].Quote < rates[j].Quote
+	})
+	return rates, nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//ProductPrice price list entry, the price of a product in a currency other than its own, in minor units
+type ProductPrice struct {
+	IDProduct   string    `gorm:"column:id_product;type:varchar(36)"`
+	Currency    string    `gorm:"column:currency;type:char(3)"`
+	Price       int       `gorm:"column:price;type:bigint"`
+	UpdatedDate time.Time `gorm:"column:updated_datetime"`
+}
+
+//ExchangeRate units of Quote bought by one unit of Base, Rate is kept as the decimal text of the numeric column
+type ExchangeRate struct {
+	TenantID    string    `gorm:"column:tenant_id;type:varchar(36)"`
+	Base        string    `gorm:"column:base_currency;type:char(3)"`
+	Quote       string    `gorm:"column:quote_currency;type:char(3)"`
+	Rate        string    `gorm:"column:rate;type:numeric(30,12)"`
+	UpdatedDate time.Time `gorm:"column:updated_datetime"`
+}
+
+//CurrencyRepository price lists and exchange rates, Set* insert or replace the row,
+//Delete* of a missing row reports gorm.ErrRecordNotFound
+type CurrencyRepository interface {
+	SetPrice(p ProductPrice) error
+	DeletePrice(idProduct, currency string) error
+	Prices(ids []string) ([]ProductPrice, error)
+	SetRate(r ExchangeRate) error
+	DeleteRate(base, quote string) error
+	Rates() ([]ExchangeRate, error)
+}
+
+//CurrencyGorm postgres CurrencyRepository
+type CurrencyGorm struct {
+	DB *gorm.DB
+}
+
+func NewCurrencyRepository(db *gorm.DB) CurrencyRepository {
+	return CurrencyGorm{DB: db}
+}
+
+func (r CurrencyGorm) SetPrice(p ProductPrice) error {
+	return r.DB.Table("product_price").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_product"}, {Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "updated_datetime"}),
+	}).Create(&p).Error
+}
+
+func (r CurrencyGorm) DeletePrice(idProduct, currency string) error {
+	result := r.DB.Table("product_price").Where("id_product=? and currency=? and id_product in (?)", idProduct, currency, tenantProducts(r.DB)).
+		Delete(&ProductPrice{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r CurrencyGorm) Prices(ids []string) ([]ProductPrice, error) {
+	prices := []ProductPrice{}
+	if len(ids) == 0 {
+		return prices, nil
+	}
+	err := r.DB.Table("product_price").Where("id_product in ?", ids).Order("id_product, currency").Find(&prices).Error
+	return prices, err
+}
+
+func (r CurrencyGorm) SetRate(rate ExchangeRate) error {
+	return r.DB.Table("exchange_rate").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "base_currency"}, {Name: "quote_currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_datetime"}),
+	}).Create(&rate).Error
+}
+
+func (r CurrencyGorm) DeleteRate(base, quote string) error {
+	result := r.DB.Table("exchange_rate").Where("base_currency=? and quote_currency=?", base, quote).Delete(&ExchangeRate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r CurrencyGorm) Rates() ([]ExchangeRate, error) {
+	rates := []ExchangeRate{}
+	err := r.DB.Table("exchange_rate").Order("base_currency, quote_currency").Find(&rates).Error
+	return rates, err
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//LowStockMemory in-memory LowStockRepository working on the products of a ProductMemory
+type LowStockMemory struct {
+	mu       sync.Mutex
+	products *ProductMemory
+	alerts   map[string]LowStockAlert
+}
+
+func NewLowStockMemoryRepository(products *ProductMemory) *LowStockMemory {
+	return &LowStockMemory{products: products, alerts: map[string]LowStockAlert{}}
+}
+
+func (r *LowStockMemory) SetThreshold(id string, threshold int, updatedDate time.Time) error {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	p, ok := r.products.products[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	p.ReorderThreshold = threshold
+	p.UpdatedDate = updatedDate
+	r.products.products[id] = p
+	return nil
+}
+
+func (r *LowStockMemory) Pending() ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	products := []Product{}
+	for id, p := range r.products.products {
+		if _, alerted := r.alerts[id]; !alerted && lowStock(p) {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Quantity != products[j].Quantity {
+			return products[i].Quantity < products[j].Quantity
+		}
+		return products[i].IDProduct < products[j].IDProduct
+	})
+	return products, nil
+}
+
+func (r *LowStockMemory) MarkAlerted(alerts []LowStockAlert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range alerts {
+		if _, ok := r.alerts[a.IDProduct]; !ok {
+			r.alerts[a.IDProduct] = a
+		}
+	}
+	return nil
+}
+
+func (r *LowStockMemory) Recover() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	var recovered int64
+	for id := range r.alerts {
+		if p, ok := r.products.products[id]; !ok || !lowStock(p) {
+			delete(r.alerts, id)
+			recovered++
+		}
+	}
+	return recovered, nil
+}
+
+func lowStock(p Product) bool {
+	return p.Active && p.ReorderThreshold > 0 && p.Quantity < p.ReorderThreshold
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//LowStockAlert alert sent for a product whose quantity fell below its reorder threshold
+type LowStockAlert struct {
+	IDProduct string    `gorm:"column:id_product;type:varchar(36)"`
+	Quantity  int       `gorm:"column:quantity;type:int"`
+	Threshold int       `gorm:"column:threshold;type:int"`
+	AlertedAt time.Time `gorm:"column:alerted_at"`
+}
+
+//LowStockRepository reorder thresholds and the low stock alerts already sent. Pending lists active products
+//below their threshold not alerted yet, Recover forgets the alerts of products back at or above it
+type LowStockRepository interface {
+	SetThreshold(id string, threshold int, updatedDate time.Time) error
+	Pending() ([]Product, error)
+	MarkAlerted(alerts []LowStockAlert) error
+	Recover() (int64, error)
+}
+
+//LowStockGorm postgres LowStockRepository
+type LowStockGorm struct {
+	DB *gorm.DB
+}
+
+func NewLowStockRepository(db *gorm.DB) LowStockRepository {
+	return LowStockGorm{DB: db}
+}
+
+func (r LowStockGorm) SetThreshold(id string, threshold int, updatedDate time.Time) error {
+	values := map[string]interface{}{"reorder_threshold": threshold, "updated_datetime": updatedDate}
+	result := r.DB.Table("product").Where("id_product=?", id).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r LowStockGorm) Pending() ([]Product, error) {
+	products := []Product{}
+	err := r.DB.Table("product").
+		Where("active = true and reorder_threshold > 0 and quantity < reorder_threshold").
+		Where("not exists (select 1 from low_stock_alert a where a.id_product = product.id_product)").
+		Order("quantity, id_product").Find(&products).Error
+	return products, err
+}
+
+func (r LowStockGorm) MarkAlerted(alerts []LowStockAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	return r.DB.Table("low_stock_alert").Clauses(clause.OnConflict{DoNothing: true}).Create(&alerts).Error
+}
+
+func (r LowStockGorm) Recover() (int64, error) {
+	sql := `delete from low_stock_alert a using product p where a.id_product = p.id_product
+		and (p.quantity >= p.reorder_threshold or p.reorder_threshold = 0 or p.active = false)`
+	result := r.DB.Exec(sql)
+	return result.RowsAffected, result.Error
+}
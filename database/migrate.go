@@ -0,0 +1,223 @@
+package database
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+//migrationLockKey pg advisory lock key held while migrating so replicas don't race
+const migrationLockKey = 727001
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type schemaMigration struct {
+	Version   int       `gorm:"column:version"`
+	Name      string    `gorm:"column:name"`
+	Checksum  string    `gorm:"column:checksum"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return Migrator{}, err
+	}
+	return Migrator{db: db, migrations: migrations}, nil
+}
+
+//loadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs ordered by version
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	handleErr := func(err error) ([]Migration, error) {
+		return nil, fmt.Errorf("load migrations : %w", err)
+	}
+
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return handleErr(err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return handleErr(fmt.Errorf("unexpected file %s", entry.Name()))
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(files, "migrations/"+entry.Name())
+		if err != nil {
+			return handleErr(err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return handleErr(fmt.Errorf("version %d used by %s and %s", version, m.Name, match[2]))
+		}
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			m.Up = string(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return handleErr(fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name))
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+//Up applies every pending migration in one transaction and returns the applied ones
+func (m Migrator) Up() ([]Migration, error) {
+	applied := []Migration{}
+	err := m.locked(func(tx *gorm.DB, done map[int]schemaMigration) error {
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return fmt.Errorf("migration %d_%s up : %w", mig.Version, mig.Name, err)
+			}
+			record := schemaMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: time.Now()}
+			if err := tx.Table("schema_migrations").Create(&record).Error; err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+//Down rolls back the last steps applied migrations
+func (m Migrator) Down(steps int) ([]Migration, error) {
+	reverted := []Migration{}
+	err := m.locked(func(tx *gorm.DB, done map[int]schemaMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+			}
+			if err := tx.Exec(mig.Down).Error; err != nil {
+				return fmt.Errorf("migration %d_%s down : %w", mig.Version, mig.Name, err)
+			}
+			if err := tx.Exec("delete from schema_migrations where version=?", mig.Version).Error; err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+func (m Migrator) Status() ([]MigrationStatus, error) {
+	status := []MigrationStatus{}
+	err := m.locked(func(tx *gorm.DB, done map[int]schemaMigration) error {
+		for _, mig := range m.migrations {
+			record, ok := done[mig.Version]
+			status = append(status, MigrationStatus{
+				Version:   mig.Version,
+				Name:      mig.Name,
+				Applied:   ok,
+				AppliedAt: record.AppliedAt,
+			})
+		}
+		return nil
+	})
+	return status, err
+}
+
+//locked runs fn in a transaction holding the migration advisory lock, after checking
+//that every applied migration still has the checksum it was applied with
+func (m Migrator) locked(fn func(tx *gorm.DB, done map[int]schemaMigration) error) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("select pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+		sql := `create table if not exists schema_migrations (
+			version    int primary key,
+			name       varchar(100) not null,
+			checksum   char(64) not null,
+			applied_at timestamp not null default now()
+		)`
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+
+		records := []schemaMigration{}
+		if err := tx.Table("schema_migrations").Order("version").Find(&records).Error; err != nil {
+			return err
+		}
+
+		known := map[int]Migration{}
+		for _, mig := range m.migrations {
+			known[mig.Version] = mig
+		}
+		done := map[int]schemaMigration{}
+		for _, record := range records {
+			//versions unknown to this binary come from a newer release, leave them alone
+			if mig, ok := known[record.Version]; ok && mig.Checksum != record.Checksum {
+				return fmt.Errorf("migration %d_%s was changed after being applied (checksum mismatch)", mig.Version, mig.Name)
+			}
+			done[record.Version] = record
+		}
+
+		return fn(tx, done)
+	})
+	if err != nil {
+		return fmt.Errorf("migrate : %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS product (
+    id_product       varchar(36) NOT NULL,
+    product_name     varchar(25) NOT NULL,
+    price            int         NOT NULL DEFAULT 0,
+    description      text        NOT NULL DEFAULT '',
+    quantity         int         NOT NULL DEFAULT 0,
+    created_datetime timestamp   NOT NULL DEFAULT now(),
+    updated_datetime timestamp,
+    active           bool        NOT NULL DEFAULT true
+);
+
+-- tables restored from the old dump still have varchar(25) ids
+ALTER TABLE product ALTER COLUMN id_product TYPE varchar(36);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_id_product_key ON product (id_product);
+
+-- list sort columns, id_product is the keyset pagination tie breaker
+CREATE INDEX IF NOT EXISTS product_created_datetime_idx ON product (created_datetime, id_product);
+CREATE INDEX IF NOT EXISTS product_price_idx ON product (price, id_product);
+CREATE INDEX IF NOT EXISTS product_product_name_idx ON product (product_name, id_product);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS category (
+    id_category      varchar(36) PRIMARY KEY,
+    name             varchar(50) NOT NULL,
+    parent_id        varchar(36) REFERENCES category (id_category),
+    -- materialized path of ids from the root down to the category itself, e.g. /root/child/
+    path             text        NOT NULL,
+    created_datetime timestamp   NOT NULL DEFAULT now(),
+    updated_datetime timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS category_path_key ON category (path text_pattern_ops);
+CREATE INDEX IF NOT EXISTS category_parent_id_idx ON category (parent_id);
+
+CREATE TABLE IF NOT EXISTS product_category (
+    id_product  varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    id_category varchar(36) NOT NULL REFERENCES category (id_category) ON DELETE CASCADE,
+    PRIMARY KEY (id_product, id_category)
+);
+
+CREATE INDEX IF NOT EXISTS product_category_id_category_idx ON product_category (id_category);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS product_variant (
+    id_variant       varchar(36) PRIMARY KEY,
+    id_product       varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    sku              varchar(50) NOT NULL,
+    -- option values like {"size": "M", "color": "red"}
+    attributes       jsonb       NOT NULL DEFAULT '{}',
+    -- null means the variant sells at the product price
+    price            int,
+    quantity         int         NOT NULL DEFAULT 0,
+    created_datetime timestamp   NOT NULL DEFAULT now(),
+    updated_datetime timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_variant_sku_key ON product_variant (sku);
+CREATE INDEX IF NOT EXISTS product_variant_id_product_idx ON product_variant (id_product);
@@ -0,0 +1,2 @@
+-- storage key of the original product image, thumbnails live next to it
+ALTER TABLE product ADD COLUMN IF NOT EXISTS image_key varchar(200) NOT NULL DEFAULT '';
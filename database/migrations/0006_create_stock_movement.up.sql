@@ -0,0 +1,14 @@
+-- inventory ledger, every change of product.quantity is recorded here
+CREATE TABLE IF NOT EXISTS stock_movement (
+    id_movement      varchar(36)  PRIMARY KEY,
+    id_product       varchar(36)  NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    movement_type    varchar(20)  NOT NULL CHECK (movement_type IN ('receipt', 'sale', 'adjustment', 'return')),
+    -- signed change applied to product.quantity and the quantity right after it
+    quantity_change  int          NOT NULL,
+    quantity_after   int          NOT NULL CHECK (quantity_after >= 0),
+    reason           varchar(200) NOT NULL DEFAULT '',
+    actor            varchar(100) NOT NULL DEFAULT '',
+    created_datetime timestamp    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS stock_movement_id_product_idx ON stock_movement (id_product, created_datetime DESC, id_movement DESC);
@@ -0,0 +1,14 @@
+-- stock held for a checkout, active reservations lower the available quantity until
+-- they are confirmed (quantity decremented through the ledger), released or expired
+CREATE TABLE IF NOT EXISTS stock_reservation (
+    id_reservation   varchar(36) PRIMARY KEY,
+    id_product       varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    quantity         int         NOT NULL CHECK (quantity > 0),
+    status           varchar(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'confirmed', 'released', 'expired')),
+    expires_at       timestamp   NOT NULL,
+    created_datetime timestamp   NOT NULL DEFAULT now(),
+    updated_datetime timestamp
+);
+
+CREATE INDEX IF NOT EXISTS stock_reservation_active_product_idx ON stock_reservation (id_product) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS stock_reservation_active_expires_idx ON stock_reservation (expires_at) WHERE status = 'active';
@@ -0,0 +1,7 @@
+DELETE FROM stock_movement WHERE movement_type = 'transfer';
+ALTER TABLE stock_movement DROP CONSTRAINT IF EXISTS stock_movement_movement_type_check;
+ALTER TABLE stock_movement ADD CONSTRAINT stock_movement_movement_type_check
+    CHECK (movement_type IN ('receipt', 'sale', 'adjustment', 'return'));
+ALTER TABLE stock_movement DROP COLUMN IF EXISTS id_warehouse;
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouse;
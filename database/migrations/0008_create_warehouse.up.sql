@@ -0,0 +1,32 @@
+-- stock locations, product.quantity stays the total over every warehouse
+CREATE TABLE IF NOT EXISTS warehouse (
+    id_warehouse     varchar(36) PRIMARY KEY,
+    code             varchar(20) NOT NULL,
+    name             varchar(50) NOT NULL,
+    created_datetime timestamp   NOT NULL DEFAULT now(),
+    updated_datetime timestamp
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS warehouse_code_key ON warehouse (code);
+
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    id_warehouse varchar(36) NOT NULL REFERENCES warehouse (id_warehouse),
+    id_product   varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    quantity     int         NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    PRIMARY KEY (id_warehouse, id_product)
+);
+
+CREATE INDEX IF NOT EXISTS warehouse_stock_id_product_idx ON warehouse_stock (id_product);
+
+-- the stock held so far moves into the default warehouse
+INSERT INTO warehouse (id_warehouse, code, name) VALUES ('main', 'MAIN', 'Main warehouse') ON CONFLICT DO NOTHING;
+INSERT INTO warehouse_stock (id_warehouse, id_product, quantity)
+SELECT 'main', id_product, quantity FROM product WHERE quantity > 0
+ON CONFLICT DO NOTHING;
+
+-- movements happen at a warehouse, transfers move stock between two of them
+ALTER TABLE stock_movement ADD COLUMN IF NOT EXISTS id_warehouse varchar(36) REFERENCES warehouse (id_warehouse);
+UPDATE stock_movement SET id_warehouse = 'main' WHERE id_warehouse IS NULL;
+ALTER TABLE stock_movement DROP CONSTRAINT IF EXISTS stock_movement_movement_type_check;
+ALTER TABLE stock_movement ADD CONSTRAINT stock_movement_movement_type_check
+    CHECK (movement_type IN ('receipt', 'sale', 'adjustment', 'return', 'transfer'));
@@ -0,0 +1,10 @@
+-- products with quantity below reorder_threshold are reported by mail, 0 disables the alert
+ALTER TABLE product ADD COLUMN IF NOT EXISTS reorder_threshold int NOT NULL DEFAULT 0;
+
+-- alerts already sent, a row stays until the stock recovers so the alert isn't sent twice
+CREATE TABLE IF NOT EXISTS low_stock_alert (
+    id_product varchar(36) PRIMARY KEY REFERENCES product (id_product) ON DELETE CASCADE,
+    quantity   int         NOT NULL,
+    threshold  int         NOT NULL,
+    alerted_at timestamp   NOT NULL DEFAULT now()
+);
@@ -0,0 +1,28 @@
+-- every change of product.price, written by manual updates and by the price scheduler
+CREATE TABLE IF NOT EXISTS price_history (
+    id_price_history bigserial   PRIMARY KEY,
+    id_product       varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    old_price        int         NOT NULL,
+    new_price        int         NOT NULL,
+    source           varchar(20) NOT NULL CHECK (source IN ('manual', 'schedule')),
+    id_schedule      varchar(36),
+    changed_at       timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS price_history_id_product_idx ON price_history (id_product, changed_at DESC, id_price_history DESC);
+
+-- future prices, applied at effective_from and reverted to previous_price at effective_to
+CREATE TABLE IF NOT EXISTS price_schedule (
+    id_schedule      varchar(36) PRIMARY KEY,
+    id_product       varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    price            int         NOT NULL CHECK (price > 0),
+    effective_from   timestamptz NOT NULL,
+    effective_to     timestamptz CHECK (effective_to > effective_from),
+    status           varchar(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'ended', 'skipped', 'cancelled')),
+    previous_price   int,
+    created_datetime timestamptz NOT NULL DEFAULT now(),
+    updated_datetime timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS price_schedule_id_product_idx ON price_schedule (id_product, effective_from);
+CREATE INDEX IF NOT EXISTS price_schedule_pending_idx ON price_schedule (effective_from) WHERE status IN ('pending', 'active');
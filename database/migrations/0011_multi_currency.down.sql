@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS exchange_rate;
+DROP TABLE IF EXISTS product_price;
+
+-- back to whole rupiah, only meaningful for IDR products
+ALTER TABLE price_schedule
+    ALTER COLUMN price TYPE int USING round(price / 100.0)::int,
+    ALTER COLUMN previous_price TYPE int USING round(previous_price / 100.0)::int;
+ALTER TABLE price_history
+    ALTER COLUMN old_price TYPE int USING round(old_price / 100.0)::int,
+    ALTER COLUMN new_price TYPE int USING round(new_price / 100.0)::int;
+ALTER TABLE product_variant ALTER COLUMN price TYPE int USING round(price / 100.0)::int;
+ALTER TABLE product ALTER COLUMN price TYPE int USING round(price / 100.0)::int;
+ALTER TABLE product DROP COLUMN IF EXISTS currency;
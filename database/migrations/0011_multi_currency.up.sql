@@ -0,0 +1,28 @@
+-- prices become minor units of the product currency (ISO 4217), existing prices are whole rupiah and IDR has 2 minor digits
+ALTER TABLE product ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE product ALTER COLUMN price TYPE bigint USING price::bigint * 100;
+ALTER TABLE product_variant ALTER COLUMN price TYPE bigint USING price::bigint * 100;
+ALTER TABLE price_history
+    ALTER COLUMN old_price TYPE bigint USING old_price::bigint * 100,
+    ALTER COLUMN new_price TYPE bigint USING new_price::bigint * 100;
+ALTER TABLE price_schedule
+    ALTER COLUMN price TYPE bigint USING price::bigint * 100,
+    ALTER COLUMN previous_price TYPE bigint USING previous_price::bigint * 100;
+
+-- price list, the price of a product in a currency other than its own
+CREATE TABLE IF NOT EXISTS product_price (
+    id_product       varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    currency         char(3)     NOT NULL,
+    price            bigint      NOT NULL CHECK (price > 0),
+    updated_datetime timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (id_product, currency)
+);
+
+-- units of quote_currency bought by one unit of base_currency, used for currencies without a price list entry
+CREATE TABLE IF NOT EXISTS exchange_rate (
+    base_currency    char(3)        NOT NULL,
+    quote_currency   char(3)        NOT NULL CHECK (quote_currency <> base_currency),
+    rate             numeric(30,12) NOT NULL CHECK (rate > 0),
+    updated_datetime timestamptz    NOT NULL DEFAULT now(),
+    PRIMARY KEY (base_currency, quote_currency)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS promotion_target;
+DROP TABLE IF EXISTS promotion;
@@ -0,0 +1,30 @@
+-- discounts, value is the percent for percentage and the amount in minor units of currency for fixed,
+-- buy_x_get_y gives get_quantity free units for every buy_quantity + get_quantity units of a line
+CREATE TABLE IF NOT EXISTS promotion (
+    id_promotion     varchar(36)  PRIMARY KEY,
+    name             varchar(100) NOT NULL,
+    kind             varchar(20)  NOT NULL CHECK (kind IN ('percentage', 'fixed', 'buy_x_get_y')),
+    value            bigint       NOT NULL DEFAULT 0,
+    currency         varchar(3)   NOT NULL DEFAULT '',
+    buy_quantity     int          NOT NULL DEFAULT 0,
+    get_quantity     int          NOT NULL DEFAULT 0,
+    -- promotions with a coupon code only apply when the code is given
+    coupon_code      varchar(50),
+    starts_at        timestamptz  NOT NULL,
+    ends_at          timestamptz  CHECK (ends_at > starts_at),
+    active           bool         NOT NULL DEFAULT true,
+    created_datetime timestamptz  NOT NULL DEFAULT now(),
+    updated_datetime timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS promotion_coupon_code_key ON promotion (coupon_code);
+CREATE INDEX IF NOT EXISTS promotion_starts_at_idx ON promotion (starts_at) WHERE active;
+
+-- products and categories a promotion is limited to, a category also covers its sub categories,
+-- a promotion without target applies to every product
+CREATE TABLE IF NOT EXISTS promotion_target (
+    id_promotion varchar(36) NOT NULL REFERENCES promotion (id_promotion) ON DELETE CASCADE,
+    target_type  varchar(10) NOT NULL CHECK (target_type IN ('product', 'category')),
+    id_target    varchar(36) NOT NULL,
+    PRIMARY KEY (id_promotion, target_type, id_target)
+);
@@ -0,0 +1,48 @@
+-- shopping carts, an item is one product with its quantity
+CREATE TABLE IF NOT EXISTS cart (
+    id_cart          varchar(36) PRIMARY KEY,
+    created_datetime timestamptz NOT NULL DEFAULT now(),
+    updated_datetime timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS cart_item (
+    id_cart          varchar(36) NOT NULL REFERENCES cart (id_cart) ON DELETE CASCADE,
+    id_product       varchar(36) NOT NULL REFERENCES product (id_product) ON DELETE CASCADE,
+    quantity         int         NOT NULL CHECK (quantity > 0),
+    created_datetime timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (id_cart, id_product)
+);
+
+-- placed orders, amounts in minor units of currency (every line of an order has the same currency),
+-- placing an order takes its units out of the stock ledger and cancelling puts them back
+CREATE TABLE IF NOT EXISTS orders (
+    id_order           varchar(36) PRIMARY KEY,
+    status             varchar(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'shipped', 'cancelled')),
+    currency           char(3)     NOT NULL,
+    subtotal           bigint      NOT NULL,
+    discount           bigint      NOT NULL DEFAULT 0,
+    total              bigint      NOT NULL,
+    coupon_code        varchar(50),
+    created_datetime   timestamptz NOT NULL DEFAULT now(),
+    updated_datetime   timestamptz,
+    paid_datetime      timestamptz,
+    shipped_datetime   timestamptz,
+    cancelled_datetime timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (status, created_datetime DESC);
+
+-- order lines keep the name, price and promotion of the product at purchase time
+CREATE TABLE IF NOT EXISTS order_line (
+    id_order       varchar(36)  NOT NULL REFERENCES orders (id_order) ON DELETE CASCADE,
+    id_product     varchar(36)  NOT NULL REFERENCES product (id_product),
+    product_name   varchar(100) NOT NULL,
+    quantity       int          NOT NULL CHECK (quantity > 0),
+    unit_price     bigint       NOT NULL,
+    discount       bigint       NOT NULL DEFAULT 0,
+    total          bigint       NOT NULL,
+    id_promotion   varchar(36),
+    promotion_name varchar(100),
+    promotion_kind varchar(20),
+    PRIMARY KEY (id_order, id_product)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS wallet_entry;
+DROP TABLE IF EXISTS wallet_transaction;
+DROP TABLE IF EXISTS wallet;
@@ -0,0 +1,39 @@
+-- customer wallets, balance in minor units of currency, it only changes with a wallet_transaction
+CREATE TABLE IF NOT EXISTS wallet (
+    id_customer      varchar(36) PRIMARY KEY,
+    currency         char(3)     NOT NULL,
+    balance          bigint      NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    created_datetime timestamptz NOT NULL DEFAULT now(),
+    updated_datetime timestamptz
+);
+
+-- deposits, withdrawals and order payments, a retried request with the same idempotency key of a
+-- customer gets the stored transaction back instead of moving money twice
+CREATE TABLE IF NOT EXISTS wallet_transaction (
+    id_transaction   varchar(36)  PRIMARY KEY,
+    id_customer      varchar(36)  NOT NULL REFERENCES wallet (id_customer),
+    idempotency_key  varchar(100) NOT NULL,
+    kind             varchar(20)  NOT NULL CHECK (kind IN ('deposit', 'withdrawal', 'payment')),
+    amount           bigint       NOT NULL CHECK (amount > 0),
+    currency         char(3)      NOT NULL,
+    balance_after    bigint       NOT NULL,
+    id_order         varchar(36)  REFERENCES orders (id_order),
+    created_datetime timestamptz  NOT NULL DEFAULT now(),
+    CONSTRAINT wallet_transaction_idempotency_key UNIQUE (id_customer, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS wallet_transaction_customer_idx ON wallet_transaction (id_customer, created_datetime DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_transaction_order_key ON wallet_transaction (id_order) WHERE kind = 'payment';
+
+-- double-entry ledger, the entries of a transaction sum to zero. Accounts are wallet:<id_customer>,
+-- cash (money coming in and out of the wallets) and sales (order payments)
+CREATE TABLE IF NOT EXISTS wallet_entry (
+    id_entry         bigserial   PRIMARY KEY,
+    id_transaction   varchar(36) NOT NULL REFERENCES wallet_transaction (id_transaction),
+    account          varchar(50) NOT NULL,
+    amount           bigint      NOT NULL CHECK (amount <> 0),
+    created_datetime timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS wallet_entry_transaction_idx ON wallet_entry (id_transaction);
+CREATE INDEX IF NOT EXISTS wallet_entry_account_idx ON wallet_entry (account);
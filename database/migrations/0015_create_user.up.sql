@@ -0,0 +1,17 @@
+-- user accounts, password holds the bcrypt hash, username and email are unique ignoring case,
+-- deactivated accounts keep their row so the username and email stay taken
+CREATE TABLE IF NOT EXISTS users (
+    id_user              varchar(36)  PRIMARY KEY,
+    username             varchar(20)  NOT NULL,
+    email                varchar(50)  NOT NULL,
+    password             varchar(100) NOT NULL,
+    full_name            varchar(50)  NOT NULL,
+    phone                varchar(45)  NOT NULL DEFAULT '',
+    active               boolean      NOT NULL DEFAULT true,
+    created_datetime     timestamptz  NOT NULL DEFAULT now(),
+    updated_datetime     timestamptz,
+    deactivated_datetime timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_key ON users (lower(username));
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (lower(email));
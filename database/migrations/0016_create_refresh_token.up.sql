@@ -0,0 +1,11 @@
+-- issued refresh tokens by jti, a refresh revokes the token it used and points replaced_by at the new one
+CREATE TABLE IF NOT EXISTS refresh_token (
+    id_token         varchar(36) PRIMARY KEY,
+    id_user          varchar(36) NOT NULL REFERENCES users (id_user) ON DELETE CASCADE,
+    expires_datetime timestamptz NOT NULL,
+    created_datetime timestamptz NOT NULL DEFAULT now(),
+    revoked_datetime timestamptz,
+    replaced_by      varchar(36)
+);
+
+CREATE INDEX IF NOT EXISTS refresh_token_user_idx ON refresh_token (id_user);
@@ -0,0 +1,8 @@
+-- roles granted to users, existing users keep reading the catalog as viewers
+CREATE TABLE IF NOT EXISTS user_role (
+    id_user varchar(36) NOT NULL REFERENCES users (id_user) ON DELETE CASCADE,
+    role    varchar(30) NOT NULL CHECK (role IN ('admin', 'catalog-manager', 'viewer')),
+    PRIMARY KEY (id_user, role)
+);
+
+INSERT INTO user_role (id_user, role) SELECT id_user, 'viewer' FROM users ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+-- api keys of service callers, key_hash is the hex hmac-sha256 of the secret part of the key,
+-- scopes are the permissions the key grants
+CREATE TABLE IF NOT EXISTS api_key (
+    id_key             varchar(36) PRIMARY KEY,
+    name               varchar(50) NOT NULL,
+    key_hash           char(64)    NOT NULL,
+    created_by         varchar(36) REFERENCES users (id_user) ON DELETE SET NULL,
+    expires_datetime   timestamptz,
+    last_used_datetime timestamptz,
+    revoked_datetime   timestamptz,
+    created_datetime   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS api_key_scope (
+    id_key varchar(36) NOT NULL REFERENCES api_key (id_key) ON DELETE CASCADE,
+    scope  varchar(30) NOT NULL,
+    PRIMARY KEY (id_key, scope)
+);
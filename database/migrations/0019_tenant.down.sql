@@ -0,0 +1,11 @@
+ALTER TABLE api_key DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS product_created_datetime_idx;
+DROP INDEX IF EXISTS product_price_idx;
+DROP INDEX IF EXISTS product_product_name_idx;
+CREATE INDEX IF NOT EXISTS product_created_datetime_idx ON product (created_datetime, id_product);
+CREATE INDEX IF NOT EXISTS product_price_idx ON product (price, id_product);
+CREATE INDEX IF NOT EXISTS product_product_name_idx ON product (product_name, id_product);
+
+ALTER TABLE product DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,17 @@
+-- storefronts sharing the deployment, the products, users and api keys from before tenants belong to 'default'
+ALTER TABLE product ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE product ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- every product query is narrowed to a tenant, the list sort indexes get it as leading column
+DROP INDEX IF EXISTS product_created_datetime_idx;
+DROP INDEX IF EXISTS product_price_idx;
+DROP INDEX IF EXISTS product_product_name_idx;
+CREATE INDEX IF NOT EXISTS product_created_datetime_idx ON product (tenant_id, created_datetime, id_product);
+CREATE INDEX IF NOT EXISTS product_price_idx ON product (tenant_id, price, id_product);
+CREATE INDEX IF NOT EXISTS product_product_name_idx ON product (tenant_id, product_name, id_product);
+
+-- tokens and api keys carry the tenant of their user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default';
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE api_key ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default';
+ALTER TABLE api_key ALTER COLUMN tenant_id DROP DEFAULT;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS wallet_transaction_refund_key;
+-- refunds already in the ledger stay, the old check only holds for new rows
+ALTER TABLE wallet_transaction DROP CONSTRAINT IF EXISTS wallet_transaction_kind_check;
+ALTER TABLE wallet_transaction ADD CONSTRAINT wallet_transaction_kind_check
+    CHECK (kind IN ('deposit', 'withdrawal', 'payment')) NOT VALID;
+
+DROP INDEX IF EXISTS orders_customer_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS id_customer;
@@ -0,0 +1,11 @@
+-- orders keep the customer who placed them, only the wallet of that customer can pay them.
+-- Orders from before have none and can't be paid from a wallet
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS id_customer varchar(36) NOT NULL DEFAULT '';
+ALTER TABLE orders ALTER COLUMN id_customer DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS orders_customer_idx ON orders (id_customer, created_datetime DESC);
+
+-- cancelling an order paid from a wallet refunds the payment to it, once per order
+ALTER TABLE wallet_transaction DROP CONSTRAINT IF EXISTS wallet_transaction_kind_check;
+ALTER TABLE wallet_transaction ADD CONSTRAINT wallet_transaction_kind_check
+    CHECK (kind IN ('deposit', 'withdrawal', 'payment', 'refund'));
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_transaction_refund_key ON wallet_transaction (id_order) WHERE kind = 'refund';
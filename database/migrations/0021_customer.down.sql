@@ -0,0 +1,5 @@
+ALTER TABLE cart DROP COLUMN IF EXISTS id_customer;
+
+DELETE FROM user_role WHERE role = 'customer';
+ALTER TABLE user_role DROP CONSTRAINT IF EXISTS user_role_role_check;
+ALTER TABLE user_role ADD CONSTRAINT user_role_role_check CHECK (role IN ('admin', 'catalog-manager', 'viewer'));
@@ -0,0 +1,7 @@
+-- customers shopping with their own carts, orders and wallet
+ALTER TABLE user_role DROP CONSTRAINT IF EXISTS user_role_role_check;
+ALTER TABLE user_role ADD CONSTRAINT user_role_role_check CHECK (role IN ('admin', 'catalog-manager', 'customer', 'viewer'));
+
+-- carts belong to the user who created them, carts from before have none and are only open to orders:manage
+ALTER TABLE cart ADD COLUMN IF NOT EXISTS id_customer varchar(36) NOT NULL DEFAULT '';
+ALTER TABLE cart ALTER COLUMN id_customer DROP DEFAULT;
@@ -0,0 +1,25 @@
+-- rows of the other tenants would collide once the keys are global again
+DELETE FROM exchange_rate WHERE tenant_id <> 'default';
+
+DROP INDEX IF EXISTS promotion_tenant_id_idx;
+DROP INDEX IF EXISTS category_tenant_id_idx;
+DROP INDEX IF EXISTS orders_status_idx;
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (status, created_datetime DESC);
+
+ALTER TABLE exchange_rate DROP CONSTRAINT IF EXISTS exchange_rate_pkey;
+ALTER TABLE exchange_rate ADD CONSTRAINT exchange_rate_pkey PRIMARY KEY (base_currency, quote_currency);
+DROP INDEX IF EXISTS promotion_coupon_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS promotion_coupon_code_key ON promotion (coupon_code);
+DROP INDEX IF EXISTS warehouse_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS warehouse_code_key ON warehouse (code);
+DROP INDEX IF EXISTS product_variant_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS product_variant_sku_key ON product_variant (sku);
+
+ALTER TABLE wallet DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE cart DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE promotion DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE exchange_rate DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE warehouse DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE category DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE product_variant DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,40 @@
+-- catalog, promotions, carts, orders and wallets belong to a tenant as well, the rows from before belong to 'default'
+ALTER TABLE product_variant ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE product_variant ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE category ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE category ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE warehouse ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE warehouse ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE exchange_rate ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE exchange_rate ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE promotion ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE promotion ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE cart ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE cart ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE orders ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE wallet ADD COLUMN IF NOT EXISTS tenant_id varchar(36) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE wallet ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- rows hanging off a product or a customer of another tenant follow it
+UPDATE product_variant v SET tenant_id = p.tenant_id FROM product p WHERE p.id_product = v.id_product;
+UPDATE cart c SET tenant_id = u.tenant_id FROM users u WHERE u.id_user = c.id_customer;
+UPDATE orders o SET tenant_id = u.tenant_id FROM users u WHERE u.id_user = o.id_customer;
+UPDATE wallet w SET tenant_id = u.tenant_id FROM users u WHERE u.id_user = w.id_customer;
+
+-- skus, warehouse codes, coupon codes and exchange rates are only unique within a tenant,
+-- every tenant has its own MAIN warehouse, created on first use
+DROP INDEX IF EXISTS product_variant_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS product_variant_sku_key ON product_variant (tenant_id, sku);
+DROP INDEX IF EXISTS warehouse_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS warehouse_code_key ON warehouse (tenant_id, code);
+DROP INDEX IF EXISTS promotion_coupon_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS promotion_coupon_code_key ON promotion (tenant_id, coupon_code);
+ALTER TABLE exchange_rate DROP CONSTRAINT IF EXISTS exchange_rate_pkey;
+ALTER TABLE exchange_rate ADD CONSTRAINT exchange_rate_pkey PRIMARY KEY (tenant_id, base_currency, quote_currency);
+
+-- order lists are read per tenant
+DROP INDEX IF EXISTS orders_status_idx;
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (tenant_id, status, created_datetime DESC);
+CREATE INDEX IF NOT EXISTS category_tenant_id_idx ON category (tenant_id);
+CREATE INDEX IF NOT EXISTS promotion_tenant_id_idx ON promotion (tenant_id);
@@ -0,0 +1,7 @@
+-- order lines keep the warehouse their units were sold from, cancelling puts them back there.
+-- Lines from before take it from the sale movement of the order
+ALTER TABLE order_line ADD COLUMN IF NOT EXISTS id_warehouse varchar(36) REFERENCES warehouse (id_warehouse);
+UPDATE order_line l SET id_warehouse = m.id_warehouse
+FROM stock_movement m
+WHERE l.id_warehouse IS NULL AND m.id_product = l.id_product
+    AND m.movement_type = 'sale' AND m.reason = 'order ' || l.id_order;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS stock_reservation_customer_idx;
+ALTER TABLE stock_reservation DROP COLUMN IF EXISTS id_customer;
@@ -0,0 +1,5 @@
+-- reservations belong to the user who made them and count against the open reservations allowed per customer,
+-- reservations from before have none and are only open to orders:manage
+ALTER TABLE stock_reservation ADD COLUMN IF NOT EXISTS id_customer varchar(36) NOT NULL DEFAULT '';
+ALTER TABLE stock_reservation ALTER COLUMN id_customer DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS stock_reservation_customer_idx ON stock_reservation (id_customer, status, expires_at);
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//OrderMemory in-memory OrderRepository working on the products of a ProductMemory, stock goes through
+//the StockMemory ledger and placed carts are deleted from the CartMemory. Cancelled orders are refunded
+//to the WalletMemory built over it
+type OrderMemory struct {
+	mu       sync.Mutex
+	orders   map[string]Order
+	products *ProductMemory
+	stock    *StockMemory
+	reserve  *ReservationMemory
+	carts    *CartMemory
+	wallets  *WalletMemory
+}
+
+func NewOrderMemoryRepository(products *ProductMemory, stock *StockMemory, reserve *ReservationMemory, carts *CartMemory) *OrderMemory {
+	return &OrderMemory{orders: map[string]Order{}, products: products, stock: stock, reserve: reserve, carts: carts}
+}
+
+func (r *OrderMemory) Place(o *Order, idCart, actor string, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := []string{}
+	products := []Product{}
+	for _, line := range o.Lines {
+		ids = append(ids, line.IDProduct)
+		if p, err := r.products.GetByID(line.IDProduct); err == nil {
+			products = append(products, p)
+		}
+	}
+	reserved, err := r.reserve.Reserved(ids, o.CreatedDate)
+	if err != nil {
+		return err
+	}
+	if err := checkOrderLines(o.Lines, products, reserved); err != nil {
+		return err
+	}
+	if _, ok := r.orders[id]; ok {
+		return fmt.Errorf("duplicate order id %s", id)
+	}
+
+	o.IDOrder = id
+	o.Status = OrderPending
+	o.UpdatedDate = o.CreatedDate
+	for i := range o.Lines {
+		o.Lines[i].IDOrder = id
+		movement := orderMovement(*o, o.Lines[i], MovementSale, actor)
+		if err := r.stock.Adjust(&movement, newID); err != nil {
+			return err
+		}
+		o.Lines[i].IDWarehouse = &movement.IDWarehouse
+	}
+	r.orders[id] = copyOrder(*o)
+	if idCart != "" {
+		r.carts.Delete(idCart)
+	}
+	return nil
+}
+
+func (r *OrderMemory) GetByID(id string) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orders[id]
+	if !ok {
+		return Order{}, gorm.ErrRecordNotFound
+	}
+	return copyOrder(o), nil
+}
+
+func (r *OrderMemory) List(customer, status string, page, size int) ([]Order, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	orders := []Order{}
+	for _, o := range r.orders {
+		if (customer == "" || o.IDCustomer == customer) && (status == "" || o.Status == status) {
+			orders = append(orders, copyOrder(o))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedDate.Equal(orders[j].CreatedDate) {
+			return orders[i].CreatedDate.After(orders[j].CreatedDate)
+		}
+		return orders[i].IDOrder > orders[j].IDOrder
+	})
+
+	total := int64(len(orders))
+	start := (page - 1) * size
+	if start > len(orders) {
+		start = len(orders)
+	}
+	end := start + size
+	if end > len(orders) {
+		end = len(orders)
+	}
+	return orders[start:end], total, nil
+}
+
+func (r *OrderMemory) Move(id, status, actor string, now time.Time, newID func() (string, error)) (Order, error) {
+	//the wallets before the orders, like WalletMemory.Apply
+	if status == OrderCancelled && r.wallets != nil {
+		r.wallets.mu.Lock()
+		defer r.wallets.mu.Unlock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orders[id]
+	if !ok {
+		return Order{}, gorm.ErrRecordNotFound
+	}
+	o = copyOrder(o)
+	if !o.CanMove(status) {
+		return o, fmt.Errorf("%w from %s to %s", ErrOrderTransition, o.Status, status)
+	}
+
+	paid := o.Status == OrderPaid
+	o.move(status, now)
+	if status == OrderCancelled {
+		for _, line := range o.Lines {
+			movement := orderMovement(o, line, MovementReturn, actor)
+			if err := r.stock.Adjust(&movement, newID); err != nil {
+				return o, err
+			}
+		}
+		if paid && r.wallets != nil {
+			if err := r.wallets.refund(o, newID); err != nil {
+				return o, err
+			}
+		}
+	}
+	r.orders[id] = copyOrder(o)
+	return o, nil
+}
+
+func copyOrder(o Order) Order {
+	lines := make([]OrderLine, len(o.Lines))
+	copy(lines, o.Lines)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].IDProduct < lines[j].IDProduct })
+	o.Lines = lines
+	return o
+}
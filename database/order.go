@@ -0,0 +1,323 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//order status
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderCancelled = "cancelled"
+)
+
+var (
+	ErrOrderTransition    = errors.New("order status can't change")
+	ErrOrderPriceChanged  = errors.New("product price changed, price the order again")
+	ErrProductUnavailable = errors.New("product is no longer available")
+)
+
+//orderTransitions next statuses allowed from each status, shipped and cancelled orders are closed
+var orderTransitions = map[string][]string{
+	OrderPending: {OrderPaid, OrderCancelled},
+	OrderPaid:    {OrderShipped, OrderCancelled},
+}
+
+//Order placed order, amounts in minor units of Currency, Subtotal - Discount = Total.
+//IDCustomer is the user who placed it, the owner of the only wallet that can pay it
+type Order struct {
+	IDOrder       string     `gorm:"column:id_order;type:varchar(36)"`
+	TenantID      string     `gorm:"column:tenant_id;type:varchar(36)"`
+	IDCustomer    string     `gorm:"column:id_customer;type:varchar(36)"`
+	Status        string     `gorm:"column:status;type:varchar(20)"`
+	Currency      string     `gorm:"column:currency;type:char(3)"`
+	Subtotal      int        `gorm:"column:subtotal;type:bigint"`
+	Discount      int        `gorm:"column:discount;type:bigint"`
+	Total         int        `gorm:"column:total;type:bigint"`
+	CouponCode    *string    `gorm:"column:coupon_code;type:varchar(50)"`
+	CreatedDate   time.Time  `gorm:"column:created_datetime"`
+	UpdatedDate   time.Time  `gorm:"column:updated_datetime"`
+	PaidDate      *time.Time `gorm:"column:paid_datetime"`
+	ShippedDate   *time.Time `gorm:"column:shipped_datetime"`
+	CancelledDate *time.Time `gorm:"column:cancelled_datetime"`
+
+	Lines []OrderLine `gorm:"-"`
+}
+
+//OrderLine product of an order with its name, price and promotion at purchase time
+type OrderLine struct {
+	IDOrder       string  `gorm:"column:id_order;type:varchar(36)"`
+	IDProduct     string  `gorm:"column:id_product;type:varchar(36)"`
+	ProductName   string  `gorm:"column:product_name;type:varchar(100)"`
+	Quantity      int     `gorm:"column:quantity;type:int"`
+	UnitPrice     int     `gorm:"column:unit_price;type:bigint"`
+	Discount      int     `gorm:"column:discount;type:bigint"`
+	Total         int     `gorm:"column:total;type:bigint"`
+	IDPromotion   *string `gorm:"column:id_promotion;type:varchar(36)"`
+	PromotionName *string `gorm:"column:promotion_name;type:varchar(100)"`
+	PromotionKind *string `gorm:"column:promotion_kind;type:varchar(20)"`
+
+	//IDWarehouse warehouse the units were sold from, nil on lines from before warehouses were kept
+	IDWarehouse *string `gorm:"column:id_warehouse;type:varchar(36)"`
+}
+
+//CanMove reports whether the order can go from its status to status
+func (o Order) CanMove(status string) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+//move sets the status with its time
+func (o *Order) move(status string, now time.Time) {
+	o.Status = status
+	o.UpdatedDate = now
+	switch status {
+	case OrderPaid:
+		o.PaidDate = &now
+	case OrderShipped:
+		o.ShippedDate = &now
+	case OrderCancelled:
+		o.CancelledDate = &now
+	}
+}
+
+//OrderRepository orders and their lines. Place checks every line against the product price and its
+//available quantity (quantity - active reservations), takes the units out through sale movements of the
+//stock ledger and deletes the cart the order came from, all in one transaction. Move changes the status,
+//a cancelled order gives its units back through return movements and the wallet payment of a paid one
+//back through a refund, in the same transaction. Missing orders are gorm.ErrRecordNotFound
+type OrderRepository interface {
+	Place(o *Order, idCart, actor string, newID func() (string, error)) error
+	GetByID(id string) (Order, error)
+	List(customer, status string, page, size int) ([]Order, int64, error)
+	Move(id, status, actor string, now time.Time, newID func() (string, error)) (Order, error)
+}
+
+//OrderGorm postgres OrderRepository
+type OrderGorm struct {
+	DB *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return OrderGorm{DB: db}
+}
+
+//Place locks the product rows in id order so concurrent orders of the same products are checked one after the other
+func (r OrderGorm) Place(o *Order, idCart, actor string, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		ids := []string{}
+		for _, line := range o.Lines {
+			ids = append(ids, line.IDProduct)
+		}
+		products := []Product{}
+		err := tx.Table("product").Clauses(clause.Locking{Strength: "UPDATE"}).Select("id_product, quantity, price, active").
+			Where("id_product in ?", ids).Order("id_product").Find(&products).Error
+		if err != nil {
+			return err
+		}
+		reserved, err := ReservationGorm{DB: tx}.Reserved(ids, o.CreatedDate)
+		if err != nil {
+			return err
+		}
+		if err := checkOrderLines(o.Lines, products, reserved); err != nil {
+			return err
+		}
+
+		o.IDOrder = id
+		o.Status = OrderPending
+		o.UpdatedDate = o.CreatedDate
+		for i := range o.Lines {
+			o.Lines[i].IDOrder = id
+			movement := orderMovement(*o, o.Lines[i], MovementSale, actor)
+			if err := (StockGorm{DB: tx}).Adjust(&movement, newID); err != nil {
+				return err
+			}
+			o.Lines[i].IDWarehouse = &movement.IDWarehouse
+		}
+		if err := tx.Table("orders").Create(o).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("order_line").Create(&o.Lines).Error; err != nil {
+			return err
+		}
+		if idCart == "" {
+			return nil
+		}
+		return tx.Table("cart").Where("id_cart=?", idCart).Delete(&Cart{}).Error
+	})
+}
+
+func (r OrderGorm) GetByID(id string) (Order, error) {
+	o := Order{}
+	if err := r.DB.Table("orders").Where("id_order=?", id).Take(&o).Error; err != nil {
+		return o, err
+	}
+	orders, err := withOrderLines(r.DB, []Order{o})
+	if err != nil {
+		return o, err
+	}
+	return orders[0], nil
+}
+
+//List orders newest first, of every customer or status when customer or status is empty
+func (r OrderGorm) List(customer, status string, page, size int) ([]Order, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	base := r.DB.Table("orders")
+	if customer != "" {
+		base = base.Where("id_customer=?", customer)
+	}
+	if status != "" {
+		base = base.Where("status=?", status)
+	}
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	orders := []Order{}
+	err := base.Session(&gorm.Session{}).Order("created_datetime desc, id_order desc").Offset((page - 1) * size).Limit(size).Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	orders, err = withOrderLines(r.DB, orders)
+	return orders, total, err
+}
+
+//Move cancelling locks the wallet of the customer before the order, in the order Apply takes them
+func (r OrderGorm) Move(id, status, actor string, now time.Time, newID func() (string, error)) (Order, error) {
+	o := Order{}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if status == OrderCancelled {
+			customer := tx.Table("orders").Select("id_customer").Where("id_order=?", id)
+			err := tx.Table("wallet").Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_customer in (?)", customer).Find(&[]Wallet{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		err := tx.Table("orders").Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_order=?", id).Take(&o).Error
+		if err != nil {
+			return err
+		}
+		orders, err := withOrderLines(tx, []Order{o})
+		if err != nil {
+			return err
+		}
+		o = orders[0]
+		if !o.CanMove(status) {
+			return fmt.Errorf("%w from %s to %s", ErrOrderTransition, o.Status, status)
+		}
+
+		paid := o.Status == OrderPaid
+		o.move(status, now)
+		if status == OrderCancelled {
+			for _, line := range o.Lines {
+				movement := orderMovement(o, line, MovementReturn, actor)
+				if err := (StockGorm{DB: tx}).Adjust(&movement, newID); err != nil {
+					return err
+				}
+			}
+			if paid {
+				if err := refundOrder(tx, o, newID); err != nil {
+					return err
+				}
+			}
+		}
+		values := map[string]interface{}{
+			"status":             o.Status,
+			"updated_datetime":   o.UpdatedDate,
+			"paid_datetime":      o.PaidDate,
+			"shipped_datetime":   o.ShippedDate,
+			"cancelled_datetime": o.CancelledDate,
+		}
+		return tx.Table("orders").Where("id_order=?", id).Updates(values).Error
+	})
+	return o, err
+}
+
+//withOrderLines loads the lines of the orders
+func withOrderLines(db *gorm.DB, orders []Order) ([]Order, error) {
+	if len(orders) == 0 {
+		return orders, nil
+	}
+	ids := []string{}
+	for _, o := range orders {
+		ids = append(ids, o.IDOrder)
+	}
+	lines := []OrderLine{}
+	if err := db.Table("order_line").Where("id_order in ?", ids).Order("id_order, id_product").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	byID := map[string][]OrderLine{}
+	for _, line := range lines {
+		byID[line.IDOrder] = append(byID[line.IDOrder], line)
+	}
+	for i := range orders {
+		orders[i].Lines = byID[orders[i].IDOrder]
+		if orders[i].Lines == nil {
+			orders[i].Lines = []OrderLine{}
+		}
+	}
+	return orders, nil
+}
+
+//checkOrderLines every line must be an active product still at the priced unit price with enough available units
+func checkOrderLines(lines []OrderLine, products []Product, reserved map[string]int) error {
+	byID := map[string]Product{}
+	for _, p := range products {
+		byID[p.IDProduct] = p
+	}
+	for _, line := range lines {
+		p, ok := byID[line.IDProduct]
+		if !ok || !p.Active {
+			return fmt.Errorf("product %s : %w", line.IDProduct, ErrProductUnavailable)
+		}
+		if p.Price != line.UnitPrice {
+			return fmt.Errorf("product %s : %w", line.IDProduct, ErrOrderPriceChanged)
+		}
+		if p.Quantity-reserved[line.IDProduct] < line.Quantity {
+			return fmt.Errorf("product %s : %w", line.IDProduct, ErrInsufficientStock)
+		}
+	}
+	return nil
+}
+
+//orderMovement stock ledger row of an order line, sale when placed and return when cancelled,
+//the return goes back to the warehouse of the sale
+func orderMovement(o Order, line OrderLine, movementType, actor string) StockMovement {
+	change, reason, warehouse := -line.Quantity, "order "+o.IDOrder, ""
+	if movementType == MovementReturn {
+		change, reason = line.Quantity, "order "+o.IDOrder+" cancelled"
+		if line.IDWarehouse != nil {
+			warehouse = *line.IDWarehouse
+		}
+	}
+	return StockMovement{
+		IDProduct:      line.IDProduct,
+		IDWarehouse:    warehouse,
+		MovementType:   movementType,
+		QuantityChange: change,
+		Reason:         reason,
+		Actor:          actor,
+		CreatedDate:    o.UpdatedDate,
+	}
+}
+
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//PriceMemory in-memory PriceRepository working on the products and price history of a ProductMemory
+type PriceMemory struct {
+	mu        sync.Mutex
+	products  *ProductMemory
+	schedules map[string]PriceSchedule
+}
+
+func NewPriceMemoryRepository(products *ProductMemory) *PriceMemory {
+	return &PriceMemory{products: products, schedules: map[string]PriceSchedule{}}
+}
+
+func (r *PriceMemory) History(idProduct string, page, size int) ([]PriceChange, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	changes := []PriceChange{}
+	r.products.mu.RLock()
+	for _, c := range r.products.prices {
+		if c.IDProduct == idProduct {
+			changes = append(changes, c)
+		}
+	}
+	r.products.mu.RUnlock()
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if !changes[i].ChangedAt.Equal(changes[j].ChangedAt) {
+			return changes[i].ChangedAt.After(changes[j].ChangedAt)
+		}
+		return changes[i].IDPriceHistory > changes[j].IDPriceHistory
+	})
+
+	total := int64(len(changes))
+	offset := (page - 1) * size
+	if offset > len(changes) {
+		offset = len(changes)
+	}
+	changes = changes[offset:]
+	if len(changes) > size {
+		changes = changes[:size]
+	}
+	return changes, total, nil
+}
+
+func (r *PriceMemory) CreateSchedule(s *PriceSchedule, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.products.GetByID(s.IDProduct); err != nil {
+		return err
+	}
+	for _, other := range r.schedules {
+		if other.IDProduct == s.IDProduct && (other.Status == SchedulePending || other.Status == ScheduleActive) && s.overlaps(other) {
+			return ErrScheduleOverlap
+		}
+	}
+
+	s.IDSchedule = id
+	s.Status = SchedulePending
+	r.schedules[id] = *s
+	return nil
+}
+
+func (r *PriceMemory) Schedules(idProduct string) ([]PriceSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedules := []PriceSchedule{}
+	for _, s := range r.schedules {
+		if s.IDProduct == idProduct {
+			schedules = append(schedules, s)
+		}
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+func (r *PriceMemory) CancelSchedule(id string, now time.Time) (PriceSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.schedules[id]
+	if !ok {
+		return PriceSchedule{}, gorm.ErrRecordNotFound
+	}
+	if s.Status != SchedulePending {
+		return s, ErrScheduleNotPending
+	}
+	s.Status = ScheduleCancelled
+	s.UpdatedDate = now
+	r.schedules[id] = s
+	return s, nil
+}
+
+func (r *PriceMemory) ApplySchedules(now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := []PriceSchedule{}
+	for _, s := range r.schedules {
+		if status, _ := s.next(now); status != s.Status {
+			due = append(due, s)
+		}
+	}
+	sortSchedules(due)
+
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+	changed := 0
+	failed := ScheduleErrors{}
+	for _, s := range due {
+		status, price := s.next(now)
+		p, ok := r.products.products[s.IDProduct]
+		if !ok {
+			failed = append(failed, fmt.Errorf("price schedule %s : %w", s.IDSchedule, gorm.ErrRecordNotFound))
+			continue
+		}
+		changed++
+		if status == ScheduleActive {
+			previous := p.Price
+			s.PreviousPrice = &previous
+		}
+		s.Status = status
+		s.UpdatedDate = now
+		r.schedules[s.IDSchedule] = s
+
+		if price == 0 || price == p.Price {
+			continue
+		}
+		id := s.IDSchedule
+		r.products.recordPrice(PriceChange{IDProduct: p.IDProduct, OldPrice: p.Price, NewPrice: price, Source: PriceSourceSchedule, IDSchedule: &id, ChangedAt: now})
+		p.Price = price
+		p.UpdatedDate = now
+		r.products.products[p.IDProduct] = p
+	}
+	if len(failed) > 0 {
+		return changed, failed
+	}
+	return changed, nil
+}
+
+func sortSchedules(schedules []PriceSchedule) {
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].EffectiveFrom.Equal(schedules[j].EffectiveFrom) {
+			return schedules[i].EffectiveFrom.Before(schedules[j].EffectiveFrom)
+		}
+		return schedules[i].IDSchedule < schedules[j].IDSchedule
+	})
+}
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//price change sources
+const (
+	PriceSourceManual   = "manual"
+	PriceSourceSchedule = "schedule"
+)
+
+//price schedule status
+const (
+	SchedulePending   = "pending"
+	ScheduleActive    = "active"
+	ScheduleEnded     = "ended"
+	ScheduleSkipped   = "skipped"
+	ScheduleCancelled = "cancelled"
+)
+
+var (
+	ErrScheduleOverlap    = errors.New("price schedule overlaps another pending or active schedule of the product")
+	ErrScheduleNotPending = errors.New("only pending price schedules can be cancelled")
+)
+
+//PriceChange price history row, prices in minor units of the product currency
+type PriceChange struct {
+	IDPriceHistory int64     `gorm:"column:id_price_history;primaryKey;autoIncrement"`
+	IDProduct      string    `gorm:"column:id_product;type:varchar(36)"`
+	OldPrice       int       `gorm:"column:old_price;type:bigint"`
+	NewPrice       int       `gorm:"column:new_price;type:bigint"`
+	Source         string    `gorm:"column:source;type:varchar(20)"`
+	IDSchedule     *string   `gorm:"column:id_schedule;type:varchar(36)"`
+	ChangedAt      time.Time `gorm:"column:changed_at"`
+}
+
+//PriceSchedule future price of a product, applied from EffectiveFrom and reverted to PreviousPrice
+//at EffectiveTo, a nil EffectiveTo keeps the price for good
+type PriceSchedule struct {
+	IDSchedule    string     `gorm:"column:id_schedule;type:varchar(36)"`
+	IDProduct     string     `gorm:"column:id_product;type:varchar(36)"`
+	Price         int        `gorm:"column:price;type:bigint"`
+	EffectiveFrom time.Time  `gorm:"column:effective_from"`
+	EffectiveTo   *time.Time `gorm:"column:effective_to"`
+	Status        string     `gorm:"column:status;type:varchar(20)"`
+	PreviousPrice *int       `gorm:"column:previous_price;type:bigint"`
+	CreatedDate   time.Time  `gorm:"column:created_datetime"`
+	UpdatedDate   time.Time  `gorm:"column:updated_datetime"`
+}
+
+//overlaps reports whether both schedules would be in effect at the same moment
+func (s PriceSchedule) overlaps(other PriceSchedule) bool {
+	startsBefore := func(a, b PriceSchedule) bool {
+		return a.EffectiveTo == nil || b.EffectiveFrom.Before(*a.EffectiveTo)
+	}
+	return startsBefore(s, other) && startsBefore(other, s)
+}
+
+//next status of the schedule at now and the price the product gets with it (0 when it doesn't change)
+func (s PriceSchedule) next(now time.Time) (string, int) {
+	ended := s.EffectiveTo != nil && !now.Before(*s.EffectiveTo)
+	switch {
+	case s.Status == SchedulePending && ended:
+		return ScheduleSkipped, 0
+	case s.Status == SchedulePending && !now.Before(s.EffectiveFrom):
+		return ScheduleActive, s.Price
+	case s.Status == ScheduleActive && ended && s.PreviousPrice != nil:
+		return ScheduleEnded, *s.PreviousPrice
+	}
+	return s.Status, 0
+}
+
+//PriceRepository price history and price schedules, missing rows are reported as gorm.ErrRecordNotFound.
+//ApplySchedules starts and ends the schedules due at now and returns how many changed, a schedule
+//that fails doesn't hold back the others, the failures come back together as ScheduleErrors
+type PriceRepository interface {
+	History(idProduct string, page, size int) ([]PriceChange, int64, error)
+	CreateSchedule(s *PriceSchedule, newID func() (string, error)) error
+	Schedules(idProduct string) ([]PriceSchedule, error)
+	CancelSchedule(id string, now time.Time) (PriceSchedule, error)
+	ApplySchedules(now time.Time) (int, error)
+}
+
+//PriceGorm postgres PriceRepository
+type PriceGorm struct {
+	DB *gorm.DB
+}
+
+func NewPriceRepository(db *gorm.DB) PriceRepository {
+	return PriceGorm{DB: db}
+}
+
+//History price changes of a product, newest first
+func (r PriceGorm) History(idProduct string, page, size int) ([]PriceChange, int64, error) {
+	handleErr := func(err error) ([]PriceChange, int64, error) {
+		return nil, 0, fmt.Errorf("price history : %w", err)
+	}
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	var total int64
+	base := r.DB.Table("price_history").Where("id_product=? and id_product in (?)", idProduct, tenantProducts(r.DB))
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return handleErr(err)
+	}
+
+	changes := []PriceChange{}
+	err := base.Session(&gorm.Session{}).Order("changed_at desc, id_price_history desc").Offset((page - 1) * size).Limit(size).Find(&changes).Error
+	if err != nil {
+		return handleErr(err)
+	}
+	return changes, total, nil
+}
+
+//CreateSchedule locks the product so overlapping schedules of concurrent requests are refused
+func (r PriceGorm) CreateSchedule(s *PriceSchedule, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockProduct(tx, s.IDProduct); err != nil {
+			return err
+		}
+
+		current := []PriceSchedule{}
+		err := tx.Table("price_schedule").Where("id_product=? and status in ?", s.IDProduct, []string{SchedulePending, ScheduleActive}).
+			Find(&current).Error
+		if err != nil {
+			return err
+		}
+		for _, other := range current {
+			if s.overlaps(other) {
+				return ErrScheduleOverlap
+			}
+		}
+
+		s.IDSchedule = id
+		s.Status = SchedulePending
+		return tx.Table("price_schedule").Create(s).Error
+	})
+}
+
+func (r PriceGorm) Schedules(idProduct string) ([]PriceSchedule, error) {
+	schedules := []PriceSchedule{}
+	err := r.DB.Table("price_schedule").Where("id_product=? and id_product in (?)", idProduct, tenantProducts(r.DB)).Order("effective_from, id_schedule").Find(&schedules).Error
+	return schedules, err
+}
+
+//CancelSchedule locks the schedule then its product like applySchedule does, a schedule of a product
+//out of reach of the db (another tenant's) is reported as gorm.ErrRecordNotFound
+func (r PriceGorm) CancelSchedule(id string, now time.Time) (PriceSchedule, error) {
+	s := PriceSchedule{}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("price_schedule").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id_schedule=? and id_product in (?)", id, tenantProducts(tx)).Take(&s).Error
+		if err != nil {
+			return err
+		}
+		if _, err := lockProduct(tx, s.IDProduct); err != nil {
+			return err
+		}
+		if s.Status != SchedulePending {
+			return ErrScheduleNotPending
+		}
+
+		s.Status = ScheduleCancelled
+		s.UpdatedDate = now
+		sql := "update price_schedule set status=?, updated_datetime=? where id_schedule=?"
+		return tx.Exec(sql, s.Status, s.UpdatedDate, id).Error
+	})
+	if errors.Is(err, ErrScheduleNotPending) {
+		return s, err
+	}
+	if err != nil {
+		return PriceSchedule{}, err
+	}
+	return s, nil
+}
+
+//ScheduleErrors failures of the schedules ApplySchedules couldn't apply, one error per schedule
+type ScheduleErrors []error
+
+func (e ScheduleErrors) Error() string {
+	msgs := []string{}
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//ApplySchedules every due schedule is applied in its own transaction, replicas running
+//the scheduler at the same time skip the schedules already handled by another one
+func (r PriceGorm) ApplySchedules(now time.Time) (int, error) {
+	due := []PriceSchedule{}
+	err := r.DB.Table("price_schedule").
+		Where("(status=? and effective_from<=?) or (status=? and effective_to<=?)", SchedulePending, now, ScheduleActive, now).
+		Order("effective_from, id_schedule").Find(&due).Error
+	if err != nil {
+		return 0, fmt.Errorf("price schedule : %w", err)
+	}
+
+	changed := 0
+	failed := ScheduleErrors{}
+	for _, s := range due {
+		applied := false
+		err := r.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			applied, err = applySchedule(tx, s.IDSchedule, now)
+			return err
+		})
+		if err != nil {
+			failed = append(failed, fmt.Errorf("price schedule %s : %w", s.IDSchedule, err))
+			continue
+		}
+		if applied {
+			changed++
+		}
+	}
+	if len(failed) > 0 {
+		return changed, failed
+	}
+	return changed, nil
+}
+
+func applySchedule(tx *gorm.DB, id string, now time.Time) (bool, error) {
+	s := PriceSchedule{}
+	err := tx.Table("price_schedule").Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_schedule=?", id).Take(&s).Error
+	if err != nil {
+		return false, err
+	}
+	status, price := s.next(now)
+	if status == s.Status {
+		return false, nil
+	}
+
+	p, err := lockProduct(tx, s.IDProduct)
+	if err != nil {
+		return false, err
+	}
+	if status == ScheduleActive {
+		s.PreviousPrice = &p.Price
+	}
+	sql := "update price_schedule set status=?, previous_price=?, updated_datetime=? where id_schedule=?"
+	if err := tx.Exec(sql, status, s.PreviousPrice, now, id).Error; err != nil {
+		return false, err
+	}
+	if price == 0 || price == p.Price {
+		return true, nil
+	}
+
+	values := map[string]interface{}{"price": price, "updated_datetime": now}
+	if err := tx.Table("product").Where("id_product=?", p.IDProduct).Updates(values).Error; err != nil {
+		return false, err
+	}
+	change := PriceChange{IDProduct: p.IDProduct, OldPrice: p.Price, NewPrice: price, Source: PriceSourceSchedule, IDSchedule: &s.IDSchedule, ChangedAt: now}
+	return true, tx.Table("price_history").Create(&change).Error
+}
@@ -0,0 +1,386 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+//ProductMemory in-memory ProductRepository safe for concurrent use, meant for tests and local runs,
+//stock holds the quantity per product per warehouse for StockMemory and prices the price history for PriceMemory
+type ProductMemory struct {
+	mu         sync.RWMutex
+	products   map[string]Product
+	categories map[string][]string
+	stock      map[string]map[string]int
+	prices     []PriceChange
+}
+
+func NewProductMemoryRepository() *ProductMemory {
+	return &ProductMemory{products: map[string]Product{}, categories: map[string][]string{}, stock: map[string]map[string]int{}}
+}
+
+func (r *ProductMemory) Create(p *Product, newID func() (string, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		if _, ok := r.products[id]; ok {
+			continue
+		}
+		p.IDProduct = id
+		r.products[id] = *p
+		r.stock[id] = map[string]int{}
+		if p.Quantity > 0 {
+			r.stock[id][DefaultWarehouseID] = p.Quantity
+		}
+		return nil
+	}
+
+	return fmt.Errorf("can't generate unique product id after %d attempts", maxIDAttempts)
+}
+
+func (r *ProductMemory) GetByID(id string) (Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return Product{}, gorm.ErrRecordNotFound
+	}
+	return p, nil
+}
+
+func (r *ProductMemory) Update(p *Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.products[p.IDProduct]
+	if !ok {
+		return nil
+	}
+	if stored.Price != p.Price {
+		r.recordPrice(PriceChange{IDProduct: p.IDProduct, OldPrice: stored.Price, NewPrice: p.Price, Source: PriceSourceManual, ChangedAt: p.UpdatedDate})
+	}
+	stored.ProductName = p.ProductName
+	stored.Price = p.Price
+	stored.Description = p.Description
+	stored.ImageKey = p.ImageKey
+	stored.UpdatedDate = p.UpdatedDate
+	r.products[p.IDProduct] = stored
+	return nil
+}
+
+//recordPrice appends to the price history, callers hold the write lock
+func (r *ProductMemory) recordPrice(change PriceChange) {
+	change.IDPriceHistory = int64(len(r.prices) + 1)
+	r.prices = append(r.prices, change)
+}
+
+func (r *ProductMemory) SetActive(id string, active bool, updatedDate time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.products[id]
+	if !ok {
+		return nil
+	}
+	stored.Active = active
+	stored.UpdatedDate = updatedDate
+	r.products[id] = stored
+	return nil
+}
+
+func (r *ProductMemory) List(q ProductQuery) (ProductPage, error) {
+	handleErr := func(err error) (ProductPage, error) {
+		return ProductPage{}, fmt.Errorf("product list : %w", err)
+	}
+
+	sortFields, err := keysetSort(q.Sort)
+	if err != nil {
+		return handleErr(err)
+	}
+	q.Size = pageSize(q.Size)
+	if err := validFilters(q.Filters); err != nil {
+		return handleErr(err)
+	}
+
+	rows := []Product{}
+	r.mu.RLock()
+	for _, p := range r.products {
+		if (p.Active || q.IncludeInactive) && matchFilters(p, q.Filters) &&
+			r.inCategories(p.IDProduct, q.CategoryIDs) && r.inWarehouse(p.IDProduct, q.WarehouseID) {
+			rows = append(rows, p)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		return compareRows(rows[i], rows[j], sortFields) < 0
+	})
+
+	page := ProductPage{Total: int64(len(rows))}
+	if q.Page > 0 {
+		offset := (q.Page - 1) * q.Size
+		if offset > len(rows) {
+			offset = len(rows)
+		}
+		rows = rows[offset:]
+	} else if q.Cursor != "" {
+		values, err := decodeCursor(q.Cursor, sortFields)
+		if err != nil {
+			return handleErr(err)
+		}
+		start := sort.Search(len(rows), func(i int) bool {
+			return compareToCursor(rows[i], sortFields, values) > 0
+		})
+		rows = rows[start:]
+	}
+
+	if len(rows) > q.Size {
+		rows = rows[:q.Size]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(sortFields, rows[len(rows)-1])
+	}
+	page.Products = append([]Product{}, rows...)
+
+	return page, nil
+}
+
+//Search word prefix search, name matches rank above description matches
+func (r *ProductMemory) Search(text string, includeInactive bool, page, size int) ([]ProductSearchResult, int64, error) {
+	terms := searchTerms(text)
+	if len(terms) == 0 {
+		return nil, 0, fmt.Errorf("product search : search text has no searchable word")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	results := []ProductSearchResult{}
+	r.mu.RLock()
+	for _, p := range r.products {
+		if !p.Active && !includeInactive {
+			continue
+		}
+		nameHits, descHits, matched := 0, 0, true
+		for _, term := range terms {
+			n, d := countPrefix(p.ProductName, term), countPrefix(p.Description, term)
+			if n+d == 0 {
+				matched = false
+				break
+			}
+			nameHits += n
+			descHits += d
+		}
+		if !matched {
+			continue
+		}
+		results = append(results, ProductSearchResult{
+			Product:              p,
+			Rank:                 float64(nameHits) + 0.4*float64(descHits),
+			NameHighlight:        highlight(p.ProductName, terms),
+			DescriptionHighlight: highlight(p.Description, terms),
+		})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].IDProduct < results[j].IDProduct
+	})
+
+	total := int64(len(results))
+	offset := (page - 1) * size
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+	if len(results) > size {
+		results = results[:size]
+	}
+	return results, total, nil
+}
+
+func (r *ProductMemory) SetCategories(id string, categoryIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := append([]string{}, categoryIDs...)
+	sort.Strings(ids)
+	r.categories[id] = ids
+	return nil
+}
+
+func (r *ProductMemory) CategoryIDs(id string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]string{}, r.categories[id]...), nil
+}
+
+func (r *ProductMemory) CategoryIDsOf(ids []string) (map[string][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := map[string][]string{}
+	for _, id := range ids {
+		if assigned := r.categories[id]; len(assigned) > 0 {
+			categories[id] = append([]string{}, assigned...)
+		}
+	}
+	return categories, nil
+}
+
+//inCategories reports whether the product is assigned to one of categoryIDs, no category means no filter
+func (r *ProductMemory) inCategories(id string, categoryIDs []string) bool {
+	if len(categoryIDs) == 0 {
+		return true
+	}
+	for _, assigned := range r.categories[id] {
+		for _, categoryID := range categoryIDs {
+			if assigned == categoryID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//levels warehouse quantities of the product, callers hold the write lock
+func (r *ProductMemory) levels(id string) map[string]int {
+	if r.stock[id] == nil {
+		r.stock[id] = map[string]int{}
+	}
+	return r.stock[id]
+}
+
+//inWarehouse reports whether the warehouse holds stock of the product, no warehouse means no filter
+func (r *ProductMemory) inWarehouse(id, warehouseID string) bool {
+	return warehouseID == "" || r.stock[id][warehouseID] > 0
+}
+
+func matchFilters(p Product, filters []ProductFilter) bool {
+	for _, f := range filters {
+		c := compareValues(p.columnValue(f.Column), f.Value)
+		ok := false
+		switch f.Operator {
+		case "eq":
+			ok = c == 0
+		case "ne":
+			ok = c != 0
+		case "gt":
+			ok = c > 0
+		case "gte":
+			ok = c >= 0
+		case "lt":
+			ok = c < 0
+		case "lte":
+			ok = c <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func compareRows(a, b Product, sortFields []SortField) int {
+	for _, f := range sortFields {
+		c := compareValues(a.columnValue(f.Column), b.columnValue(f.Column))
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareToCursor(p Product, sortFields []SortField, values []interface{}) int {
+	for i, f := range sortFields {
+		c := compareValues(p.columnValue(f.Column), values[i])
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case int:
+		bv := b.(int)
+		if av < bv {
+			return -1
+		} else if av > bv {
+			return 1
+		}
+	case time.Time:
+		bv := b.(time.Time)
+		if av.Before(bv) {
+			return -1
+		} else if av.After(bv) {
+			return 1
+		}
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+	return 0
+}
+
+func countPrefix(text, term string) int {
+	n := 0
+	for _, w := range searchTerms(text) {
+		if strings.HasPrefix(w, term) {
+			n++
+		}
+	}
+	return n
+}
+
+//htmlEscaper escapes the text around the marks the way escapeHTML does in sql
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;")
+
+//highlight wraps words starting with one of terms in <mark></mark> like ts_headline does, the text is html escaped
+func highlight(text string, terms []string) string {
+	out := strings.Builder{}
+	word := strings.Builder{}
+	flush := func() {
+		w := word.String()
+		word.Reset()
+		for _, term := range terms {
+			if w != "" && strings.HasPrefix(strings.ToLower(w), term) {
+				out.WriteString("<mark>" + htmlEscaper.Replace(w) + "</mark>")
+				return
+			}
+		}
+		out.WriteString(htmlEscaper.Replace(w))
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		out.WriteString(htmlEscaper.Replace(string(r)))
+	}
+	flush()
+	return out.String()
+}
@@ -0,0 +1,358 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type columnKind int
+
+const (
+	stringColumn columnKind = iota
+	intColumn
+	timeColumn
+)
+
+//productColumns whitelist of product columns usable for sorting and filtering, nothing outside it reaches the sql
+var productColumns = map[string]columnKind{
+	"id_product":       stringColumn,
+	"product_name":     stringColumn,
+	"price":            intColumn,
+	"currency":         stringColumn,
+	"quantity":         intColumn,
+	"created_datetime": timeColumn,
+}
+
+//filterOperators operators accepted by ProductFilter with their sql counterpart
+var filterOperators = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+type ProductFilter struct {
+	Column   string
+	Operator string
+	Value    interface{}
+}
+
+//ProductQuery listing parameters, Page > 0 switch to offset pagination otherwise Cursor is used.
+//CategoryIDs keeps products assigned to any of the categories, WarehouseID products in stock at that warehouse
+type ProductQuery struct {
+	Sort            []SortField
+	Filters         []ProductFilter
+	CategoryIDs     []string
+	WarehouseID     string
+	IncludeInactive bool
+	Page            int
+	Size            int
+	Cursor          string
+}
+
+type ProductPage struct {
+	Products   []Product
+	Total      int64
+	NextCursor string
+	HasMore    bool
+}
+
+type productCursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+func (p Product) ProductList(db *gorm.DB, q ProductQuery) (ProductPage, error) {
+	handleErr := func(err error) (ProductPage, error) {
+		return ProductPage{}, fmt.Errorf("product list : %w", err)
+	}
+
+	sort, err := keysetSort(q.Sort)
+	if err != nil {
+		return handleErr(err)
+	}
+	q.Size = pageSize(q.Size)
+	if err := validFilters(q.Filters); err != nil {
+		return handleErr(err)
+	}
+
+	base := activeScope(db.Table("product"), q.IncludeInactive)
+	for _, f := range q.Filters {
+		base = base.Where(f.Column+" "+filterOperators[f.Operator]+" ?", f.Value)
+	}
+	if len(q.CategoryIDs) > 0 {
+		base = base.Where("id_product in (select id_product from product_category where id_category in ?)", q.CategoryIDs)
+	}
+	if q.WarehouseID != "" {
+		base = base.Where("id_product in (select id_product from warehouse_stock where id_warehouse=? and quantity > 0)", q.WarehouseID)
+	}
+
+	page := ProductPage{}
+	if err := base.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return handleErr(err)
+	}
+
+	find := base.Session(&gorm.Session{})
+	for _, f := range sort {
+		find = find.Order(f.orderBy())
+	}
+
+	if q.Page > 0 {
+		find = find.Offset((q.Page - 1) * q.Size)
+	} else if q.Cursor != "" {
+		values, err := decodeCursor(q.Cursor, sort)
+		if err != nil {
+			return handleErr(err)
+		}
+		where, args := keysetCondition(sort, values)
+		find = find.Where(where, args...)
+	}
+
+	products := []Product{}
+	if err := find.Limit(q.Size + 1).Find(&products).Error; err != nil {
+		return handleErr(err)
+	}
+
+	if len(products) > q.Size {
+		products = products[:q.Size]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(sort, products[len(products)-1])
+	}
+	page.Products = products
+
+	return page, nil
+}
+
+//ParseProductSort parses a comma separated column list, a leading - means descending (e.g. -price,product_name)
+func ParseProductSort(raw string) ([]SortField, error) {
+	sort := []SortField{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		f := SortField{Column: part}
+		if strings.HasPrefix(part, "-") {
+			f = SortField{Column: part[1:], Desc: true}
+		}
+		if _, ok := productColumns[f.Column]; !ok {
+			return nil, fmt.Errorf("unknown sort column %s", f.Column)
+		}
+		sort = append(sort, f)
+	}
+	return sort, nil
+}
+
+//ParseProductFilter validates column and operator against the whitelist and converts raw into the column type
+func ParseProductFilter(column, operator, raw string) (ProductFilter, error) {
+	kind, ok := productColumns[column]
+	if !ok {
+		return ProductFilter{}, fmt.Errorf("unknown filter column %s", column)
+	}
+	if _, ok := filterOperators[operator]; !ok {
+		return ProductFilter{}, fmt.Errorf("unknown filter operator %s", operator)
+	}
+
+	f := ProductFilter{Column: column, Operator: operator}
+	switch kind {
+	case intColumn:
+		val, err := strconv.Atoi(raw)
+		if err != nil {
+			return ProductFilter{}, fmt.Errorf("%s must be a number", column)
+		}
+		f.Value = val
+	case timeColumn:
+		val, err := parseTime(raw)
+		if err != nil {
+			return ProductFilter{}, fmt.Errorf("%s must be a date (2006-01-02) or RFC3339 time", column)
+		}
+		f.Value = val
+	default:
+		f.Value = raw
+	}
+	return f, nil
+}
+
+func parseTime(raw string) (time.Time, error) {
+	if val, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return val, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+//pageSize applies the default and maximum page size
+func pageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	if size > MaxPageSize {
+		return MaxPageSize
+	}
+	return size
+}
+
+func validFilters(filters []ProductFilter) error {
+	for _, f := range filters {
+		_, known := productColumns[f.Column]
+		if _, ok := filterOperators[f.Operator]; !ok || !known {
+			return fmt.Errorf("unknown filter %s %s", f.Column, f.Operator)
+		}
+	}
+	return nil
+}
+
+//keysetSort validates the sort fields and appends id_product as tie breaker so every row has a unique position
+func keysetSort(fields []SortField) ([]SortField, error) {
+	sort := []SortField{}
+	hasID := false
+	for _, f := range fields {
+		if _, ok := productColumns[f.Column]; !ok {
+			return nil, fmt.Errorf("unknown sort column %s", f.Column)
+		}
+		if f.Column == "id_product" {
+			hasID = true
+		}
+		sort = append(sort, f)
+	}
+	if !hasID {
+		sort = append(sort, SortField{Column: "id_product"})
+	}
+	return sort, nil
+}
+
+func (f SortField) orderBy() string {
+	if f.Desc {
+		return f.Column + " desc"
+	}
+	return f.Column + " asc"
+}
+
+func sortSignature(sort []SortField) string {
+	parts := []string{}
+	for _, f := range sort {
+		parts = append(parts, f.orderBy())
+	}
+	return strings.Join(parts, ",")
+}
+
+//keysetCondition builds (a > ?) or (a = ? and b > ?) ... for the rows after the cursor
+func keysetCondition(sort []SortField, values []interface{}) (string, []interface{}) {
+	ors := []string{}
+	args := []interface{}{}
+	for i, f := range sort {
+		ands := []string{}
+		for j := 0; j < i; j++ {
+			ands = append(ands, sort[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if f.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, f.Column+op)
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " and ")+")")
+	}
+	return "(" + strings.Join(ors, " or ") + ")", args
+}
+
+func encodeCursor(sort []SortField, last Product) string {
+	cur := productCursor{Sort: sortSignature(sort)}
+	for _, f := range sort {
+		val := last.columnValue(f.Column)
+		if t, ok := val.(time.Time); ok {
+			val = t.Format(time.RFC3339Nano)
+		}
+		cur.Values = append(cur.Values, val)
+	}
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(cursor string, sort []SortField) ([]interface{}, error) {
+	handleErr := func(err error) ([]interface{}, error) {
+		return nil, fmt.Errorf("invalid cursor : %w", err)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return handleErr(err)
+	}
+
+	cur := productCursor{}
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return handleErr(err)
+	}
+	if cur.Sort != sortSignature(sort) || len(cur.Values) != len(sort) {
+		return handleErr(fmt.Errorf("cursor belongs to another sort"))
+	}
+
+	values := []interface{}{}
+	for i, f := range sort {
+		val, err := cursorValue(productColumns[f.Column], cur.Values[i])
+		if err != nil {
+			return handleErr(err)
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+//cursorValue turns a json decoded cursor value back into the column type
+func cursorValue(kind columnKind, raw interface{}) (interface{}, error) {
+	switch kind {
+	case intColumn:
+		val, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected number got %v", raw)
+		}
+		return int(val), nil
+	case timeColumn:
+		val, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected time got %v", raw)
+		}
+		return time.Parse(time.RFC3339Nano, val)
+	default:
+		val, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected text got %v", raw)
+		}
+		return val, nil
+	}
+}
+
+func (p Product) columnValue(column string) interface{} {
+	switch column {
+	case "product_name":
+		return p.ProductName
+	case "price":
+		return p.Price
+	case "currency":
+		return p.Currency
+	case "quantity":
+		return p.Quantity
+	case "created_datetime":
+		return p.CreatedDate
+	default:
+		return p.IDProduct
+	}
+}
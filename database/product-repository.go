@@ -0,0 +1,136 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//ProductRepository product storage used by the services, missing products are reported as gorm.ErrRecordNotFound,
+//Update leaves the quantity alone since it only changes through the StockRepository ledger
+type ProductRepository interface {
+	Create(p *Product, newID func() (string, error)) error
+	GetByID(id string) (Product, error)
+	Update(p *Product) error
+	SetActive(id string, active bool, updatedDate time.Time) error
+	List(q ProductQuery) (ProductPage, error)
+	Search(text string, includeInactive bool, page, size int) ([]ProductSearchResult, int64, error)
+	SetCategories(id string, categoryIDs []string) error
+	CategoryIDs(id string) ([]string, error)
+	CategoryIDsOf(ids []string) (map[string][]string, error)
+}
+
+//ProductGorm postgres ProductRepository
+type ProductGorm struct {
+	DB *gorm.DB
+}
+
+func NewProductRepository(db *gorm.DB) ProductRepository {
+	return ProductGorm{DB: db}
+}
+
+//Create inserts the product, its initial quantity is stocked at the MAIN warehouse of the tenant
+func (r ProductGorm) Create(p *Product, newID func() (string, error)) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := p.Create(tx, newID, p.ProductName, p.Description, p.Price, p.Quantity, p.CreatedDate, p.Active); err != nil {
+			return err
+		}
+		if p.Quantity == 0 {
+			return nil
+		}
+		warehouse, err := defaultWarehouse(tx, newID, p.CreatedDate)
+		if err != nil {
+			return err
+		}
+		level := WarehouseStock{IDWarehouse: warehouse, IDProduct: p.IDProduct, Quantity: p.Quantity}
+		return tx.Table("warehouse_stock").Create(&level).Error
+	})
+}
+
+func (r ProductGorm) GetByID(id string) (Product, error) {
+	p := Product{}
+	err := p.GetByID(r.DB, id)
+	return p, err
+}
+
+//Update writes the product, a price change is recorded in the price history in the same transaction
+func (r ProductGorm) Update(p *Product) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		old, err := lockProduct(tx, p.IDProduct)
+		if err != nil {
+			return err
+		}
+		if err := p.Updateproduct(tx, p.IDProduct, p.ProductName, p.Description, p.Price, p.ImageKey, p.UpdatedDate); err != nil {
+			return err
+		}
+		if old.Price == p.Price {
+			return nil
+		}
+		change := PriceChange{IDProduct: p.IDProduct, OldPrice: old.Price, NewPrice: p.Price, Source: PriceSourceManual, ChangedAt: p.UpdatedDate}
+		return tx.Table("price_history").Create(&change).Error
+	})
+}
+
+func (r ProductGorm) SetActive(id string, active bool, updatedDate time.Time) error {
+	p := Product{}
+	return p.setActive(r.DB, id, active, updatedDate)
+}
+
+func (r ProductGorm) List(q ProductQuery) (ProductPage, error) {
+	return Product{}.ProductList(r.DB, q)
+}
+
+func (r ProductGorm) Search(text string, includeInactive bool, page, size int) ([]ProductSearchResult, int64, error) {
+	return Product{}.Search(r.DB, text, includeInactive, page, size)
+}
+
+//SetCategories replaces the categories the product is assigned to, the product and the categories
+//are looked up first so only those of the tenant are linked
+func (r ProductGorm) SetCategories(id string, categoryIDs []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockProduct(tx, id); err != nil {
+			return err
+		}
+		for _, categoryID := range categoryIDs {
+			if _, err := (CategoryGorm{DB: tx}).GetByID(categoryID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("delete from product_category where id_product=?", id).Error; err != nil {
+			return err
+		}
+		for _, categoryID := range categoryIDs {
+			sql := "insert into product_category (id_product, id_category) values (?, ?) on conflict do nothing"
+			if err := tx.Exec(sql, id, categoryID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r ProductGorm) CategoryIDs(id string) ([]string, error) {
+	ids := []string{}
+	err := r.DB.Table("product_category").Where("id_product=? and id_product in (?)", id, tenantProducts(r.DB)).Order("id_category").Pluck("id_category", &ids).Error
+	return ids, err
+}
+
+//CategoryIDsOf category ids of several products keyed by product id
+func (r ProductGorm) CategoryIDsOf(ids []string) (map[string][]string, error) {
+	categories := map[string][]string{}
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	rows := []struct {
+		IDProduct  string `gorm:"column:id_product"`
+		IDCategory string `gorm:"column:id_category"`
+	}{}
+	err := r.DB.Table("product_category").Where("id_product in ? and id_product in (?)", ids, tenantProducts(r.DB)).Order("id_product, id_category").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		categories[row.IDProduct] = append(categories[row.IDProduct], row.IDCategory)
+	}
+	return categories, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+//highlightOptions ts_headline options, matches are wrapped in <mark></mark>
+const highlightOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, HighlightAll=false"
+
+type ProductSearchResult struct {
+	Product
+	Rank                 float64 `gorm:"column:rank"`
+	NameHighlight        string  `gorm:"column:name_highlight"`
+	DescriptionHighlight string  `gorm:"column:description_highlight"`
+}
+
+//Search full text search on product name and description ranked by ts_rank, every word is prefix matched
+func (p Product) Search(db *gorm.DB, text string, includeInactive bool, page, size int) ([]ProductSearchResult, int64, error) {
+	handleErr := func(err error) ([]ProductSearchResult, int64, error) {
+		return nil, 0, fmt.Errorf("product search : %w", err)
+	}
+
+	tsQuery := PrefixTSQuery(text)
+	if tsQuery == "" {
+		return handleErr(fmt.Errorf("search text has no searchable word"))
+	}
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	base := activeScope(db.Table("product, to_tsquery('simple', ?) search_query", tsQuery), includeInactive).
+		Where("product.search_vector @@ search_query")
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return handleErr(err)
+	}
+
+	results := []ProductSearchResult{}
+	err := base.Session(&gorm.Session{}).
+		Select("product.*, ts_rank(product.search_vector, search_query) as rank, "+
+			"ts_headline('simple', "+escapeHTML("product.product_name")+", search_query, ?) as name_highlight, "+
+			"ts_headline('simple', "+escapeHTML("product.description")+", search_query, ?) as description_highlight",
+			highlightOptions, highlightOptions).
+		Order("rank desc").Order("product.id_product asc").
+		Offset((page - 1) * size).Limit(size).
+		Find(&results).Error
+	if err != nil {
+		return handleErr(err)
+	}
+
+	return results, total, nil
+}
+
+//escapeHTML sql expression html escaping column, the highlights are rendered as markup so only the
+//<mark></mark> added by ts_headline may reach the client unescaped
+func escapeHTML(column string) string {
+	expr := "replace(" + column + ", '&', '&amp;')"
+	for _, r := range [][2]string{{"<", "&lt;"}, {">", "&gt;"}, {`"`, "&quot;"}, {"'", "&#39;"}} {
+		expr = "replace(" + expr + ", '" + strings.ReplaceAll(r[0], "'", "''") + "', '" + r[1] + "')"
+	}
+	return expr
+}
+
+//PrefixTSQuery turns free text into a tsquery where every word must match as prefix (kopi hit -> kopi:* & hit:*),
+//only letters and digits are kept so user input can't break the tsquery syntax
+func PrefixTSQuery(text string) string {
+	terms := []string{}
+	for _, w := range searchTerms(text) {
+		terms = append(terms, w+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+//searchTerms lower cased words of text, made of letters and digits only
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -1,24 +1,34 @@
 package database
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Product struct {
-	IDProduct   string    `gorm:"column:id_product;type:varchar(25)"`
+	IDProduct   string    `gorm:"column:id_product;type:varchar(36);uniqueIndex"`
+	TenantID    string    `gorm:"column:tenant_id;type:varchar(36)"`
 	ProductName string    `gorm:"column:product_name;type:varchar(25)"`
-	Price       int       `gorm:"column:price;type:int"`
+	Price       int       `gorm:"column:price;type:bigint"`
+	Currency    string    `gorm:"column:currency;type:char(3)"`
 	Description string    `gorm:"column:description;type:text"`
 	Quantity    int       `gorm:"column:quantity;type:int"`
 	CreatedDate time.Time `gorm:"column:created_datetime"`
 	UpdatedDate time.Time `gorm:"column:updated_datetime"`
 	Active      bool      `gorm:"column:active;type:bool"`
+	ImageKey    string    `gorm:"column:image_key;type:varchar(200)"`
+
+	ReorderThreshold int `gorm:"column:reorder_threshold;type:int"`
 }
 
-func (p *Product) Create(db *gorm.DB, IDProduct, productName, description string, price, quantity int, createdDate time.Time, active bool) error {
-	p.IDProduct = IDProduct
+//maxIDAttempts number of generated ids tried before Create gives up
+const maxIDAttempts = 5
+
+//Create inserts the product under an id from newID, a colliding id is regenerated and retried
+func (p *Product) Create(db *gorm.DB, newID func() (string, error), productName, description string, price, quantity int, createdDate time.Time, active bool) error {
 	p.ProductName = productName
 	p.Price = price
 	p.Description = description
@@ -26,52 +36,77 @@ func (p *Product) Create(db *gorm.DB, IDProduct, productName, description string
 	p.CreatedDate = createdDate
 	p.Active = active
 
-	return db.Table("product").Create(&p).Error
-}
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		p.IDProduct = id
 
-func (p Product) ProductListTime(db *gorm.DB) ([]Product, error) {
-	products := []Product{}
-	err := db.Table("product").Order("created_datetime desc").Find(&products).Error
-	return products, err
-}
+		result := db.Table("product").
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id_product"}}, DoNothing: true}).
+			Create(&p)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil
+		}
+	}
 
-func (p Product) ProductPriceHigh(db *gorm.DB) ([]Product, error) {
-	products := []Product{}
-	err := db.Table("product").Order("price desc").Find(&products).Error
-	return products, err
+	return fmt.Errorf("can't generate unique product id after %d attempts", maxIDAttempts)
 }
 
-func (p Product) ProductPriceLow(db *gorm.DB) ([]Product, error) {
-	products := []Product{}
-	err := db.Table("product").Order("price asc").Find(&products).Error
-	return products, err
+func (p *Product) GetByID(db *gorm.DB, id_product string) error {
+	return db.Table("product").Where("id_product=?", id_product).Last(&p).Error
 }
 
-func (p Product) ProductNameAZ(db *gorm.DB) ([]Product, error) {
-	products := []Product{}
-	err := db.Table("product").Order("product_name asc").Find(&products).Error
-	return products, err
+func (p *Product) Updateproduct(db *gorm.DB, idproduct, productName, description string, price int, imageKey string, updated_datetime time.Time) error {
+	values := map[string]interface{}{
+		"product_name":     productName,
+		"price":            price,
+		"description":      description,
+		"image_key":        imageKey,
+		"updated_datetime": updated_datetime,
+	}
+	if err := db.Table("product").Where("id_product=?", idproduct).Updates(values).Error; err != nil {
+		return err
+	}
+
+	p.ProductName = productName
+	p.Price = price
+	p.Description = description
+	p.ImageKey = imageKey
+	p.UpdatedDate = updated_datetime
+
+	return nil
 }
 
-func (p Product) ProductNameZA(db *gorm.DB) ([]Product, error) {
-	products := []Product{}
-	err := db.Table("product").Order("created_datetime desc").Find(&products).Error
-	return products, err
+//Deleteproduct soft deletes the product by switching its active flag off
+func (p *Product) Deleteproduct(db *gorm.DB, id_product string, updated_datetime time.Time) error {
+	return p.setActive(db, id_product, false, updated_datetime)
 }
 
-func (p *Product) GetByID(db *gorm.DB, id_product string) error {
-	return db.Table("product").Where("id_product=?", id_product).Last(&p).Error
+func (p *Product) Restoreproduct(db *gorm.DB, id_product string, updated_datetime time.Time) error {
+	return p.setActive(db, id_product, true, updated_datetime)
 }
 
-func (p *Product) Updateproduct(db *gorm.DB, idproduct, namaBarang, deskripsi string, jumlahBarang int, hargaSatuan, gambarBarang string, updated_datetime time.Time) error {
-	sql := "update product set nama_barang=?, deskripsi_barang=?, jumlah_barang=?, harga_barangsatuan=?, gambar_barang=?, updated_datetime=? where id_product=?"
-	if err := db.Table("product").Exec(sql, namaBarang, deskripsi, jumlahBarang, hargaSatuan, gambarBarang, updated_datetime, idproduct).Error; err != nil {
+func (p *Product) setActive(db *gorm.DB, id_product string, active bool, updated_datetime time.Time) error {
+	values := map[string]interface{}{"active": active, "updated_datetime": updated_datetime}
+	if err := db.Table("product").Where("id_product=?", id_product).Updates(values).Error; err != nil {
 		return err
 	}
 
+	p.Active = active
+	p.UpdatedDate = updated_datetime
+
 	return nil
 }
 
-func (p *Product) Deleteproduct(db *gorm.DB, id_product string) error {
-	return db.Table("product").Where("id_product=?", id_product).Delete(&p).Error
+//activeScope hides inactive (soft deleted) products unless asked otherwise
+func activeScope(db *gorm.DB, includeInactive bool) *gorm.DB {
+	if includeInactive {
+		return db
+	}
+	return db.Where("active = ?", true)
 }
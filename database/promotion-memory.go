@@ -0,0 +1,136 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//PromotionMemory in-memory PromotionRepository
+type PromotionMemory struct {
+	mu         sync.Mutex
+	promotions map[string]Promotion
+}
+
+func NewPromotionMemoryRepository() *PromotionMemory {
+	return &PromotionMemory{promotions: map[string]Promotion{}}
+}
+
+func (r *PromotionMemory) Create(p *Promotion, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.couponUsed(p.CouponCode, "") {
+		return ErrDuplicateCoupon
+	}
+	p.IDPromotion = id
+	r.promotions[id] = copyPromotion(*p)
+	return nil
+}
+
+func (r *PromotionMemory) GetByID(id string) (Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.promotions[id]
+	if !ok {
+		return Promotion{}, gorm.ErrRecordNotFound
+	}
+	return copyPromotion(p), nil
+}
+
+func (r *PromotionMemory) List() ([]Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	promotions := []Promotion{}
+	for _, p := range r.promotions {
+		promotions = append(promotions, copyPromotion(p))
+	}
+	sort.Slice(promotions, func(i, j int) bool {
+		if !promotions[i].CreatedDate.Equal(promotions[j].CreatedDate) {
+			return promotions[i].CreatedDate.After(promotions[j].CreatedDate)
+		}
+		return promotions[i].IDPromotion < promotions[j].IDPromotion
+	})
+	return promotions, nil
+}
+
+func (r *PromotionMemory) Update(p *Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.promotions[p.IDPromotion]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if r.couponUsed(p.CouponCode, p.IDPromotion) {
+		return ErrDuplicateCoupon
+	}
+	updated := copyPromotion(*p)
+	updated.CreatedDate = stored.CreatedDate
+	r.promotions[p.IDPromotion] = updated
+	return nil
+}
+
+func (r *PromotionMemory) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.promotions[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.promotions, id)
+	return nil
+}
+
+func (r *PromotionMemory) Running(now time.Time, coupon string) ([]Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	promotions := []Promotion{}
+	for _, p := range r.promotions {
+		if !p.RunningAt(now) {
+			continue
+		}
+		if p.CouponCode == nil || (coupon != "" && *p.CouponCode == coupon) {
+			promotions = append(promotions, copyPromotion(p))
+		}
+	}
+	sort.Slice(promotions, func(i, j int) bool {
+		if !promotions[i].CreatedDate.Equal(promotions[j].CreatedDate) {
+			return promotions[i].CreatedDate.Before(promotions[j].CreatedDate)
+		}
+		return promotions[i].IDPromotion < promotions[j].IDPromotion
+	})
+	return promotions, nil
+}
+
+//couponUsed reports whether another promotion than exceptID has the coupon code
+func (r *PromotionMemory) couponUsed(coupon *string, exceptID string) bool {
+	if coupon == nil {
+		return false
+	}
+	for id, p := range r.promotions {
+		if id != exceptID && p.CouponCode != nil && *p.CouponCode == *coupon {
+			return true
+		}
+	}
+	return false
+}
+
+func copyPromotion(p Promotion) Promotion {
+	targets := make([]PromotionTarget, len(p.Targets))
+	for i, t := range p.Targets {
+		t.IDPromotion = p.IDPromotion
+		targets[i] = t
+	}
+	p.Targets = groupTargets([]Promotion{{IDPromotion: p.IDPromotion}}, targets)[0].Targets
+	return p
+}
@@ -0,0 +1,306 @@
+package database
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//promotion kinds
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+	PromotionBuyXGetY   = "buy_x_get_y"
+)
+
+//promotion target types
+const (
+	TargetProduct  = "product"
+	TargetCategory = "category"
+)
+
+var ErrDuplicateCoupon = errors.New("coupon code is already used by another promotion")
+
+//Promotion discount campaign, Value is the percent of a percentage promotion and the amount in minor units
+//of Currency of a fixed one, buy_x_get_y gives GetQuantity free units for every BuyQuantity + GetQuantity units
+type Promotion struct {
+	IDPromotion string     `gorm:"column:id_promotion;type:varchar(36)"`
+	TenantID    string     `gorm:"column:tenant_id;type:varchar(36)"`
+	Name        string     `gorm:"column:name;type:varchar(100)"`
+	Kind        string     `gorm:"column:kind;type:varchar(20)"`
+	Value       int        `gorm:"column:value;type:bigint"`
+	Currency    string     `gorm:"column:currency;type:varchar(3)"`
+	BuyQuantity int        `gorm:"column:buy_quantity;type:int"`
+	GetQuantity int        `gorm:"column:get_quantity;type:int"`
+	CouponCode  *string    `gorm:"column:coupon_code;type:varchar(50)"`
+	StartsAt    time.Time  `gorm:"column:starts_at"`
+	EndsAt      *time.Time `gorm:"column:ends_at"`
+	Active      bool       `gorm:"column:active;type:bool"`
+	CreatedDate time.Time  `gorm:"column:created_datetime"`
+	UpdatedDate time.Time  `gorm:"column:updated_datetime"`
+
+	Targets []PromotionTarget `gorm:"-"`
+}
+
+//PromotionTarget product or category a promotion is limited to
+type PromotionTarget struct {
+	IDPromotion string `gorm:"column:id_promotion;type:varchar(36)"`
+	TargetType  string `gorm:"column:target_type;type:varchar(10)"`
+	IDTarget    string `gorm:"column:id_target;type:varchar(36)"`
+}
+
+//PromotionItem line priced by the promotions, UnitPrice in minor units of the product currency and
+//CategoryPaths the paths of the product categories (a category target also covers its sub categories)
+type PromotionItem struct {
+	Product       Product
+	UnitPrice     int
+	Quantity      int
+	CategoryPaths []string
+}
+
+//PromotionResult price of a line with its best promotion, Discount is over the whole line
+type PromotionResult struct {
+	UnitPrice int
+	Quantity  int
+	Discount  int
+	Total     int
+	Promotion *Promotion
+}
+
+//RunningAt reports whether the promotion is active and inside its date window
+func (p Promotion) RunningAt(now time.Time) bool {
+	return p.Active && !now.Before(p.StartsAt) && (p.EndsAt == nil || now.Before(*p.EndsAt))
+}
+
+//Covers reports whether the item is targeted by the promotion, a promotion without targets covers
+//every product of its own tenant
+func (p Promotion) Covers(item PromotionItem) bool {
+	if p.TenantID != item.Product.TenantID {
+		return false
+	}
+	if len(p.Targets) == 0 {
+		return true
+	}
+	for _, t := range p.Targets {
+		switch t.TargetType {
+		case TargetProduct:
+			if t.IDTarget == item.Product.IDProduct {
+				return true
+			}
+		case TargetCategory:
+			for _, path := range item.CategoryPaths {
+				if strings.Contains(path, "/"+t.IDTarget+"/") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+//Discount of the whole line in minor units, never more than the line total.
+//Percentages are rounded half away from zero, fixed amounts only apply to products priced in their currency
+func (p Promotion) Discount(item PromotionItem) int {
+	if item.Quantity <= 0 || item.UnitPrice <= 0 || !p.Covers(item) {
+		return 0
+	}
+	total := item.UnitPrice * item.Quantity
+	discount := 0
+	switch p.Kind {
+	case PromotionPercentage:
+		discount = (total*p.Value + 50) / 100
+	case PromotionFixed:
+		if p.Currency == item.Product.Currency {
+			discount = p.Value * item.Quantity
+		}
+	case PromotionBuyXGetY:
+		if group := p.BuyQuantity + p.GetQuantity; p.BuyQuantity > 0 && p.GetQuantity > 0 {
+			discount = item.Quantity / group * p.GetQuantity * item.UnitPrice
+		}
+	}
+	if discount > total {
+		discount = total
+	}
+	return discount
+}
+
+//BestPromotion prices the item with the promotion giving the largest discount, promotions don't stack.
+//Ties go to the promotion listed first
+func BestPromotion(item PromotionItem, promotions []Promotion) PromotionResult {
+	result := PromotionResult{UnitPrice: item.UnitPrice, Quantity: item.Quantity, Total: item.UnitPrice * item.Quantity}
+	for i := range promotions {
+		if discount := promotions[i].Discount(item); discount > result.Discount {
+			result.Discount = discount
+			result.Promotion = &promotions[i]
+		}
+	}
+	result.Total -= result.Discount
+	return result
+}
+
+//PromotionRepository promotions with their targets, missing promotions are reported as gorm.ErrRecordNotFound.
+//Running lists the promotions running at now without coupon code plus the one of coupon when given
+type PromotionRepository interface {
+	Create(p *Promotion, newID func() (string, error)) error
+	GetByID(id string) (Promotion, error)
+	List() ([]Promotion, error)
+	Update(p *Promotion) error
+	Delete(id string) error
+	Running(now time.Time, coupon string) ([]Promotion, error)
+}
+
+//PromotionGorm postgres PromotionRepository
+type PromotionGorm struct {
+	DB *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return PromotionGorm{DB: db}
+}
+
+func (r PromotionGorm) Create(p *Promotion, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	p.IDPromotion = id
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("promotion").Create(p).Error; err != nil {
+			return err
+		}
+		return saveTargets(tx, p)
+	})
+	if isUniqueViolation(err, "promotion_coupon_code_key") {
+		return ErrDuplicateCoupon
+	}
+	return err
+}
+
+func (r PromotionGorm) GetByID(id string) (Promotion, error) {
+	p := Promotion{}
+	if err := r.DB.Table("promotion").Where("id_promotion=?", id).Take(&p).Error; err != nil {
+		return p, err
+	}
+	promotions, err := r.withTargets([]Promotion{p})
+	if err != nil {
+		return p, err
+	}
+	return promotions[0], nil
+}
+
+func (r PromotionGorm) List() ([]Promotion, error) {
+	promotions := []Promotion{}
+	if err := r.DB.Table("promotion").Order("created_datetime desc, id_promotion").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return r.withTargets(promotions)
+}
+
+func (r PromotionGorm) Update(p *Promotion) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		values := map[string]interface{}{
+			"name":             p.Name,
+			"kind":             p.Kind,
+			"value":            p.Value,
+			"currency":         p.Currency,
+			"buy_quantity":     p.BuyQuantity,
+			"get_quantity":     p.GetQuantity,
+			"coupon_code":      p.CouponCode,
+			"starts_at":        p.StartsAt,
+			"ends_at":          p.EndsAt,
+			"active":           p.Active,
+			"updated_datetime": p.UpdatedDate,
+		}
+		result := tx.Table("promotion").Where("id_promotion=?", p.IDPromotion).Updates(values)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Exec("delete from promotion_target where id_promotion=?", p.IDPromotion).Error; err != nil {
+			return err
+		}
+		return saveTargets(tx, p)
+	})
+	if isUniqueViolation(err, "promotion_coupon_code_key") {
+		return ErrDuplicateCoupon
+	}
+	return err
+}
+
+func (r PromotionGorm) Delete(id string) error {
+	result := r.DB.Table("promotion").Where("id_promotion=?", id).Delete(&Promotion{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r PromotionGorm) Running(now time.Time, coupon string) ([]Promotion, error) {
+	query := r.DB.Table("promotion").
+		Where("active = true and starts_at <= ? and (ends_at is null or ends_at > ?)", now, now)
+	if coupon == "" {
+		query = query.Where("coupon_code is null")
+	} else {
+		query = query.Where("(coupon_code is null or coupon_code = ?)", coupon)
+	}
+
+	promotions := []Promotion{}
+	if err := query.Order("created_datetime, id_promotion").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return r.withTargets(promotions)
+}
+
+//withTargets loads the targets of the promotions
+func (r PromotionGorm) withTargets(promotions []Promotion) ([]Promotion, error) {
+	if len(promotions) == 0 {
+		return promotions, nil
+	}
+	ids := []string{}
+	for _, p := range promotions {
+		ids = append(ids, p.IDPromotion)
+	}
+	targets := []PromotionTarget{}
+	err := r.DB.Table("promotion_target").Where("id_promotion in ?", ids).Order("id_promotion, target_type, id_target").Find(&targets).Error
+	if err != nil {
+		return nil, err
+	}
+	return groupTargets(promotions, targets), nil
+}
+
+func saveTargets(tx *gorm.DB, p *Promotion) error {
+	if len(p.Targets) == 0 {
+		return nil
+	}
+	for i := range p.Targets {
+		p.Targets[i].IDPromotion = p.IDPromotion
+	}
+	return tx.Table("promotion_target").Create(&p.Targets).Error
+}
+
+func groupTargets(promotions []Promotion, targets []PromotionTarget) []Promotion {
+	byID := map[string][]PromotionTarget{}
+	for _, t := range targets {
+		byID[t.IDPromotion] = append(byID[t.IDPromotion], t)
+	}
+	for i := range promotions {
+		promotions[i].Targets = byID[promotions[i].IDPromotion]
+		sort.Slice(promotions[i].Targets, func(a, b int) bool {
+			ta, tb := promotions[i].Targets[a], promotions[i].Targets[b]
+			if ta.TargetType != tb.TargetType {
+				return ta.TargetType < tb.TargetType
+			}
+			return ta.IDTarget < tb.IDTarget
+		})
+	}
+	return promotions
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//RefreshTokenMemory in-memory RefreshTokenRepository
+type RefreshTokenMemory struct {
+	mu     sync.RWMutex
+	tokens map[string]RefreshToken
+}
+
+func NewRefreshTokenMemoryRepository() *RefreshTokenMemory {
+	return &RefreshTokenMemory{tokens: map[string]RefreshToken{}}
+}
+
+func (r *RefreshTokenMemory) Create(t *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(t)
+}
+
+func (r *RefreshTokenMemory) GetByID(id string) (RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tokens[id]
+	if !ok {
+		return RefreshToken{}, gorm.ErrRecordNotFound
+	}
+	return t, nil
+}
+
+func (r *RefreshTokenMemory) Rotate(id string, next *RefreshToken, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tokens[id]
+	if !ok || stored.RevokedAt != nil {
+		return ErrTokenRevoked
+	}
+	if err := r.create(next); err != nil {
+		return err
+	}
+	replacedBy := next.IDToken
+	stored.RevokedAt = &revokedAt
+	stored.ReplacedBy = &replacedBy
+	r.tokens[id] = stored
+	return nil
+}
+
+func (r *RefreshTokenMemory) Revoke(id string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tokens[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if stored.RevokedAt == nil {
+		stored.RevokedAt = &revokedAt
+		r.tokens[id] = stored
+	}
+	return nil
+}
+
+func (r *RefreshTokenMemory) RevokeUser(idUser string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.tokens {
+		if t.IDUser == idUser && t.RevokedAt == nil {
+			t.RevokedAt = &revokedAt
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+//create stores t, callers hold r.mu
+func (r *RefreshTokenMemory) create(t *RefreshToken) error {
+	if _, ok := r.tokens[t.IDToken]; ok {
+		return fmt.Errorf("duplicate refresh token id %s", t.IDToken)
+	}
+	r.tokens[t.IDToken] = *t
+	return nil
+}
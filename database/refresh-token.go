@@ -0,0 +1,83 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrTokenRevoked = errors.New("refresh token is revoked")
+
+//RefreshToken issued refresh token, IDToken is the jti of the token
+type RefreshToken struct {
+	IDToken     string     `gorm:"column:id_token;type:varchar(36)"`
+	IDUser      string     `gorm:"column:id_user;type:varchar(36)"`
+	ExpiresAt   time.Time  `gorm:"column:expires_datetime"`
+	CreatedDate time.Time  `gorm:"column:created_datetime"`
+	RevokedAt   *time.Time `gorm:"column:revoked_datetime"`
+	ReplacedBy  *string    `gorm:"column:replaced_by;type:varchar(36)"`
+}
+
+//RefreshTokenRepository refresh token storage, missing tokens are reported as gorm.ErrRecordNotFound
+type RefreshTokenRepository interface {
+	Create(t *RefreshToken) error
+	GetByID(id string) (RefreshToken, error)
+	Rotate(id string, next *RefreshToken, revokedAt time.Time) error
+	Revoke(id string, revokedAt time.Time) error
+	RevokeUser(idUser string, revokedAt time.Time) error
+}
+
+//RefreshTokenGorm postgres RefreshTokenRepository
+type RefreshTokenGorm struct {
+	DB *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return RefreshTokenGorm{DB: db}
+}
+
+func (r RefreshTokenGorm) Create(t *RefreshToken) error {
+	return r.DB.Table("refresh_token").Create(t).Error
+}
+
+func (r RefreshTokenGorm) GetByID(id string) (RefreshToken, error) {
+	t := RefreshToken{}
+	err := r.DB.Table("refresh_token").Where("id_token=?", id).Take(&t).Error
+	return t, err
+}
+
+//Rotate revokes token id and stores next in its place, a token already revoked gives ErrTokenRevoked
+//so two refreshes racing with the same token can't both succeed
+func (r RefreshTokenGorm) Rotate(id string, next *RefreshToken, revokedAt time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		sql := "update refresh_token set revoked_datetime=?, replaced_by=? where id_token=? and revoked_datetime is null"
+		result := tx.Exec(sql, revokedAt, next.IDToken, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenRevoked
+		}
+		return tx.Table("refresh_token").Create(next).Error
+	})
+}
+
+//Revoke revokes token id, revoking it again keeps the first revoked_datetime
+func (r RefreshTokenGorm) Revoke(id string, revokedAt time.Time) error {
+	sql := "update refresh_token set revoked_datetime=coalesce(revoked_datetime, ?) where id_token=?"
+	result := r.DB.Exec(sql, revokedAt, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+//RevokeUser revokes every refresh token of the user still in use
+func (r RefreshTokenGorm) RevokeUser(idUser string, revokedAt time.Time) error {
+	sql := "update refresh_token set revoked_datetime=? where id_user=? and revoked_datetime is null"
+	return r.DB.Exec(sql, revokedAt, idUser).Error
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//ReservationMemory in-memory ReservationRepository working on the products of a ProductMemory,
+//confirmations go through the StockMemory ledger
+type ReservationMemory struct {
+	mu           sync.Mutex
+	reservations map[string]Reservation
+	products     *ProductMemory
+	stock        *StockMemory
+}
+
+func NewReservationMemoryRepository(products *ProductMemory, stock *StockMemory) *ReservationMemory {
+	return &ReservationMemory{reservations: map[string]Reservation{}, products: products, stock: stock}
+}
+
+func (r *ReservationMemory) Reserve(res *Reservation, limit int, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit > 0 {
+		open := 0
+		for _, other := range r.reservations {
+			if other.IDCustomer == res.IDCustomer && other.StatusAt(res.CreatedDate) == ReservationActive {
+				open++
+			}
+		}
+		if open >= limit {
+			return ErrReservationLimit
+		}
+	}
+
+	p, err := r.products.GetByID(res.IDProduct)
+	if err != nil {
+		return err
+	}
+	if p.Quantity-r.reserved(res.IDProduct, res.CreatedDate) < res.Quantity {
+		return ErrInsufficientStock
+	}
+	if _, ok := r.reservations[id]; ok {
+		return fmt.Errorf("duplicate reservation id %s", id)
+	}
+	res.IDReservation = id
+	res.Status = ReservationActive
+	r.reservations[id] = *res
+	return nil
+}
+
+func (r *ReservationMemory) GetByID(id string) (Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, ok := r.reservations[id]
+	if !ok {
+		return Reservation{}, gorm.ErrRecordNotFound
+	}
+	return res, nil
+}
+
+func (r *ReservationMemory) Confirm(id, actor string, now time.Time, newID func() (string, error)) (Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, ok := r.reservations[id]
+	if !ok {
+		return Reservation{}, gorm.ErrRecordNotFound
+	}
+	if res.Status != ReservationActive {
+		return res, ErrReservationClosed
+	}
+	if res.StatusAt(now) == ReservationExpired {
+		return res, ErrReservationExpired
+	}
+
+	movement := StockMovement{
+		IDProduct:      res.IDProduct,
+		MovementType:   MovementSale,
+		QuantityChange: -res.Quantity,
+		Reason:         "reservation " + res.IDReservation,
+		Actor:          actor,
+		CreatedDate:    now,
+	}
+	if err := r.stock.Adjust(&movement, newID); err != nil {
+		return res, err
+	}
+
+	res.Status = ReservationConfirmed
+	res.UpdatedDate = now
+	r.reservations[id] = res
+	return res, nil
+}
+
+func (r *ReservationMemory) Release(id string, now time.Time) (Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, ok := r.reservations[id]
+	if !ok {
+		return Reservation{}, gorm.ErrRecordNotFound
+	}
+	if res.Status != ReservationActive {
+		return res, ErrReservationClosed
+	}
+	res.Status = ReservationReleased
+	res.UpdatedDate = now
+	r.reservations[id] = res
+	return res, nil
+}
+
+func (r *ReservationMemory) Expire(now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired int64
+	for id, res := range r.reservations {
+		if res.StatusAt(now) == ReservationExpired && res.Status == ReservationActive {
+			res.Status = ReservationExpired
+			res.UpdatedDate = now
+			r.reservations[id] = res
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (r *ReservationMemory) Reserved(productIDs []string, now time.Time) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reserved := map[string]int{}
+	for _, id := range productIDs {
+		if n := r.reserved(id, now); n > 0 {
+			reserved[id] = n
+		}
+	}
+	return reserved, nil
+}
+
+//reserved units held for the product, callers hold r.mu
+func (r *ReservationMemory) reserved(idProduct string, now time.Time) int {
+	n := 0
+	for _, res := range r.reservations {
+		if res.IDProduct == idProduct && res.StatusAt(now) == ReservationActive {
+			n += res.Quantity
+		}
+	}
+	return n
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//reservation status
+const (
+	ReservationActive    = "active"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+var (
+	ErrReservationClosed  = errors.New("reservation is already confirmed, released or expired")
+	ErrReservationExpired = errors.New("reservation has expired")
+	ErrReservationLimit   = errors.New("too many open reservations, confirm or release one first")
+)
+
+//Reservation stock held for a checkout until ExpiresAt, only active reservations lower the available quantity
+type Reservation struct {
+	IDReservation string    `gorm:"column:id_reservation;type:varchar(36)"`
+	IDProduct     string    `gorm:"column:id_product;type:varchar(36)"`
+	IDCustomer    string    `gorm:"column:id_customer;type:varchar(36)"`
+	Quantity      int       `gorm:"column:quantity;type:int"`
+	Status        string    `gorm:"column:status;type:varchar(20)"`
+	ExpiresAt     time.Time `gorm:"column:expires_at"`
+	CreatedDate   time.Time `gorm:"column:created_datetime"`
+	UpdatedDate   time.Time `gorm:"column:updated_datetime"`
+}
+
+//StatusAt status of the reservation at now, an active reservation past ExpiresAt is expired even before the sweeper ran
+func (r Reservation) StatusAt(now time.Time) string {
+	if r.Status == ReservationActive && !now.Before(r.ExpiresAt) {
+		return ReservationExpired
+	}
+	return r.Status
+}
+
+//ReservationRepository stock reservations, Reserve refuses with ErrInsufficientStock when the available
+//quantity (quantity - active reservations) is too low and with ErrReservationLimit when the customer already
+//holds limit active reservations (0 for no limit), missing rows are reported as gorm.ErrRecordNotFound
+type ReservationRepository interface {
+	Reserve(r *Reservation, limit int, newID func() (string, error)) error
+	GetByID(id string) (Reservation, error)
+	Confirm(id, actor string, now time.Time, newID func() (string, error)) (Reservation, error)
+	Release(id string, now time.Time) (Reservation, error)
+	Expire(now time.Time) (int64, error)
+	Reserved(productIDs []string, now time.Time) (map[string]int, error)
+}
+
+//ReservationGorm postgres ReservationRepository
+type ReservationGorm struct {
+	DB *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return ReservationGorm{DB: db}
+}
+
+//Reserve locks the product row so concurrent reservations of the same product are checked one after the other
+//Reserve the reservations of a customer are counted under an advisory lock of the customer so concurrent
+//requests can't go over limit
+func (r ReservationGorm) Reserve(res *Reservation, limit int, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if limit > 0 {
+			if err := tx.Exec("select pg_advisory_xact_lock(hashtext(?))", "stock_reservation:"+res.IDCustomer).Error; err != nil {
+				return err
+			}
+			var open int64
+			err := tx.Table("stock_reservation").
+				Where("id_customer=? and status=? and expires_at>? and id_product in (?)", res.IDCustomer, ReservationActive, res.CreatedDate, tenantProducts(tx)).
+				Count(&open).Error
+			if err != nil {
+				return err
+			}
+			if open >= int64(limit) {
+				return ErrReservationLimit
+			}
+		}
+
+		p := Product{}
+		err := tx.Table("product").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id_product, quantity").Where("id_product=?", res.IDProduct).Take(&p).Error
+		if err != nil {
+			return err
+		}
+
+		reserved, err := ReservationGorm{DB: tx}.Reserved([]string{res.IDProduct}, res.CreatedDate)
+		if err != nil {
+			return err
+		}
+		if p.Quantity-reserved[res.IDProduct] < res.Quantity {
+			return ErrInsufficientStock
+		}
+
+		res.IDReservation = id
+		res.Status = ReservationActive
+		return tx.Table("stock_reservation").Create(res).Error
+	})
+}
+
+//GetByID reservations of products the db can't see (another tenant's) are reported as gorm.ErrRecordNotFound
+func (r ReservationGorm) GetByID(id string) (Reservation, error) {
+	res := Reservation{}
+	err := r.DB.Table("stock_reservation").Where("id_reservation=? and id_product in (?)", id, tenantProducts(r.DB)).Take(&res).Error
+	return res, err
+}
+
+//Confirm takes the reserved units out of the product quantity through a sale movement of the ledger
+func (r ReservationGorm) Confirm(id, actor string, now time.Time, newID func() (string, error)) (Reservation, error) {
+	res := Reservation{}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("stock_reservation").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id_reservation=? and id_product in (?)", id, tenantProducts(tx)).Take(&res).Error
+		if err != nil {
+			return err
+		}
+		if res.Status != ReservationActive {
+			return ErrReservationClosed
+		}
+		if res.StatusAt(now) == ReservationExpired {
+			return ErrReservationExpired
+		}
+
+		movement := StockMovement{
+			IDProduct:      res.IDProduct,
+			MovementType:   MovementSale,
+			QuantityChange: -res.Quantity,
+			Reason:         "reservation " + res.IDReservation,
+			Actor:          actor,
+			CreatedDate:    now,
+		}
+		if err := (StockGorm{DB: tx}).Adjust(&movement, newID); err != nil {
+			return err
+		}
+
+		res.Status = ReservationConfirmed
+		res.UpdatedDate = now
+		sql := "update stock_reservation set status=?, updated_datetime=? where id_reservation=?"
+		return tx.Exec(sql, res.Status, res.UpdatedDate, id).Error
+	})
+	return res, err
+}
+
+func (r ReservationGorm) Release(id string, now time.Time) (Reservation, error) {
+	sql := "update stock_reservation set status=?, updated_datetime=? where id_reservation=? and status=? and id_product in (?)"
+	result := r.DB.Exec(sql, ReservationReleased, now, id, ReservationActive, tenantProducts(r.DB))
+	if result.Error != nil {
+		return Reservation{}, result.Error
+	}
+
+	res, err := r.GetByID(id)
+	if err != nil {
+		return Reservation{}, err
+	}
+	if result.RowsAffected == 0 {
+		return res, ErrReservationClosed
+	}
+	return res, nil
+}
+
+//Expire marks every active reservation past its expiry as expired and returns how many there were
+func (r ReservationGorm) Expire(now time.Time) (int64, error) {
+	sql := "update stock_reservation set status=?, updated_datetime=? where status=? and expires_at<=?"
+	result := r.DB.Exec(sql, ReservationExpired, now, ReservationActive, now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("reservation expire : %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+//Reserved units held by active, not yet expired reservations per product
+func (r ReservationGorm) Reserved(productIDs []string, now time.Time) (map[string]int, error) {
+	reserved := map[string]int{}
+	if len(productIDs) == 0 {
+		return reserved, nil
+	}
+
+	rows := []struct {
+		IDProduct string `gorm:"column:id_product"`
+		Quantity  int    `gorm:"column:quantity"`
+	}{}
+	err := r.DB.Table("stock_reservation").Select("id_product, sum(quantity) as quantity").
+		Where("id_product in ? and status=? and expires_at>?", productIDs, ReservationActive, now).
+		Group("id_product").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		reserved[row.IDProduct] = row.Quantity
+	}
+	return reserved, nil
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+//StockMemory in-memory StockRepository adjusting the quantities and warehouse levels of a ProductMemory
+type StockMemory struct {
+	mu         sync.RWMutex
+	products   *ProductMemory
+	warehouses *WarehouseMemory
+	movements  []StockMovement
+}
+
+func NewStockMemoryRepository(products *ProductMemory, warehouses *WarehouseMemory) *StockMemory {
+	return &StockMemory{products: products, warehouses: warehouses}
+}
+
+func (r *StockMemory) Adjust(m *StockMovement, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	p, ok := r.products.products[m.IDProduct]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	levels := r.products.levels(m.IDProduct)
+	if m.IDWarehouse == "" {
+		m.IDWarehouse = pickLevel(levels, m.QuantityChange)
+	}
+	if _, err := r.warehouses.GetByID(m.IDWarehouse); err != nil {
+		return err
+	}
+	if p.Quantity+m.QuantityChange < 0 || levels[m.IDWarehouse]+m.QuantityChange < 0 {
+		return ErrInsufficientStock
+	}
+
+	m.IDMovement = id
+	m.QuantityAfter = p.Quantity + m.QuantityChange
+	levels[m.IDWarehouse] += m.QuantityChange
+	p.Quantity = m.QuantityAfter
+	p.UpdatedDate = m.CreatedDate
+	r.products.products[m.IDProduct] = p
+
+	r.mu.Lock()
+	r.movements = append(r.movements, *m)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *StockMemory) Transfer(t StockTransfer, newID func() (string, error)) ([]StockMovement, error) {
+	outID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	inID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	p, ok := r.products.products[t.IDProduct]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	for _, id := range []string{t.FromWarehouse, t.ToWarehouse} {
+		if _, err := r.warehouses.GetByID(id); err != nil {
+			return nil, err
+		}
+	}
+	levels := r.products.levels(t.IDProduct)
+	if levels[t.FromWarehouse] < t.Quantity {
+		return nil, ErrInsufficientStock
+	}
+	levels[t.FromWarehouse] -= t.Quantity
+	levels[t.ToWarehouse] += t.Quantity
+
+	movements := transferMovements(t, outID, inID, p.Quantity)
+	r.mu.Lock()
+	r.movements = append(r.movements, movements...)
+	r.mu.Unlock()
+	return movements, nil
+}
+
+func (r *StockMemory) History(idProduct string, page, size int) ([]StockMovement, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	movements := []StockMovement{}
+	r.mu.RLock()
+	for _, m := range r.movements {
+		if m.IDProduct == idProduct {
+			movements = append(movements, m)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(movements, func(i, j int) bool {
+		if !movements[i].CreatedDate.Equal(movements[j].CreatedDate) {
+			return movements[i].CreatedDate.After(movements[j].CreatedDate)
+		}
+		return movements[i].IDMovement > movements[j].IDMovement
+	})
+
+	total := int64(len(movements))
+	offset := (page - 1) * size
+	if offset > len(movements) {
+		offset = len(movements)
+	}
+	movements = movements[offset:]
+	if len(movements) > size {
+		movements = movements[:size]
+	}
+	return movements, total, nil
+}
+
+func (r *StockMemory) Levels(productIDs []string) ([]WarehouseStock, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	levels := []WarehouseStock{}
+	for _, id := range productIDs {
+		for warehouse, quantity := range r.products.stock[id] {
+			if quantity > 0 {
+				levels = append(levels, WarehouseStock{IDWarehouse: warehouse, IDProduct: id, Quantity: quantity})
+			}
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].IDProduct != levels[j].IDProduct {
+			return levels[i].IDProduct < levels[j].IDProduct
+		}
+		return levels[i].IDWarehouse < levels[j].IDWarehouse
+	})
+	return levels, nil
+}
+
+//pickLevel same choice as pickWarehouse over in-memory levels
+func pickLevel(levels map[string]int, change int) string {
+	picked, most := DefaultWarehouseID, 0
+	if change > 0 {
+		return picked
+	}
+	for warehouse, quantity := range levels {
+		if quantity > most || (quantity == most && quantity > 0 && warehouse < picked) {
+			picked, most = warehouse, quantity
+		}
+	}
+	return picked
+}
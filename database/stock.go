@@ -0,0 +1,272 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//stock movement types, transfers are only recorded by StockRepository.Transfer
+const (
+	MovementReceipt    = "receipt"
+	MovementSale       = "sale"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+	MovementTransfer   = "transfer"
+)
+
+var ErrInsufficientStock = errors.New("not enough stock")
+
+//StockMovement ledger row of one change of a product quantity at a warehouse,
+//QuantityAfter is the product total over every warehouse
+type StockMovement struct {
+	IDMovement     string    `gorm:"column:id_movement;type:varchar(36)"`
+	IDProduct      string    `gorm:"column:id_product;type:varchar(36)"`
+	IDWarehouse    string    `gorm:"column:id_warehouse;type:varchar(36)"`
+	MovementType   string    `gorm:"column:movement_type;type:varchar(20)"`
+	QuantityChange int       `gorm:"column:quantity_change;type:int"`
+	QuantityAfter  int       `gorm:"column:quantity_after;type:int"`
+	Reason         string    `gorm:"column:reason;type:varchar(200)"`
+	Actor          string    `gorm:"column:actor;type:varchar(100)"`
+	CreatedDate    time.Time `gorm:"column:created_datetime"`
+}
+
+//StockTransfer move of Quantity units of a product from one warehouse to another
+type StockTransfer struct {
+	IDProduct     string
+	FromWarehouse string
+	ToWarehouse   string
+	Quantity      int
+	Reason        string
+	Actor         string
+	CreatedDate   time.Time
+}
+
+//MovementChange signed quantity change of a movement, receipt/sale/return take a positive quantity,
+//adjustment takes the signed correction itself
+func MovementChange(movementType string, quantity int) (int, error) {
+	switch movementType {
+	case MovementReceipt, MovementReturn:
+		if quantity <= 0 {
+			return 0, fmt.Errorf("%s quantity must be positive", movementType)
+		}
+		return quantity, nil
+	case MovementSale:
+		if quantity <= 0 {
+			return 0, fmt.Errorf("%s quantity must be positive", movementType)
+		}
+		return -quantity, nil
+	case MovementAdjustment:
+		if quantity == 0 {
+			return 0, fmt.Errorf("adjustment quantity must not be zero")
+		}
+		return quantity, nil
+	}
+	return 0, fmt.Errorf("unknown movement type %s, use receipt, sale, adjustment or return", movementType)
+}
+
+//StockRepository inventory ledger and per warehouse stock levels. Adjust applies the movement at
+//IDWarehouse, without warehouse stock comes in at the default warehouse and goes out of the warehouse
+//holding the most. Both refuse with ErrInsufficientStock when a level would go negative,
+//missing products or warehouses are reported as gorm.ErrRecordNotFound
+type StockRepository interface {
+	Adjust(m *StockMovement, newID func() (string, error)) error
+	Transfer(t StockTransfer, newID func() (string, error)) ([]StockMovement, error)
+	History(idProduct string, page, size int) ([]StockMovement, int64, error)
+	Levels(productIDs []string) ([]WarehouseStock, error)
+}
+
+//StockGorm postgres StockRepository, every change locks the product row first so
+//the product total and its warehouse levels are always changed one writer at a time
+type StockGorm struct {
+	DB *gorm.DB
+}
+
+func NewStockRepository(db *gorm.DB) StockRepository {
+	return StockGorm{DB: db}
+}
+
+//Adjust locks the product row, applies QuantityChange and records the movement in one transaction
+func (r StockGorm) Adjust(m *StockMovement, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		p, err := lockProduct(tx, m.IDProduct)
+		if err != nil {
+			return err
+		}
+
+		if m.IDWarehouse == "" {
+			m.IDWarehouse, err = pickWarehouse(tx, m.IDProduct, m.QuantityChange, newID, m.CreatedDate)
+			if err != nil {
+				return err
+			}
+		}
+		level, err := stockLevel(tx, m.IDWarehouse, m.IDProduct)
+		if err != nil {
+			return err
+		}
+
+		m.IDMovement = id
+		m.QuantityAfter = p.Quantity + m.QuantityChange
+		if m.QuantityAfter < 0 || level+m.QuantityChange < 0 {
+			return ErrInsufficientStock
+		}
+
+		sql := "update warehouse_stock set quantity=? where id_warehouse=? and id_product=?"
+		if err := tx.Exec(sql, level+m.QuantityChange, m.IDWarehouse, m.IDProduct).Error; err != nil {
+			return err
+		}
+		values := map[string]interface{}{"quantity": m.QuantityAfter, "updated_datetime": m.CreatedDate}
+		if err := tx.Table("product").Where("id_product=?", m.IDProduct).Updates(values).Error; err != nil {
+			return err
+		}
+		return tx.Table("stock_movement").Create(m).Error
+	})
+	if err != nil && !errors.Is(err, ErrInsufficientStock) && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("stock adjust : %w", err)
+	}
+	return err
+}
+
+//Transfer moves stock between two warehouses, recorded as an outgoing and an incoming transfer movement
+func (r StockGorm) Transfer(t StockTransfer, newID func() (string, error)) ([]StockMovement, error) {
+	outID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	inID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	movements := []StockMovement{}
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		p, err := lockProduct(tx, t.IDProduct)
+		if err != nil {
+			return err
+		}
+		from, err := stockLevel(tx, t.FromWarehouse, t.IDProduct)
+		if err != nil {
+			return err
+		}
+		to, err := stockLevel(tx, t.ToWarehouse, t.IDProduct)
+		if err != nil {
+			return err
+		}
+		if from < t.Quantity {
+			return ErrInsufficientStock
+		}
+
+		sql := "update warehouse_stock set quantity=? where id_warehouse=? and id_product=?"
+		if err := tx.Exec(sql, from-t.Quantity, t.FromWarehouse, t.IDProduct).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(sql, to+t.Quantity, t.ToWarehouse, t.IDProduct).Error; err != nil {
+			return err
+		}
+
+		movements = transferMovements(t, outID, inID, p.Quantity)
+		return tx.Table("stock_movement").Create(&movements).Error
+	})
+	if err != nil && !errors.Is(err, ErrInsufficientStock) && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("stock transfer : %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+//History movements of a product, newest first
+func (r StockGorm) History(idProduct string, page, size int) ([]StockMovement, int64, error) {
+	handleErr := func(err error) ([]StockMovement, int64, error) {
+		return nil, 0, fmt.Errorf("stock history : %w", err)
+	}
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	var total int64
+	base := r.DB.Table("stock_movement").Where("id_product=? and id_product in (?)", idProduct, tenantProducts(r.DB))
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return handleErr(err)
+	}
+
+	movements := []StockMovement{}
+	err := base.Session(&gorm.Session{}).Order("created_datetime desc, id_movement desc").Offset((page - 1) * size).Limit(size).Find(&movements).Error
+	if err != nil {
+		return handleErr(err)
+	}
+	return movements, total, nil
+}
+
+//Levels stock of the products at every warehouse holding some
+func (r StockGorm) Levels(productIDs []string) ([]WarehouseStock, error) {
+	levels := []WarehouseStock{}
+	if len(productIDs) == 0 {
+		return levels, nil
+	}
+	err := r.DB.Table("warehouse_stock").Where("id_product in ? and quantity > 0 and id_product in (?)", productIDs, tenantProducts(r.DB)).
+		Order("id_product, id_warehouse").Find(&levels).Error
+	return levels, err
+}
+
+func lockProduct(tx *gorm.DB, id string) (Product, error) {
+	p := Product{}
+	err := tx.Table("product").Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id_product, quantity, price").Where("id_product=?", id).Take(&p).Error
+	return p, err
+}
+
+//pickWarehouse warehouse of a movement sent without one
+func pickWarehouse(tx *gorm.DB, idProduct string, change int, newID func() (string, error), now time.Time) (string, error) {
+	if change > 0 {
+		return defaultWarehouse(tx, newID, now)
+	}
+	level := WarehouseStock{}
+	err := tx.Table("warehouse_stock").Where("id_product=?", idProduct).
+		Order("quantity desc, id_warehouse").Take(&level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultWarehouse(tx, newID, now)
+	}
+	return level.IDWarehouse, err
+}
+
+//stockLevel quantity of the product at the warehouse, the level row is created when missing
+func stockLevel(tx *gorm.DB, idWarehouse, idProduct string) (int, error) {
+	if _, err := (WarehouseGorm{DB: tx}).GetByID(idWarehouse); err != nil {
+		return 0, err
+	}
+	sql := "insert into warehouse_stock (id_warehouse, id_product, quantity) values (?, ?, 0) on conflict do nothing"
+	if err := tx.Exec(sql, idWarehouse, idProduct).Error; err != nil {
+		return 0, err
+	}
+
+	level := WarehouseStock{}
+	err := tx.Table("warehouse_stock").Where("id_warehouse=? and id_product=?", idWarehouse, idProduct).Take(&level).Error
+	return level.Quantity, err
+}
+
+//transferMovements outgoing and incoming ledger rows of a transfer, the product total doesn't change
+func transferMovements(t StockTransfer, outID, inID string, total int) []StockMovement {
+	movement := StockMovement{
+		IDProduct:     t.IDProduct,
+		MovementType:  MovementTransfer,
+		QuantityAfter: total,
+		Reason:        t.Reason,
+		Actor:         t.Actor,
+		CreatedDate:   t.CreatedDate,
+	}
+	out, in := movement, movement
+	out.IDMovement, out.IDWarehouse, out.QuantityChange = outID, t.FromWarehouse, -t.Quantity
+	in.IDMovement, in.IDWarehouse, in.QuantityChange = inID, t.ToWarehouse, t.Quantity
+	return []StockMovement{out, in}
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//DefaultTenantID tenant created by the migrations, owns the products, users and api keys from before tenants
+const DefaultTenantID = "default"
+
+//tenantTables tables carrying the tenant_id column the tenant scope narrows, the rows of the other tables
+//hang off one of them and are reached through it
+var tenantTables = map[string]bool{
+	"product":         true,
+	"product_variant": true,
+	"category":        true,
+	"warehouse":       true,
+	"exchange_rate":   true,
+	"promotion":       true,
+	"cart":            true,
+	"orders":          true,
+	"wallet":          true,
+}
+
+//tenantSetting gorm setting holding the tenant of a db made by WithTenant
+const tenantSetting = "product-test:tenant"
+
+//WithTenant db whose statements on the tenant tables only see, change and insert rows of tenant,
+//transactions and repositories built on it keep the tenant. A db without tenant isn't narrowed, that's what
+//the background jobs working over every tenant use
+func WithTenant(db *gorm.DB, tenant string) *gorm.DB {
+	return db.Set(tenantSetting, tenant).Session(&gorm.Session{})
+}
+
+//RegisterTenantScope installs the callbacks adding tenant_id = <tenant of WithTenant> to every select, update and delete
+//on the tenant tables and setting tenant_id on inserts. Raw sql of Exec and Raw is sent as written, so statements
+//on the tenant tables go through the query builder
+func RegisterTenantScope(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:create", tenantColumn); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", tenantWhere); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:row", tenantWhere); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", tenantWhere); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("tenant:delete", tenantWhere)
+}
+
+//tenantProducts subquery of the ids of the products db can see, narrows tables referencing product
+//to the tenant of WithTenant
+func tenantProducts(db *gorm.DB) *gorm.DB {
+	return db.Table("product").Select("id_product")
+}
+
+func tenantWhere(db *gorm.DB) {
+	table, tenant, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: table, Name: "tenant_id"}, Value: tenant},
+	}})
+}
+
+func tenantColumn(db *gorm.DB) {
+	_, tenant, ok := statementTenant(db)
+	if !ok || db.Statement.Schema == nil || db.Statement.Schema.LookUpField("tenant_id") == nil {
+		return
+	}
+	db.Statement.SetColumn("tenant_id", tenant)
+}
+
+//statementTenant tenant table (its alias when it has one) and tenant of a statement. The first table of a table expression
+//like "product, to_tsquery(?) q" wins over Table, which Find fills from the destination type
+//(product_search_results) when the expression has no alias
+func statementTenant(db *gorm.DB) (string, string, bool) {
+	if db.Error != nil {
+		return "", "", false
+	}
+	table, reference := db.Statement.Table, db.Statement.Table
+	if db.Statement.TableExpr != nil {
+		table, reference = exprTable(db.Statement.TableExpr.SQL)
+	}
+	if !tenantTables[table] {
+		return "", "", false
+	}
+	tenant, ok := db.Get(tenantSetting)
+	if !ok {
+		return "", "", false
+	}
+	id, ok := tenant.(string)
+	return reference, id, ok
+}
+
+//exprTable first table of a table expression and the name it's referenced by, its alias when it has one
+func exprTable(expr string) (string, string) {
+	fields := strings.Fields(strings.SplitN(expr, ",", 2)[0])
+	if len(fields) == 0 {
+		return "", ""
+	}
+	table := strings.Trim(fields[0], `"`)
+	return table, strings.Trim(fields[len(fields)-1], `"`)
+}
@@ -0,0 +1,320 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+//statement sql sent to the recording driver with its arguments
+type statement struct {
+	SQL  string
+	Args []driver.Value
+}
+
+//recorder database/sql driver keeping every statement, queries answer no rows and
+//exec statements report one affected row
+type recorder struct {
+	mu         sync.Mutex
+	statements []statement
+}
+
+func (r *recorder) record(query string, args []driver.Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, statement{SQL: query, Args: args})
+}
+
+//take statements recorded since the last take
+func (r *recorder) take() []statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := r.statements
+	r.statements = nil
+	return statements
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return recordConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+type recordConn struct{ r *recorder }
+
+func (c recordConn) Prepare(query string) (driver.Stmt, error) { return recordStmt{c.r, query}, nil }
+func (c recordConn) Close() error                              { return nil }
+func (c recordConn) Begin() (driver.Tx, error)                 { return recordTx{}, nil }
+
+type recordTx struct{}
+
+func (recordTx) Commit() error   { return nil }
+func (recordTx) Rollback() error { return nil }
+
+type recordStmt struct {
+	r     *recorder
+	query string
+}
+
+func (s recordStmt) Close() error  { return nil }
+func (s recordStmt) NumInput() int { return -1 }
+
+func (s recordStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.r.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s recordStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.r.record(s.query, args)
+	return noRows{}, nil
+}
+
+type noRows struct{}
+
+func (noRows) Columns() []string              { return []string{} }
+func (noRows) Close() error                   { return nil }
+func (noRows) Next(dest []driver.Value) error { return io.EOF }
+
+//scopedTables tables whose rows belong to a tenant
+var scopedTables = []string{"product", "product_variant", "category", "warehouse", "exchange_rate", "promotion", "cart", "orders", "wallet"}
+
+//productTables tables whose rows hang off a product of a tenant, reading or changing them has to go through
+//a tenant table in the same statement
+var productTables = []string{"product_category", "product_price", "price_history", "price_schedule", "stock_movement",
+	"warehouse_stock", "stock_reservation", "cart_item", "order_line", "wallet_transaction"}
+
+//tableReference tables a statement reads or writes, raw sql written in lower case included
+var tableReference = regexp.MustCompile(`(?i)\b(?:from|update|into|join)\s+"?(\w+)"?`)
+
+//insertColumns column list of an insert statement
+var insertColumns = regexp.MustCompile(`(?i)^insert into "?\w+"? \(([^)]*)\)`)
+
+func openRecorder(t *testing.T) (*gorm.DB, *recorder) {
+	t.Helper()
+	r := &recorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(r)}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterTenantScope(db); err != nil {
+		t.Fatal(err)
+	}
+	return db, r
+}
+
+func sequentialIDs() func() (string, error) {
+	n := 0
+	return func() (string, error) {
+		n++
+		return fmt.Sprintf("id-%d", n), nil
+	}
+}
+
+//checkScoped fails the test when a statement reaches a tenant table without being narrowed to tenant:
+//selects, updates and deletes need the tenant_id condition, inserts the tenant_id column
+func checkScoped(t *testing.T, name, tenant string, statements []statement) {
+	t.Helper()
+	if len(statements) == 0 {
+		t.Errorf("%s: no statement sent", name)
+	}
+	for _, st := range statements {
+		hasTenant := false
+		for _, arg := range st.Args {
+			if arg == tenant {
+				hasTenant = true
+			}
+		}
+		references := tableReference.FindAllStringSubmatch(st.SQL, -1)
+		throughTenant := false
+		for _, match := range references {
+			throughTenant = throughTenant || isTable(scopedTables, match[1])
+		}
+		for _, match := range references {
+			table := match[1]
+			if isTable(productTables, table) && !throughTenant && insertColumns.FindStringSubmatch(st.SQL) == nil {
+				t.Errorf("%s: statement on %s doesn't go through a tenant table: %s %v", name, table, st.SQL, st.Args)
+			}
+			if !isTable(scopedTables, table) {
+				continue
+			}
+			scoped := strings.Contains(st.SQL, `"`+table+`"."tenant_id" = `)
+			if columns := insertColumns.FindStringSubmatch(st.SQL); columns != nil && strings.Contains(st.SQL, `INTO "`+table+`"`) {
+				scoped = strings.Contains(columns[1], `"tenant_id"`)
+			}
+			if !scoped || !hasTenant {
+				t.Errorf("%s: statement on %s isn't narrowed to tenant %s: %s %v", name, table, tenant, st.SQL, st.Args)
+			}
+		}
+	}
+}
+
+func isTable(tables []string, table string) bool {
+	for _, scoped := range tables {
+		if table == scoped {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTenantScopeNarrowsEveryStatement(t *testing.T) {
+	db, rec := openRecorder(t)
+	tenant := WithTenant(db, "b")
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	newID := sequentialIDs()
+	coupon := "SAVE"
+	price := 1500
+
+	calls := []struct {
+		name string
+		call func()
+	}{
+		{"product create", func() {
+			p := Product{ProductName: "tea", Price: 1000, Quantity: 3, CreatedDate: now}
+			NewProductRepository(tenant).Create(&p, newID)
+		}},
+		{"product get", func() { NewProductRepository(tenant).GetByID("p1") }},
+		{"product update", func() {
+			NewProductRepository(tenant).Update(&Product{IDProduct: "p1", ProductName: "tea", Price: 1200, UpdatedDate: now})
+		}},
+		{"product set active", func() { NewProductRepository(tenant).SetActive("p1", false, now) }},
+		{"product list", func() {
+			NewProductRepository(tenant).List(ProductQuery{CategoryIDs: []string{"c1"}, WarehouseID: "w1", Size: 10})
+		}},
+		{"product search", func() { NewProductRepository(tenant).Search("tea", false, 1, 10) }},
+		{"product categories", func() { NewProductRepository(tenant).SetCategories("p1", []string{"c1"}) }},
+		{"variant create", func() {
+			v := Variant{IDProduct: "p1", SKU: "TEA-1", Attributes: VariantAttributes{"size": "s"}, CreatedDate: now}
+			NewVariantRepository(tenant).Create(&v, newID)
+		}},
+		{"variant get", func() { NewVariantRepository(tenant).GetByID("v1") }},
+		{"variant update", func() {
+			NewVariantRepository(tenant).Update(&Variant{IDVariant: "v1", SKU: "TEA-1", Price: &price, UpdatedDate: now})
+		}},
+		{"category create", func() {
+			NewCategoryRepository(tenant).Create(&Category{Name: "drinks", CreatedDate: now}, newID)
+		}},
+		{"category list", func() { NewCategoryRepository(tenant).List() }},
+		{"category update", func() { NewCategoryRepository(tenant).Update(&Category{IDCategory: "c1", Name: "hot"}) }},
+		{"category delete", func() { NewCategoryRepository(tenant).Delete("c1") }},
+		{"warehouse create", func() {
+			NewWarehouseRepository(tenant).Create(&Warehouse{Code: "EAST", Name: "East", CreatedDate: now}, newID)
+		}},
+		{"warehouse list", func() { NewWarehouseRepository(tenant).List() }},
+		{"warehouse update", func() {
+			NewWarehouseRepository(tenant).Update(&Warehouse{IDWarehouse: "w1", Code: "WEST", Name: "West", UpdatedDate: now})
+		}},
+		{"exchange rate set", func() {
+			NewCurrencyRepository(tenant).SetRate(ExchangeRate{Base: "USD", Quote: "IDR", Rate: "15000", UpdatedDate: now})
+		}},
+		{"exchange rate delete", func() { NewCurrencyRepository(tenant).DeleteRate("USD", "IDR") }},
+		{"exchange rate list", func() { NewCurrencyRepository(tenant).Rates() }},
+		{"price delete", func() { NewCurrencyRepository(tenant).DeletePrice("p1", "USD") }},
+		{"stock adjust", func() {
+			m := StockMovement{IDProduct: "p1", MovementType: MovementReceipt, QuantityChange: 2, CreatedDate: now}
+			NewStockRepository(tenant).Adjust(&m, newID)
+		}},
+		{"stock history", func() { NewStockRepository(tenant).History("p1", 1, 10) }},
+		{"stock transfer", func() {
+			t := StockTransfer{IDProduct: "p1", FromWarehouse: "w1", ToWarehouse: "w2", Quantity: 1, CreatedDate: now}
+			NewStockRepository(tenant).Transfer(t, newID)
+		}},
+		{"reservation reserve", func() {
+			r := Reservation{IDProduct: "p1", IDCustomer: "u1", Quantity: 1, ExpiresAt: now.Add(time.Hour), CreatedDate: now}
+			NewReservationRepository(tenant).Reserve(&r, 5, newID)
+		}},
+		{"reservation get", func() { NewReservationRepository(tenant).GetByID("r1") }},
+		{"reservation confirm", func() { NewReservationRepository(tenant).Confirm("r1", "user:u1", now, newID) }},
+		{"reservation release", func() { NewReservationRepository(tenant).Release("r1", now) }},
+		{"price schedule create", func() {
+			ps := PriceSchedule{IDProduct: "p1", Price: 900, EffectiveFrom: now, CreatedDate: now}
+			NewPriceRepository(tenant).CreateSchedule(&ps, newID)
+		}},
+		{"price schedule list", func() { NewPriceRepository(tenant).Schedules("p1") }},
+		{"price schedule cancel", func() { NewPriceRepository(tenant).CancelSchedule("ps1", now) }},
+		{"promotion create", func() {
+			p := Promotion{Name: "ten off", Kind: PromotionPercentage, Value: 10, CouponCode: &coupon, StartsAt: now, CreatedDate: now}
+			NewPromotionRepository(tenant).Create(&p, newID)
+		}},
+		{"promotion get", func() { NewPromotionRepository(tenant).GetByID("pr1") }},
+		{"promotion list", func() { NewPromotionRepository(tenant).List() }},
+		{"promotion running", func() { NewPromotionRepository(tenant).Running(now, coupon) }},
+		{"promotion update", func() {
+			p := Promotion{IDPromotion: "pr1", Name: "five off", Kind: PromotionPercentage, Value: 5, StartsAt: now, UpdatedDate: now}
+			NewPromotionRepository(tenant).Update(&p)
+		}},
+		{"promotion delete", func() { NewPromotionRepository(tenant).Delete("pr1") }},
+		{"cart create", func() { NewCartRepository(tenant).Create(&Cart{IDCustomer: "u1", CreatedDate: now}, newID) }},
+		{"cart get", func() { NewCartRepository(tenant).GetByID("cart1") }},
+		{"cart add item", func() {
+			NewCartRepository(tenant).AddItem(CartItem{IDCart: "cart1", IDProduct: "p1", Quantity: 1, CreatedDate: now})
+		}},
+		{"cart delete", func() { NewCartRepository(tenant).Delete("cart1") }},
+		{"order get", func() { NewOrderRepository(tenant).GetByID("o1") }},
+		{"order list", func() { NewOrderRepository(tenant).List("", OrderPending, 1, 10) }},
+		{"order move", func() { NewOrderRepository(tenant).Move("o1", OrderCancelled, "user:u1", now, newID) }},
+		{"wallet get", func() { NewWalletRepository(tenant).GetByID("u1") }},
+		{"wallet transactions", func() { NewWalletRepository(tenant).Transactions("u1", 1, 10) }},
+		{"wallet deposit", func() {
+			w := WalletTransaction{IDCustomer: "u1", Kind: WalletDeposit, Amount: 500, Currency: "IDR", IdempotencyKey: "k1", CreatedDate: now}
+			NewWalletRepository(tenant).Apply(&w, func(balance, amount int) error { return nil }, newID)
+		}},
+	}
+	for _, c := range calls {
+		c.call()
+		checkScoped(t, c.name, "b", rec.take())
+	}
+}
+
+func TestTenantScopeNarrowsTableExpressions(t *testing.T) {
+	db, rec := openRecorder(t)
+	tenant := WithTenant(db, "b")
+	tenant.Table("product p").Where("p.active").Find(&[]ProductSearchResult{})
+
+	for _, st := range rec.take() {
+		if !strings.Contains(st.SQL, `"p"."tenant_id" = `) {
+			t.Errorf("aliased table isn't narrowed: %s", st.SQL)
+		}
+	}
+}
+
+func TestTenantScopeLeavesOtherTablesAlone(t *testing.T) {
+	db, rec := openRecorder(t)
+	NewReservationRepository(db).Expire(time.Now())
+	NewPromotionRepository(db).List()
+
+	for _, st := range rec.take() {
+		if strings.Contains(st.SQL, "tenant_id") {
+			t.Errorf("statement without tenant is narrowed: %s", st.SQL)
+		}
+	}
+}
+
+func TestPromotionCoversOnlyItsTenant(t *testing.T) {
+	promotion := Promotion{TenantID: "a", Kind: PromotionPercentage, Value: 10}
+	own := PromotionItem{Product: Product{IDProduct: "p1", TenantID: "a"}, UnitPrice: 1000, Quantity: 1}
+	other := PromotionItem{Product: Product{IDProduct: "p2", TenantID: "b"}, UnitPrice: 1000, Quantity: 1}
+
+	if !promotion.Covers(own) {
+		t.Error("untargeted promotion doesn't cover a product of its tenant")
+	}
+	if promotion.Covers(other) {
+		t.Error("untargeted promotion covers a product of another tenant")
+	}
+	promotion.Targets = []PromotionTarget{{TargetType: TargetProduct, IDTarget: "p2"}}
+	if promotion.Covers(other) {
+		t.Error("targeted promotion covers a product of another tenant")
+	}
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//UserMemory in-memory UserRepository
+type UserMemory struct {
+	mu    sync.RWMutex
+	users map[string]User
+	roles map[string][]string
+}
+
+func NewUserMemoryRepository() *UserMemory {
+	return &UserMemory{users: map[string]User{}, roles: map[string][]string{}}
+}
+
+func (r *UserMemory) Create(u *User, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; ok {
+		return fmt.Errorf("duplicate user id %s", id)
+	}
+	if err := r.taken(*u, ""); err != nil {
+		return err
+	}
+	u.IDUser = id
+	stored := *u
+	stored.Roles = nil
+	r.users[id] = stored
+	r.roles[id] = sortedSet(u.Roles)
+	return nil
+}
+
+func (r *UserMemory) GetByID(id string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return User{}, gorm.ErrRecordNotFound
+	}
+	return u, nil
+}
+
+func (r *UserMemory) GetByUsername(username string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if strings.EqualFold(u.Username, username) {
+			return u, nil
+		}
+	}
+	return User{}, gorm.ErrRecordNotFound
+}
+
+func (r *UserMemory) Update(u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[u.IDUser]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if err := r.taken(User{Email: u.Email}, u.IDUser); err != nil {
+		return err
+	}
+	stored.Email = u.Email
+	stored.FullName = u.FullName
+	stored.Phone = u.Phone
+	stored.UpdatedDate = u.UpdatedDate
+	r.users[u.IDUser] = stored
+	return nil
+}
+
+func (r *UserMemory) SetPassword(id, password string, updatedDate time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Password = password
+	stored.UpdatedDate = updatedDate
+	r.users[id] = stored
+	return nil
+}
+
+func (r *UserMemory) Deactivate(id string, deactivatedDate time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Active = false
+	stored.UpdatedDate = deactivatedDate
+	if stored.DeactivatedDate == nil {
+		stored.DeactivatedDate = &deactivatedDate
+	}
+	r.users[id] = stored
+	return nil
+}
+
+func (r *UserMemory) Roles(id string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]string{}, r.roles[id]...), nil
+}
+
+func (r *UserMemory) SetRoles(id string, roles []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.roles[id] = sortedSet(roles)
+	return nil
+}
+
+//sortedSet de-duplicated sorted copy of list, the order UserGorm and APIKeyGorm read roles and scopes in
+func sortedSet(list []string) []string {
+	seen := map[string]bool{}
+	sorted := []string{}
+	for _, item := range list {
+		if !seen[item] {
+			seen[item] = true
+			sorted = append(sorted, item)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+//taken reports the username or email of u used by another user than except, callers hold r.mu
+func (r *UserMemory) taken(u User, except string) error {
+	for id, other := range r.users {
+		if id == except {
+			continue
+		}
+		if u.Username != "" && strings.EqualFold(other.Username, u.Username) {
+			return ErrDuplicateUsername
+		}
+		if strings.EqualFold(other.Email, u.Email) {
+			return ErrDuplicateEmail
+		}
+	}
+	return nil
+}
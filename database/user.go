@@ -0,0 +1,166 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//roles granted to users, helpers.Authorize maps them to permissions
+const (
+	RoleAdmin          = "admin"
+	RoleCatalogManager = "catalog-manager"
+	RoleCustomer       = "customer"
+	RoleViewer         = "viewer"
+)
+
+var (
+	ErrDuplicateUsername = errors.New("username is already used")
+	ErrDuplicateEmail    = errors.New("email is already used")
+)
+
+//User account, Password is the bcrypt hash
+type User struct {
+	IDUser          string     `gorm:"column:id_user;type:varchar(36)"`
+	TenantID        string     `gorm:"column:tenant_id;type:varchar(36)"`
+	Username        string     `gorm:"column:username;type:varchar(20)"`
+	Email           string     `gorm:"column:email;type:varchar(50)"`
+	Password        string     `gorm:"column:password;type:varchar(100)"`
+	FullName        string     `gorm:"column:full_name;type:varchar(50)"`
+	Phone           string     `gorm:"column:phone;type:varchar(45)"`
+	Active          bool       `gorm:"column:active;type:bool"`
+	CreatedDate     time.Time  `gorm:"column:created_datetime"`
+	UpdatedDate     time.Time  `gorm:"column:updated_datetime"`
+	DeactivatedDate *time.Time `gorm:"column:deactivated_datetime"`
+
+	//Roles written by Create, read through UserRepository.Roles
+	Roles []string `gorm:"-"`
+}
+
+//UserRepository user storage, missing users are reported as gorm.ErrRecordNotFound, usernames and emails
+//are matched ignoring case. Update writes the profile only, the password changes through SetPassword
+type UserRepository interface {
+	Create(u *User, newID func() (string, error)) error
+	GetByID(id string) (User, error)
+	GetByUsername(username string) (User, error)
+	Update(u *User) error
+	SetPassword(id, password string, updatedDate time.Time) error
+	Deactivate(id string, deactivatedDate time.Time) error
+	Roles(id string) ([]string, error)
+	SetRoles(id string, roles []string) error
+}
+
+//UserGorm postgres UserRepository
+type UserGorm struct {
+	DB *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return UserGorm{DB: db}
+}
+
+func (r UserGorm) Create(u *User, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	u.IDUser = id
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("users").Create(u).Error; err != nil {
+			return userError(err)
+		}
+		return insertRoles(tx, id, u.Roles)
+	})
+}
+
+func (r UserGorm) GetByID(id string) (User, error) {
+	u := User{}
+	err := r.DB.Table("users").Where("id_user=?", id).Take(&u).Error
+	return u, err
+}
+
+func (r UserGorm) GetByUsername(username string) (User, error) {
+	u := User{}
+	err := r.DB.Table("users").Where("lower(username)=lower(?)", username).Take(&u).Error
+	return u, err
+}
+
+func (r UserGorm) Update(u *User) error {
+	sql := "update users set email=?, full_name=?, phone=?, updated_datetime=? where id_user=?"
+	result := r.DB.Exec(sql, u.Email, u.FullName, u.Phone, u.UpdatedDate, u.IDUser)
+	if err := userError(result.Error); err != nil {
+		return err
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r UserGorm) SetPassword(id, password string, updatedDate time.Time) error {
+	sql := "update users set password=?, updated_datetime=? where id_user=?"
+	result := r.DB.Exec(sql, password, updatedDate, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+//Deactivate marks the user inactive, an already inactive user keeps its first deactivated_datetime
+func (r UserGorm) Deactivate(id string, deactivatedDate time.Time) error {
+	sql := "update users set active=false, updated_datetime=?, deactivated_datetime=coalesce(deactivated_datetime, ?) where id_user=?"
+	result := r.DB.Exec(sql, deactivatedDate, deactivatedDate, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+//userError unique violations of users as ErrDuplicateUsername or ErrDuplicateEmail
+func userError(err error) error {
+	if isUniqueViolation(err, "users_username_key") {
+		return ErrDuplicateUsername
+	}
+	if isUniqueViolation(err, "users_email_key") {
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+//Roles of the user sorted by name
+func (r UserGorm) Roles(id string) ([]string, error) {
+	roles := []string{}
+	err := r.DB.Table("user_role").Where("id_user=?", id).Order("role").Pluck("role", &roles).Error
+	return roles, err
+}
+
+//SetRoles replaces the roles of the user
+func (r UserGorm) SetRoles(id string, roles []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := (UserGorm{DB: tx}).GetByID(id); err != nil {
+			return err
+		}
+		if err := tx.Exec("delete from user_role where id_user=?", id).Error; err != nil {
+			return err
+		}
+		return insertRoles(tx, id, roles)
+	})
+}
+
+func insertRoles(tx *gorm.DB, id string, roles []string) error {
+	for _, role := range roles {
+		sql := "insert into user_role (id_user, role) values (?, ?) on conflict do nothing"
+		if err := tx.Exec(sql, id, role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
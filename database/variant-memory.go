@@ -0,0 +1,103 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+//VariantMemory in-memory VariantRepository safe for concurrent use
+type VariantMemory struct {
+	mu       sync.RWMutex
+	variants map[string]Variant
+}
+
+func NewVariantMemoryRepository() *VariantMemory {
+	return &VariantMemory{variants: map[string]Variant{}}
+}
+
+func (r *VariantMemory) Create(v *Variant, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.variants[id]; ok {
+		return fmt.Errorf("duplicate variant id %s", id)
+	}
+	if r.skuTaken(v.SKU, "") {
+		return ErrDuplicateSKU
+	}
+	v.IDVariant = id
+	r.variants[id] = *v
+	return nil
+}
+
+func (r *VariantMemory) GetByID(id string) (Variant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.variants[id]
+	if !ok {
+		return Variant{}, gorm.ErrRecordNotFound
+	}
+	return v, nil
+}
+
+func (r *VariantMemory) ListByProducts(productIDs []string) ([]Variant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, id := range productIDs {
+		wanted[id] = true
+	}
+	variants := []Variant{}
+	for _, v := range r.variants {
+		if wanted[v.IDProduct] {
+			variants = append(variants, v)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		if variants[i].IDProduct != variants[j].IDProduct {
+			return variants[i].IDProduct < variants[j].IDProduct
+		}
+		return variants[i].SKU < variants[j].SKU
+	})
+	return variants, nil
+}
+
+func (r *VariantMemory) Update(v *Variant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.variants[v.IDVariant]
+	if !ok {
+		return nil
+	}
+	if r.skuTaken(v.SKU, v.IDVariant) {
+		return ErrDuplicateSKU
+	}
+	stored.SKU = v.SKU
+	stored.Attributes = v.Attributes
+	stored.Price = v.Price
+	stored.Quantity = v.Quantity
+	stored.UpdatedDate = v.UpdatedDate
+	r.variants[v.IDVariant] = stored
+	return nil
+}
+
+//skuTaken reports whether another variant than except already uses sku, callers hold the lock
+func (r *VariantMemory) skuTaken(sku, except string) bool {
+	for id, v := range r.variants {
+		if v.SKU == sku && id != except {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+var ErrDuplicateSKU = errors.New("sku is already used by another variant")
+
+//VariantAttributes option values of a variant (size, color, ...) stored as jsonb
+type VariantAttributes map[string]string
+
+func (a VariantAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(a)
+	return string(raw), err
+}
+
+func (a *VariantAttributes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	case nil:
+		*a = VariantAttributes{}
+		return nil
+	}
+	return fmt.Errorf("can't scan %T into variant attributes", src)
+}
+
+//Variant sellable version of a product, a nil Price sells at the product price
+type Variant struct {
+	IDVariant   string            `gorm:"column:id_variant;type:varchar(36)"`
+	TenantID    string            `gorm:"column:tenant_id;type:varchar(36)"`
+	IDProduct   string            `gorm:"column:id_product;type:varchar(36)"`
+	SKU         string            `gorm:"column:sku;type:varchar(50)"`
+	Attributes  VariantAttributes `gorm:"column:attributes;type:jsonb"`
+	Price       *int              `gorm:"column:price;type:bigint"`
+	Quantity    int               `gorm:"column:quantity;type:int"`
+	CreatedDate time.Time         `gorm:"column:created_datetime"`
+	UpdatedDate time.Time         `gorm:"column:updated_datetime"`
+}
+
+//EffectivePrice price the variant sells at
+func (v Variant) EffectivePrice(product Product) int {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+//VariantSummary aggregate of the variants of one product
+type VariantSummary struct {
+	Count      int
+	MinPrice   int
+	MaxPrice   int
+	TotalStock int
+}
+
+//SummarizeVariants aggregates variants per product, products without variant are left out
+func SummarizeVariants(products []Product, variants []Variant) map[string]VariantSummary {
+	byID := map[string]Product{}
+	for _, p := range products {
+		byID[p.IDProduct] = p
+	}
+
+	summaries := map[string]VariantSummary{}
+	for _, v := range variants {
+		product, ok := byID[v.IDProduct]
+		if !ok {
+			continue
+		}
+		price := v.EffectivePrice(product)
+		s, ok := summaries[v.IDProduct]
+		if !ok {
+			s = VariantSummary{MinPrice: price, MaxPrice: price}
+		}
+		s.Count++
+		s.TotalStock += v.Quantity
+		if price < s.MinPrice {
+			s.MinPrice = price
+		}
+		if price > s.MaxPrice {
+			s.MaxPrice = price
+		}
+		summaries[v.IDProduct] = s
+	}
+	return summaries
+}
+
+//VariantRepository variant storage, missing variants are reported as gorm.ErrRecordNotFound
+type VariantRepository interface {
+	Create(v *Variant, newID func() (string, error)) error
+	GetByID(id string) (Variant, error)
+	ListByProducts(productIDs []string) ([]Variant, error)
+	Update(v *Variant) error
+}
+
+//VariantGorm postgres VariantRepository
+type VariantGorm struct {
+	DB *gorm.DB
+}
+
+func NewVariantRepository(db *gorm.DB) VariantRepository {
+	return VariantGorm{DB: db}
+}
+
+func (r VariantGorm) Create(v *Variant, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	v.IDVariant = id
+
+	err = r.DB.Table("product_variant").Create(v).Error
+	if isUniqueViolation(err, "product_variant_sku_key") {
+		return ErrDuplicateSKU
+	}
+	return err
+}
+
+func (r VariantGorm) GetByID(id string) (Variant, error) {
+	v := Variant{}
+	err := r.DB.Table("product_variant").Where("id_variant=?", id).Take(&v).Error
+	return v, err
+}
+
+func (r VariantGorm) ListByProducts(productIDs []string) ([]Variant, error) {
+	variants := []Variant{}
+	if len(productIDs) == 0 {
+		return variants, nil
+	}
+	err := r.DB.Table("product_variant").Where("id_product in ?", productIDs).Order("id_product, sku").Find(&variants).Error
+	return variants, err
+}
+
+func (r VariantGorm) Update(v *Variant) error {
+	values := map[string]interface{}{
+		"sku":              v.SKU,
+		"attributes":       v.Attributes,
+		"price":            v.Price,
+		"quantity":         v.Quantity,
+		"updated_datetime": v.UpdatedDate,
+	}
+	err := r.DB.Table("product_variant").Where("id_variant=?", v.IDVariant).Updates(values).Error
+	if isUniqueViolation(err, "product_variant_sku_key") {
+		return ErrDuplicateSKU
+	}
+	return err
+}
+
+//isUniqueViolation reports whether err is a postgres unique violation of constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
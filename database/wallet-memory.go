@@ -0,0 +1,144 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+//WalletMemory in-memory WalletRepository, payments mark the orders of an OrderMemory paid and
+//the OrderMemory refunds cancelled ones through it
+type WalletMemory struct {
+	mu           sync.Mutex
+	wallets      map[string]Wallet
+	transactions []WalletTransaction
+	orders       *OrderMemory
+}
+
+func NewWalletMemoryRepository(orders *OrderMemory) *WalletMemory {
+	r := &WalletMemory{wallets: map[string]Wallet{}, orders: orders}
+	orders.wallets = r
+	return r
+}
+
+func (r *WalletMemory) GetByID(idCustomer string) (Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[idCustomer]
+	if !ok {
+		return Wallet{}, gorm.ErrRecordNotFound
+	}
+	return w, nil
+}
+
+func (r *WalletMemory) Transactions(idCustomer string, page, size int) ([]WalletTransaction, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	transactions := []WalletTransaction{}
+	for _, t := range r.transactions {
+		if t.IDCustomer == idCustomer {
+			transactions = append(transactions, copyWalletTransaction(t))
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].CreatedDate.After(transactions[j].CreatedDate)
+	})
+
+	total := int64(len(transactions))
+	start := (page - 1) * size
+	if start > len(transactions) {
+		start = len(transactions)
+	}
+	end := start + size
+	if end > len(transactions) {
+		end = len(transactions)
+	}
+	return transactions[start:end], total, nil
+}
+
+func (r *WalletMemory) Apply(t *WalletTransaction, check func(balance, amount int) error, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.wallets[t.IDCustomer]
+	if !ok && t.Kind == WalletDeposit {
+		w = Wallet{IDCustomer: t.IDCustomer, Currency: t.Currency, CreatedDate: t.CreatedDate, UpdatedDate: t.CreatedDate}
+	} else if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	for _, stored := range r.transactions {
+		if stored.IDCustomer == t.IDCustomer && stored.IdempotencyKey == t.IdempotencyKey {
+			return replay(t, copyWalletTransaction(stored))
+		}
+	}
+
+	if t.Kind == WalletPayment {
+		o, err := r.orders.GetByID(*t.IDOrder)
+		if err != nil {
+			return err
+		}
+		if err := payable(o, w); err != nil {
+			return err
+		}
+		t.Amount = o.Total
+		if err := walletMove(w, t, check); err != nil {
+			return err
+		}
+		if _, err := r.orders.Move(o.IDOrder, OrderPaid, WalletAccount(w.IDCustomer), t.CreatedDate, newID); err != nil {
+			return err
+		}
+	} else if err := walletMove(w, t, check); err != nil {
+		return err
+	}
+
+	t.IDTransaction = id
+	t.Entries = walletEntries(*t)
+	w.Balance = t.BalanceAfter
+	w.UpdatedDate = t.CreatedDate
+	r.wallets[w.IDCustomer] = w
+	r.transactions = append(r.transactions, copyWalletTransaction(*t))
+	return nil
+}
+
+//refund pays the wallet payment of the cancelled order o back, the caller holds the lock
+func (r *WalletMemory) refund(o Order, newID func() (string, error)) error {
+	for _, payment := range r.transactions {
+		if payment.Kind != WalletPayment || payment.IDOrder == nil || *payment.IDOrder != o.IDOrder {
+			continue
+		}
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		w := r.wallets[payment.IDCustomer]
+		t := orderRefund(o, payment, w, id)
+		t.Entries = walletEntries(t)
+		w.Balance = t.BalanceAfter
+		w.UpdatedDate = t.CreatedDate
+		r.wallets[w.IDCustomer] = w
+		r.transactions = append(r.transactions, t)
+		return nil
+	}
+	return nil
+}
+
+func copyWalletTransaction(t WalletTransaction) WalletTransaction {
+	entries := make([]WalletEntry, len(t.Entries))
+	copy(entries, t.Entries)
+	t.Entries = entries
+	return t
+}
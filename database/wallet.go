@@ -0,0 +1,325 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//wallet transaction kinds
+const (
+	WalletDeposit    = "deposit"
+	WalletWithdrawal = "withdrawal"
+	WalletPayment    = "payment"
+	WalletRefund     = "refund"
+)
+
+//ledger accounts besides the wallet:<id_customer> account of every wallet
+const (
+	CashAccount  = "cash"
+	SalesAccount = "sales"
+)
+
+var (
+	ErrIdempotencyConflict = errors.New("idempotency key was already used by another request")
+	ErrWalletCurrency      = errors.New("currency differs from the wallet currency")
+	ErrNothingToPay        = errors.New("order total is 0, there is nothing to pay")
+	ErrInsufficientBalance = errors.New("balance is not enough")
+	ErrOrderCustomer       = errors.New("order was placed by another customer")
+)
+
+//Wallet balance of a customer in minor units of Currency
+type Wallet struct {
+	IDCustomer  string    `gorm:"column:id_customer;type:varchar(36)"`
+	TenantID    string    `gorm:"column:tenant_id;type:varchar(36)"`
+	Currency    string    `gorm:"column:currency;type:char(3)"`
+	Balance     int       `gorm:"column:balance;type:bigint"`
+	CreatedDate time.Time `gorm:"column:created_datetime"`
+	UpdatedDate time.Time `gorm:"column:updated_datetime"`
+}
+
+//WalletTransaction deposit, withdrawal, order payment or refund with its ledger entries, Replayed is set
+//when the transaction was stored by an earlier request with the same idempotency key
+type WalletTransaction struct {
+	IDTransaction  string    `gorm:"column:id_transaction;type:varchar(36)"`
+	IDCustomer     string    `gorm:"column:id_customer;type:varchar(36)"`
+	IdempotencyKey string    `gorm:"column:idempotency_key;type:varchar(100)"`
+	Kind           string    `gorm:"column:kind;type:varchar(20)"`
+	Amount         int       `gorm:"column:amount;type:bigint"`
+	Currency       string    `gorm:"column:currency;type:char(3)"`
+	BalanceAfter   int       `gorm:"column:balance_after;type:bigint"`
+	IDOrder        *string   `gorm:"column:id_order;type:varchar(36)"`
+	CreatedDate    time.Time `gorm:"column:created_datetime"`
+
+	Entries  []WalletEntry `gorm:"-"`
+	Replayed bool          `gorm:"-"`
+}
+
+//WalletEntry one side of a transaction in the double-entry ledger, the entries of a transaction sum to zero
+type WalletEntry struct {
+	IDEntry       int64     `gorm:"column:id_entry;primaryKey;autoIncrement"`
+	IDTransaction string    `gorm:"column:id_transaction;type:varchar(36)"`
+	Account       string    `gorm:"column:account;type:varchar(50)"`
+	Amount        int       `gorm:"column:amount;type:bigint"`
+	CreatedDate   time.Time `gorm:"column:created_datetime"`
+}
+
+//WalletAccount ledger account of the wallet of a customer
+func WalletAccount(idCustomer string) string {
+	return "wallet:" + idCustomer
+}
+
+//WalletRepository wallets and their ledger, missing wallets are reported as gorm.ErrRecordNotFound.
+//Apply stores a transaction: a deposit creates the wallet in t.Currency when missing, a payment marks
+//t.IDOrder paid and takes its total. check gets the balance and amount of a withdrawal or payment
+//before it is applied and can refuse it. A transaction with an idempotency key already used by the customer is not applied again,
+//t gets the stored one back with Replayed set, or ErrIdempotencyConflict when it was another request
+type WalletRepository interface {
+	GetByID(idCustomer string) (Wallet, error)
+	Transactions(idCustomer string, page, size int) ([]WalletTransaction, int64, error)
+	Apply(t *WalletTransaction, check func(balance, amount int) error, newID func() (string, error)) error
+}
+
+//WalletGorm postgres WalletRepository
+type WalletGorm struct {
+	DB *gorm.DB
+}
+
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return WalletGorm{DB: db}
+}
+
+func (r WalletGorm) GetByID(idCustomer string) (Wallet, error) {
+	w := Wallet{}
+	err := r.DB.Table("wallet").Where("id_customer=?", idCustomer).Take(&w).Error
+	return w, err
+}
+
+//Transactions newest first with their entries
+func (r WalletGorm) Transactions(idCustomer string, page, size int) ([]WalletTransaction, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	size = pageSize(size)
+
+	var total int64
+	wallets := r.DB.Table("wallet").Select("id_customer")
+	base := r.DB.Table("wallet_transaction").Where("id_customer=? and id_customer in (?)", idCustomer, wallets)
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	transactions := []WalletTransaction{}
+	err := base.Session(&gorm.Session{}).Order("created_datetime desc, id_transaction desc").Offset((page - 1) * size).Limit(size).Find(&transactions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	transactions, err = withEntries(r.DB, transactions)
+	return transactions, total, err
+}
+
+//Apply locks the wallet row so the transactions of a customer, retries included, run one after the other
+func (r WalletGorm) Apply(t *WalletTransaction, check func(balance, amount int) error, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if t.Kind == WalletDeposit {
+			w := Wallet{IDCustomer: t.IDCustomer, Currency: t.Currency, CreatedDate: t.CreatedDate, UpdatedDate: t.CreatedDate}
+			if err := tx.Table("wallet").Clauses(clause.OnConflict{DoNothing: true}).Create(&w).Error; err != nil {
+				return err
+			}
+		}
+		w := Wallet{}
+		err := tx.Table("wallet").Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_customer=?", t.IDCustomer).Take(&w).Error
+		if err != nil {
+			return err
+		}
+
+		stored := WalletTransaction{}
+		err = tx.Table("wallet_transaction").Where("id_customer=? and idempotency_key=?", t.IDCustomer, t.IdempotencyKey).Take(&stored).Error
+		if err == nil {
+			replayed, err := withEntries(tx, []WalletTransaction{stored})
+			if err != nil {
+				return err
+			}
+			return replay(t, replayed[0])
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if t.Kind == WalletPayment {
+			o, err := OrderGorm{DB: tx}.GetByID(*t.IDOrder)
+			if err != nil {
+				return err
+			}
+			if err := payable(o, w); err != nil {
+				return err
+			}
+			if _, err := (OrderGorm{DB: tx}).Move(o.IDOrder, OrderPaid, WalletAccount(w.IDCustomer), t.CreatedDate, newID); err != nil {
+				return err
+			}
+			t.Amount = o.Total
+		}
+		if err := walletMove(w, t, check); err != nil {
+			return err
+		}
+
+		t.IDTransaction = id
+		return storeWalletTransaction(tx, t)
+	})
+}
+
+//storeWalletTransaction writes the transaction with its entries and the balance after it to the locked wallet
+func storeWalletTransaction(tx *gorm.DB, t *WalletTransaction) error {
+	t.Entries = walletEntries(*t)
+	values := map[string]interface{}{"balance": t.BalanceAfter, "updated_datetime": t.CreatedDate}
+	if err := tx.Table("wallet").Where("id_customer=?", t.IDCustomer).Updates(values).Error; err != nil {
+		return err
+	}
+	if err := tx.Table("wallet_transaction").Create(t).Error; err != nil {
+		return err
+	}
+	return tx.Table("wallet_entry").Create(&t.Entries).Error
+}
+
+//refundOrder pays the wallet payment of the cancelled order o back to the wallet it came from.
+//Orders paid before wallet payments were the only way to paid have no payment to refund
+func refundOrder(tx *gorm.DB, o Order, newID func() (string, error)) error {
+	payment := WalletTransaction{}
+	err := tx.Table("wallet_transaction").Where("id_order=? and kind=?", o.IDOrder, WalletPayment).Take(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w := Wallet{}
+	err = tx.Table("wallet").Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_customer=?", payment.IDCustomer).Take(&w).Error
+	if err != nil {
+		return err
+	}
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	t := orderRefund(o, payment, w, id)
+	return storeWalletTransaction(tx, &t)
+}
+
+//orderRefund transaction giving payment of the cancelled order o back to wallet w
+func orderRefund(o Order, payment WalletTransaction, w Wallet, id string) WalletTransaction {
+	idOrder := o.IDOrder
+	return WalletTransaction{
+		IDTransaction:  id,
+		IDCustomer:     w.IDCustomer,
+		IdempotencyKey: "refund:" + idOrder,
+		Kind:           WalletRefund,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		BalanceAfter:   w.Balance + payment.Amount,
+		IDOrder:        &idOrder,
+		CreatedDate:    o.UpdatedDate,
+	}
+}
+
+//withEntries loads the ledger entries of the transactions
+func withEntries(db *gorm.DB, transactions []WalletTransaction) ([]WalletTransaction, error) {
+	if len(transactions) == 0 {
+		return transactions, nil
+	}
+	ids := []string{}
+	for _, t := range transactions {
+		ids = append(ids, t.IDTransaction)
+	}
+	entries := []WalletEntry{}
+	if err := db.Table("wallet_entry").Where("id_transaction in ?", ids).Order("id_entry").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	byID := map[string][]WalletEntry{}
+	for _, e := range entries {
+		byID[e.IDTransaction] = append(byID[e.IDTransaction], e)
+	}
+	for i := range transactions {
+		transactions[i].Entries = byID[transactions[i].IDTransaction]
+	}
+	return transactions, nil
+}
+
+//replay hands the stored transaction back when it is the same request
+func replay(t *WalletTransaction, stored WalletTransaction) error {
+	same := stored.Kind == t.Kind
+	if t.Kind == WalletPayment {
+		same = same && t.IDOrder != nil && stored.IDOrder != nil && *stored.IDOrder == *t.IDOrder
+	} else {
+		same = same && stored.Amount == t.Amount
+	}
+	if !same {
+		return ErrIdempotencyConflict
+	}
+	*t = stored
+	t.Replayed = true
+	return nil
+}
+
+//payable the order must be placed by the owner of the wallet, pending, not free and in the currency of the wallet
+func payable(o Order, w Wallet) error {
+	if o.IDCustomer != w.IDCustomer {
+		return ErrOrderCustomer
+	}
+	if !o.CanMove(OrderPaid) {
+		return fmt.Errorf("%w from %s to %s", ErrOrderTransition, o.Status, OrderPaid)
+	}
+	if o.Currency != w.Currency {
+		return ErrWalletCurrency
+	}
+	if o.Total <= 0 {
+		return ErrNothingToPay
+	}
+	return nil
+}
+
+//walletMove sets the currency and balance after of the transaction, check runs before money goes out
+func walletMove(w Wallet, t *WalletTransaction, check func(balance, amount int) error) error {
+	if t.Currency != "" && t.Currency != w.Currency {
+		return ErrWalletCurrency
+	}
+	t.Currency = w.Currency
+	if t.Kind == WalletDeposit {
+		t.BalanceAfter = w.Balance + t.Amount
+		return nil
+	}
+	if check != nil {
+		if err := check(w.Balance, t.Amount); err != nil {
+			return err
+		}
+	}
+	if w.Balance < t.Amount {
+		return ErrInsufficientBalance
+	}
+	t.BalanceAfter = w.Balance - t.Amount
+	return nil
+}
+
+//walletEntries the wallet side and the cash or sales side of the transaction
+func walletEntries(t WalletTransaction) []WalletEntry {
+	wallet, other, account := t.Amount, -t.Amount, CashAccount
+	switch t.Kind {
+	case WalletWithdrawal:
+		wallet, other = -t.Amount, t.Amount
+	case WalletPayment:
+		wallet, other, account = -t.Amount, t.Amount, SalesAccount
+	case WalletRefund:
+		account = SalesAccount
+	}
+	return []WalletEntry{
+		{IDTransaction: t.IDTransaction, Account: WalletAccount(t.IDCustomer), Amount: wallet, CreatedDate: t.CreatedDate},
+		{IDTransaction: t.IDTransaction, Account: account, Amount: other, CreatedDate: t.CreatedDate},
+	}
+}
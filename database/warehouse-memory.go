@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+//WarehouseMemory in-memory WarehouseRepository, starts with the default warehouse like the migrations do
+type WarehouseMemory struct {
+	mu         sync.RWMutex
+	warehouses map[string]Warehouse
+}
+
+func NewWarehouseMemoryRepository() *WarehouseMemory {
+	return &WarehouseMemory{warehouses: map[string]Warehouse{
+		DefaultWarehouseID: {IDWarehouse: DefaultWarehouseID, Code: "MAIN", Name: "Main warehouse"},
+	}}
+}
+
+func (r *WarehouseMemory) Create(w *Warehouse, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.warehouses[id]; ok {
+		return fmt.Errorf("duplicate warehouse id %s", id)
+	}
+	if r.codeTaken(w.Code, "") {
+		return ErrDuplicateWarehouseCode
+	}
+	w.IDWarehouse = id
+	r.warehouses[id] = *w
+	return nil
+}
+
+func (r *WarehouseMemory) GetByID(id string) (Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.warehouses[id]
+	if !ok {
+		return Warehouse{}, gorm.ErrRecordNotFound
+	}
+	return w, nil
+}
+
+func (r *WarehouseMemory) List() ([]Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	warehouses := []Warehouse{}
+	for _, w := range r.warehouses {
+		warehouses = append(warehouses, w)
+	}
+	sort.Slice(warehouses, func(i, j int) bool { return warehouses[i].Code < warehouses[j].Code })
+	return warehouses, nil
+}
+
+func (r *WarehouseMemory) Update(w *Warehouse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.warehouses[w.IDWarehouse]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if r.codeTaken(w.Code, w.IDWarehouse) {
+		return ErrDuplicateWarehouseCode
+	}
+	stored.Code = w.Code
+	stored.Name = w.Name
+	stored.UpdatedDate = w.UpdatedDate
+	r.warehouses[w.IDWarehouse] = stored
+	return nil
+}
+
+//codeTaken reports whether another warehouse than except uses code, callers hold r.mu
+func (r *WarehouseMemory) codeTaken(code, except string) bool {
+	for id, w := range r.warehouses {
+		if w.Code == code && id != except {
+			return true
+		}
+	}
+	return false
+}
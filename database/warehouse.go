@@ -0,0 +1,116 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//DefaultWarehouseID warehouse created by the migrations, the MAIN warehouse of the default tenant
+const DefaultWarehouseID = "main"
+
+//DefaultWarehouseCode code of the warehouse receiving the stock of new products, every tenant has its own
+const DefaultWarehouseCode = "MAIN"
+
+var ErrDuplicateWarehouseCode = errors.New("warehouse code is already used")
+
+//Warehouse stock location
+type Warehouse struct {
+	IDWarehouse string    `gorm:"column:id_warehouse;type:varchar(36)"`
+	TenantID    string    `gorm:"column:tenant_id;type:varchar(36)"`
+	Code        string    `gorm:"column:code;type:varchar(20)"`
+	Name        string    `gorm:"column:name;type:varchar(50)"`
+	CreatedDate time.Time `gorm:"column:created_datetime"`
+	UpdatedDate time.Time `gorm:"column:updated_datetime"`
+}
+
+//WarehouseStock quantity of a product held at one warehouse
+type WarehouseStock struct {
+	IDWarehouse string `gorm:"column:id_warehouse;type:varchar(36)"`
+	IDProduct   string `gorm:"column:id_product;type:varchar(36)"`
+	Quantity    int    `gorm:"column:quantity;type:int"`
+}
+
+//WarehouseRepository warehouse storage, missing warehouses are reported as gorm.ErrRecordNotFound
+type WarehouseRepository interface {
+	Create(w *Warehouse, newID func() (string, error)) error
+	GetByID(id string) (Warehouse, error)
+	List() ([]Warehouse, error)
+	Update(w *Warehouse) error
+}
+
+//WarehouseGorm postgres WarehouseRepository
+type WarehouseGorm struct {
+	DB *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return WarehouseGorm{DB: db}
+}
+
+func (r WarehouseGorm) Create(w *Warehouse, newID func() (string, error)) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	w.IDWarehouse = id
+
+	err = r.DB.Table("warehouse").Create(w).Error
+	if isUniqueViolation(err, "warehouse_code_key") {
+		return ErrDuplicateWarehouseCode
+	}
+	return err
+}
+
+func (r WarehouseGorm) GetByID(id string) (Warehouse, error) {
+	w := Warehouse{}
+	err := r.DB.Table("warehouse").Where("id_warehouse=?", id).Take(&w).Error
+	return w, err
+}
+
+func (r WarehouseGorm) List() ([]Warehouse, error) {
+	warehouses := []Warehouse{}
+	err := r.DB.Table("warehouse").Order("code").Find(&warehouses).Error
+	return warehouses, err
+}
+
+//defaultWarehouse id of the MAIN warehouse of the tenant of tx, created on first use.
+//A db without tenant stocks into the warehouse created by the migrations
+func defaultWarehouse(tx *gorm.DB, newID func() (string, error), now time.Time) (string, error) {
+	if _, ok := tx.Get(tenantSetting); !ok {
+		return DefaultWarehouseID, nil
+	}
+	w := Warehouse{}
+	err := tx.Table("warehouse").Where("code=?", DefaultWarehouseCode).Take(&w).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return w.IDWarehouse, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+	w = Warehouse{IDWarehouse: id, Code: DefaultWarehouseCode, Name: "Main warehouse", CreatedDate: now, UpdatedDate: now}
+	if err := tx.Table("warehouse").Clauses(clause.OnConflict{DoNothing: true}).Create(&w).Error; err != nil {
+		return "", err
+	}
+	err = tx.Table("warehouse").Where("code=?", DefaultWarehouseCode).Take(&w).Error
+	return w.IDWarehouse, err
+}
+
+func (r WarehouseGorm) Update(w *Warehouse) error {
+	values := map[string]interface{}{"code": w.Code, "name": w.Name, "updated_datetime": w.UpdatedDate}
+	result := r.DB.Table("warehouse").Where("id_warehouse=?", w.IDWarehouse).Updates(values)
+	if isUniqueViolation(result.Error, "warehouse_code_key") {
+		return ErrDuplicateWarehouseCode
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package functions
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	IDGeneratorULID      = "ulid"
+	IDGeneratorUUIDv7    = "uuidv7"
+	IDGeneratorSnowflake = "snowflake"
+)
+
+//crockford base32 alphabet used by ulid
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+//snowflakeEpoch custom epoch of snowflake ids (2021-01-01 UTC)
+var snowflakeEpoch = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//IDGenerator generates unique, time ordered record ids
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+//NewIDGenerator returns the generator of kind (ulid, uuidv7 or snowflake), rr is the random source
+//and node the snowflake worker id (0-1023) which must be unique per running replica
+func NewIDGenerator(kind string, rr io.Reader, node int) (IDGenerator, error) {
+	switch kind {
+	case "", IDGeneratorULID:
+		return &ulidGenerator{rr: rr, now: time.Now}, nil
+	case IDGeneratorUUIDv7:
+		return &uuidv7Generator{rr: rr, now: time.Now}, nil
+	case IDGeneratorSnowflake:
+		if node < 0 || node > 1023 {
+			return nil, fmt.Errorf("snowflake node must be between 0-1023, got %d", node)
+		}
+		return &snowflakeGenerator{node: int64(node), now: time.Now}, nil
+	}
+	return nil, fmt.Errorf("unknown id generator %s", kind)
+}
+
+type ulidGenerator struct {
+	mu  sync.Mutex
+	rr  io.Reader
+	now func() time.Time
+}
+
+//NewID 48 bit millisecond timestamp followed by 80 random bits, crockford base32 encoded (26 characters)
+func (g *ulidGenerator) NewID() (string, error) {
+	var raw [16]byte
+	binary.BigEndian.PutUint64(raw[:8], uint64(g.now().UnixNano()/int64(time.Millisecond))<<16)
+
+	g.mu.Lock()
+	_, err := io.ReadFull(g.rr, raw[6:])
+	g.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("ulid random : %w", err)
+	}
+
+	//128 bits padded to 130 so every character holds 5 bits
+	out := make([]byte, 26)
+	hi := binary.BigEndian.Uint64(raw[:8])
+	lo := binary.BigEndian.Uint64(raw[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out), nil
+}
+
+type uuidv7Generator struct {
+	mu  sync.Mutex
+	rr  io.Reader
+	now func() time.Time
+}
+
+//NewID rfc 9562 version 7 uuid
+func (g *uuidv7Generator) NewID() (string, error) {
+	var raw [16]byte
+	binary.BigEndian.PutUint64(raw[:8], uint64(g.now().UnixNano()/int64(time.Millisecond))<<16)
+
+	g.mu.Lock()
+	_, err := io.ReadFull(g.rr, raw[6:])
+	g.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("uuidv7 random : %w", err)
+	}
+	raw[6] = raw[6]&0x0f | 0x70
+	raw[8] = raw[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(raw[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+type snowflakeGenerator struct {
+	mu       sync.Mutex
+	node     int64
+	lastMs   int64
+	sequence int64
+	now      func() time.Time
+}
+
+//NewID 41 bit millisecond since snowflakeEpoch, 10 bit node and 12 bit sequence as decimal string
+func (g *snowflakeGenerator) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.now().Sub(snowflakeEpoch).Milliseconds()
+	if ms < g.lastMs {
+		//clock moved backwards, keep counting on the last timestamp
+		ms = g.lastMs
+	}
+	if ms == g.lastMs {
+		g.sequence = (g.sequence + 1) & 0xfff
+		if g.sequence == 0 {
+			//sequence exhausted, borrow the next millisecond
+			ms++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+
+	return strconv.FormatInt(ms<<22|g.node<<12|g.sequence, 10), nil
+}
@@ -0,0 +1,134 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+//maxImagePixels refuses images whose decoded size would eat the memory (decompression bombs)
+const maxImagePixels = 40000000
+
+//ImageContentTypes content types accepted for upload with the file extension they are stored with
+var ImageContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+//ThumbnailSizes bounding box (pixel) of every generated thumbnail
+var ThumbnailSizes = map[string]int{
+	"small":  150,
+	"medium": 400,
+	"large":  800,
+}
+
+//DetectImage sniffs the content type from the data itself and checks the image can be decoded safely
+func DetectImage(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := ImageContentTypes[contentType]; !ok {
+		return "", fmt.Errorf("unsupported image type %s, use jpeg, png or gif", contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("invalid image : %s", err)
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return "", fmt.Errorf("image is too large (%dx%d)", cfg.Width, cfg.Height)
+	}
+	return contentType, nil
+}
+
+//Thumbnails scales the image down into every ThumbnailSizes box keeping its aspect ratio,
+//png stays png to keep transparency, everything else becomes jpeg
+func Thumbnails(data []byte, contentType string) (map[string][]byte, string, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image : %w", err)
+	}
+
+	thumbType := "image/jpeg"
+	if contentType == "image/png" {
+		thumbType = "image/png"
+	}
+
+	thumbs := map[string][]byte{}
+	for name, box := range ThumbnailSizes {
+		img := scaleDown(src, box)
+		buf := bytes.Buffer{}
+		if thumbType == "image/png" {
+			err = png.Encode(&buf, img)
+		} else {
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("encode %s thumbnail : %w", name, err)
+		}
+		thumbs[name] = buf.Bytes()
+	}
+	return thumbs, thumbType, nil
+}
+
+//scaleDown area averaging resize so the image fits in a box x box square, never upscales
+func scaleDown(src image.Image, box int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > box || sh > box {
+		if sw >= sh {
+			dw, dh = box, sh*box/sw
+		} else {
+			dw, dh = sw*box/sh, box
+		}
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	if dw == sw && dh == sh {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, (y+1)*sh/dh
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, (x+1)*sw/dw
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(rgba.Pix[i])
+					g += int(rgba.Pix[i+1])
+					bl += int(rgba.Pix[i+2])
+					a += int(rgba.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(bl / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,163 @@
+package functions
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+//JWTClaims claims of the tokens issued by this service, Use is access or refresh
+type JWTClaims struct {
+	ID        string   `json:"jti"`
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub"`
+	Username  string   `json:"username,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Tenant    string   `json:"tenant,omitempty"`
+	Use       string   `json:"token_use"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+//JWTKeys RS256 keys, tokens are signed with the private key of Kid and verified with the public key named
+//by their kid header, so tokens signed before a rotation stay valid as long as the old public key is kept
+type JWTKeys struct {
+	Kid     string
+	private *rsa.PrivateKey
+	public  map[string]*rsa.PublicKey
+}
+
+//LoadJWTKeys reads the <kid>.pem private keys (PKCS1) and <kid>.pub.pem public keys (PKIX) of dir,
+//kid signs new tokens and needs its private key, a private key without .pub.pem verifies with its own public part
+func LoadJWTKeys(dir, kid string) (JWTKeys, error) {
+	keys := JWTKeys{Kid: kid, public: map[string]*rsa.PublicKey{}}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return JWTKeys{}, fmt.Errorf("jwt keys : %w", err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ".pem") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return JWTKeys{}, fmt.Errorf("jwt keys : %w", err)
+		}
+
+		if strings.HasSuffix(name, ".pub.pem") {
+			key, err := GetPubKeyFromPem(string(data))
+			if err != nil {
+				return JWTKeys{}, fmt.Errorf("jwt key %s : %w", name, err)
+			}
+			keys.public[strings.TrimSuffix(name, ".pub.pem")] = key
+			continue
+		}
+		if strings.TrimSuffix(name, ".pem") != kid {
+			continue
+		}
+		keys.private, err = GetPrivateKeyFromPem(string(data))
+		if err != nil {
+			return JWTKeys{}, fmt.Errorf("jwt key %s : %w", name, err)
+		}
+	}
+
+	if keys.private == nil {
+		return JWTKeys{}, fmt.Errorf("jwt keys : no private key %s.pem in %s", kid, dir)
+	}
+	if _, ok := keys.public[kid]; !ok {
+		keys.public[kid] = &keys.private.PublicKey
+	}
+	return keys, nil
+}
+
+//Sign RS256 token of claims with the kid header of the signing key
+func (k JWTKeys) Sign(claims JWTClaims) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "RS256", Typ: "JWT", Kid: k.Kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, k.private, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign token : %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+//Parse claims of a token signed by one of the known keys, errors wrap ErrInvalidToken or ErrTokenExpired
+func (k JWTKeys) Parse(token string, now time.Time) (JWTClaims, error) {
+	handleErr := func(reason string) (JWTClaims, error) {
+		return JWTClaims{}, fmt.Errorf("%w : %s", ErrInvalidToken, reason)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return handleErr("malformed")
+	}
+
+	header := jwtHeader{}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(raw, &header) != nil {
+		return handleErr("malformed header")
+	}
+	//only RS256 is accepted so a token can't pick a weaker algorithm
+	if header.Alg != "RS256" {
+		return handleErr("unexpected algorithm " + header.Alg)
+	}
+	key, ok := k.public[header.Kid]
+	if !ok {
+		return handleErr("unknown kid " + header.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return handleErr("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return handleErr("bad signature")
+	}
+
+	claims := JWTClaims{}
+	raw, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(raw, &claims) != nil {
+		return handleErr("malformed claims")
+	}
+	if claims.ExpiresAt == 0 || now.Unix() >= claims.ExpiresAt {
+		return JWTClaims{}, ErrTokenExpired
+	}
+	return claims, nil
+}
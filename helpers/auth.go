@@ -0,0 +1,351 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"errors"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	fx "product-test/functions"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//permissions checked by Authorize
+const (
+	PermCatalogRead   = "catalog:read"
+	PermCatalogWrite  = "catalog:write"
+	PermStockWrite    = "stock:write"
+	PermUsersManage   = "users:manage"
+	PermAPIKeysManage = "api-keys:manage"
+	PermOrdersPlace   = "orders:place"
+	PermOrdersManage  = "orders:manage"
+)
+
+//rolePermissions permissions granted by every role
+var rolePermissions = map[string][]string{
+	tables.RoleAdmin: {PermCatalogRead, PermCatalogWrite, PermStockWrite, PermUsersManage, PermAPIKeysManage,
+		PermOrdersPlace, PermOrdersManage},
+	tables.RoleCatalogManager: {PermCatalogRead, PermCatalogWrite, PermStockWrite},
+	tables.RoleCustomer:       {PermCatalogRead, PermOrdersPlace},
+	tables.RoleViewer:         {PermCatalogRead},
+}
+
+const (
+	PrincipalUser   = "user"
+	PrincipalClient = "client"
+)
+
+//PrincipalKey gin context key of the Principal of the authenticated request
+const PrincipalKey = "auth.principal"
+
+//Principal caller of an authenticated request, a logged in user or an api client
+type Principal struct {
+	Kind        string
+	ID          string
+	Name        string
+	Tenant      string
+	Permissions []string
+}
+
+//Can reports whether the principal holds permission
+func (p Principal) Can(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+//Actor name of the principal in the stock ledger and order history, kind and id like user:<id_user>
+func (p Principal) Actor() string {
+	return p.Kind + ":" + p.ID
+}
+
+//CurrentActor actor of the authenticated request, empty when the route has no authentication
+func CurrentActor(c *gin.Context) string {
+	principal, ok := CurrentPrincipal(c)
+	if !ok {
+		return ""
+	}
+	return principal.Actor()
+}
+
+//apiKeyScopes permissions an api key can be given, managing users and keys stays with users
+var apiKeyScopes = []string{PermCatalogRead, PermCatalogWrite, PermStockWrite}
+
+//APIKeyPrefix start of every api key, the key is pk_<id_key>_<secret>
+const APIKeyPrefix = "pk_"
+
+//IsAPIKeyScope reports whether scope can be given to an api key
+func IsAPIKeyScope(scope string) bool {
+	for _, allowed := range apiKeyScopes {
+		if allowed == scope {
+			return true
+		}
+	}
+	return false
+}
+
+//SplitAPIKey id and secret of an api key
+func SplitAPIKey(key string) (string, string, bool) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(key, APIKeyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+//IsRole reports whether role is one of the known roles
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+//RolePermissions permissions granted by roles without duplicates, unknown roles grant nothing
+func RolePermissions(roles []string) []string {
+	seen := map[string]bool{}
+	permissions := []string{}
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions
+}
+
+//Authenticate requires an api key in the X-API-Key header or else an access token signed by one of the configured
+//keys in the Authorization: Bearer header, the Principal of the key or user is kept in the gin context under PrincipalKey
+func Authenticate(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|helpers|authenticate|"
+
+		if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+			principal, ok := authenticateKey(ctx, c, process, key)
+			if !ok {
+				return
+			}
+			c.Set(PrincipalKey, principal)
+			c.Next()
+			return
+		}
+
+		token := strings.TrimSpace(c.GetHeader("Authorization"))
+		if len(token) < 7 || !strings.EqualFold(token[:7], "bearer ") {
+			UnauthorizedResponse(RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: DEBUG,
+				Section:  process + "header",
+				Reason:   "missing bearer token",
+				Input:    c.Request.URL.Path,
+			})
+			return
+		}
+
+		claims, err := ctx.JWT.Parse(strings.TrimSpace(token[7:]), time.Now())
+		if err == nil && (claims.Use != fx.TokenAccess || claims.Issuer != ctx.Config.Auth.Issuer) {
+			err = errors.New("not an access token of this service")
+		}
+		if err != nil {
+			reason := "invalid token"
+			if errors.Is(err, fx.ErrTokenExpired) {
+				reason = "token expired"
+			}
+			UnauthorizedResponse(RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: DEBUG,
+				Section:  process + "parse",
+				Error:    err,
+				Reason:   reason,
+				Input:    c.Request.URL.Path,
+			})
+			return
+		}
+
+		//tokens from before tenants carry none, their users were moved to the default tenant
+		tenant := claims.Tenant
+		if tenant == "" {
+			tenant = tables.DefaultTenantID
+		}
+		c.Set(PrincipalKey, Principal{
+			Kind:        PrincipalUser,
+			ID:          claims.Subject,
+			Name:        claims.Username,
+			Tenant:      tenant,
+			Permissions: RolePermissions(claims.Roles),
+		})
+		c.Next()
+	}
+}
+
+//Authorize lets the request through when its principal holds permission, declared per route after Authenticate
+func Authorize(ctx cfg.RepositoryContext, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := authorized(ctx, c, "|helpers|authorize|")
+		if !ok {
+			return
+		}
+		if !principal.Can(permission) {
+			forbidden(ctx, c, "|helpers|authorize|", principal, permission)
+			return
+		}
+		c.Next()
+	}
+}
+
+//AuthorizeSelf lets a user through on the routes of its own account (the path param named param),
+//everybody else needs permission
+func AuthorizeSelf(ctx cfg.RepositoryContext, param, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := authorized(ctx, c, "|helpers|authorize-self|")
+		if !ok {
+			return
+		}
+		self := principal.Kind == PrincipalUser && principal.ID == c.Param(param)
+		if !self && !principal.Can(permission) {
+			forbidden(ctx, c, "|helpers|authorize-self|", principal, permission)
+			return
+		}
+		c.Next()
+	}
+}
+
+//AuthorizeCustomer lets a user holding permission through on the routes of its own customer account
+//(the path param named param, the id of a customer is the id of its user), everybody else needs manage
+func AuthorizeCustomer(ctx cfg.RepositoryContext, param, permission, manage string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := authorized(ctx, c, "|helpers|authorize-customer|")
+		if !ok {
+			return
+		}
+		if principal.Can(manage) {
+			c.Next()
+			return
+		}
+		if principal.Kind != PrincipalUser || principal.ID != c.Param(param) {
+			forbidden(ctx, c, "|helpers|authorize-customer|", principal, manage)
+			return
+		}
+		if !principal.Can(permission) {
+			forbidden(ctx, c, "|helpers|authorize-customer|", principal, permission)
+			return
+		}
+		c.Next()
+	}
+}
+
+//CurrentPrincipal principal of the request, false when the route has no authentication
+func CurrentPrincipal(c *gin.Context) (Principal, bool) {
+	val, ok := c.Get(PrincipalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	principal, ok := val.(Principal)
+	return principal, ok
+}
+
+//authorized principal of the request, writes the 401 itself when there is none
+func authorized(ctx cfg.RepositoryContext, c *gin.Context, process string) (Principal, bool) {
+	principal, ok := CurrentPrincipal(c)
+	if !ok {
+		UnauthorizedResponse(RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: DEBUG,
+			Section:  process + "principal",
+			Reason:   "authentication required",
+			Input:    c.Request.URL.Path,
+		})
+	}
+	return principal, ok
+}
+
+func forbidden(ctx cfg.RepositoryContext, c *gin.Context, process string, principal Principal, permission string) {
+	ForbiddenResponse(RespParams{
+		Log:      ctx.Log,
+		Context:  c,
+		Severity: DEBUG,
+		Section:  process + "permission",
+		Reason:   "permission " + permission + " is required",
+		Input:    principal.Kind + " " + principal.ID + " " + c.Request.Method + " " + c.Request.URL.Path,
+	})
+}
+
+//apiKeyTouchInterval last use of an api key is written at most this often
+const apiKeyTouchInterval = time.Minute
+
+//authenticateKey principal of an active api key, writes the 401 itself when the key isn't
+func authenticateKey(ctx cfg.RepositoryContext, c *gin.Context, process, key string) (Principal, bool) {
+	now := time.Now()
+	reason := "invalid api key"
+
+	id, secret, ok := SplitAPIKey(key)
+	var stored tables.APIKey
+	var err error
+	if ok {
+		stored, err = ctx.APIKeys.GetByID(id)
+		ok = err == nil && hmac.Equal([]byte(stored.KeyHash), []byte(HashAPIKey(ctx.Config.Auth.APIKeySecret, secret)))
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		BadResponse(RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: ERROR,
+			Section:  process + "api-key",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		c.Abort()
+		return Principal{}, false
+	}
+	if ok && stored.RevokedAt != nil {
+		ok, reason = false, "api key revoked"
+	}
+	if ok && stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt) {
+		ok, reason = false, "api key expired"
+	}
+	if !ok {
+		UnauthorizedResponse(RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: DEBUG,
+			Section:  process + "api-key",
+			Reason:   reason,
+			Input:    id,
+		})
+		return Principal{}, false
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= apiKeyTouchInterval {
+		if err := ctx.APIKeys.Touch(stored.IDKey, now); err != nil {
+			BadLogging(RespParams{
+				Log:      ctx.Log,
+				Severity: WARN,
+				Section:  process + "touch",
+				Error:    err,
+				Input:    stored.IDKey,
+			})
+		}
+	}
+
+	return Principal{
+		Kind:        PrincipalClient,
+		ID:          stored.IDKey,
+		Name:        stored.Name,
+		Tenant:      stored.TenantID,
+		Permissions: stored.Scopes,
+	}, true
+}
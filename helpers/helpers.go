@@ -1,16 +1,27 @@
 package helpers
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/smtp"
 	"os"
+	"strconv"
+	"strings"
 
 	"time"
 
 	cfg "product-test/config"
+	tables "product-test/database"
 	fx "product-test/functions"
 	adt "product-test/repo-adaptor"
+	"product-test/storage"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -23,6 +34,12 @@ const (
 	DEBUG
 )
 
+//ErrorCode values of HTTPResponse
+const (
+	ErrCodeUnauthorized = "unauthorized"
+	ErrCodeForbidden    = "forbidden"
+)
+
 type HTTPResponse struct {
 	Status      bool   `json:"status"`
 	ErrorCode   string `json:"error_code"`
@@ -35,6 +52,19 @@ func HashPassword(password string) (string, error) {
 	return string(bytes), err
 }
 
+//CheckPasswordHash reports whether password matches the bcrypt hash made by HashPassword
+func CheckPasswordHash(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+//HashAPIKey hex hmac-sha256 of an api key secret, bcrypt would be too slow to run on every request
+//and the secrets are random enough not to need it
+func HashAPIKey(key, secret string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 func NewRepositoryContext(rr io.Reader, rt http.RoundTripper) (cfg.RepositoryContext, error) {
 	//error handle
 	handleErr := func(err error) (cfg.RepositoryContext, error) {
@@ -78,6 +108,36 @@ func NewRepositoryContext(rr io.Reader, rt http.RoundTripper) (cfg.RepositoryCon
 		},
 	}
 
+	//init id generator
+	idgen, err := fx.NewIDGenerator(config.ID.Generator, rr, config.ID.Node)
+	if err != nil {
+		return handleErr(err)
+	}
+
+	//init file storage
+	store, err := storage.New(storage.Config{
+		Backend:   config.Storage.Backend,
+		LocalPath: config.Storage.LocalPath,
+		PublicURL: config.Storage.PublicURL,
+		S3: storage.S3Config{
+			Endpoint:  config.Storage.S3Endpoint,
+			Region:    config.Storage.S3Region,
+			Bucket:    config.Storage.S3Bucket,
+			AccessKey: config.Storage.S3AccessKey,
+			SecretKey: config.Storage.S3SecretKey,
+			PathStyle: config.Storage.S3PathStyle,
+		},
+	}, httpclient.Client)
+	if err != nil {
+		return handleErr(err)
+	}
+
+	//init token keys
+	jwtKeys, err := fx.LoadJWTKeys(config.Auth.KeysPath, config.Auth.Kid)
+	if err != nil {
+		return handleErr(err)
+	}
+
 	//init db
 	dbCfg := fx.DBParam{
 		Host:     config.DB.Host,
@@ -97,12 +157,37 @@ func NewRepositoryContext(rr io.Reader, rt http.RoundTripper) (cfg.RepositoryCon
 		return handleErr(err)
 	}
 
+	//narrow product statements to the tenant of the request
+	if err := tables.RegisterTenantScope(db); err != nil {
+		return handleErr(err)
+	}
+
 	//return service context
 	return cfg.RepositoryContext{
-		Config:  config,
-		Log:     l,
-		Adaptor: adaptor,
-		DB:      db,
+		Config:     config,
+		Log:        l,
+		Adaptor:    adaptor,
+		DB:         db,
+		Products:   tables.NewProductRepository(db),
+		Categories: tables.NewCategoryRepository(db),
+		Variants:   tables.NewVariantRepository(db),
+		Stock:      tables.NewStockRepository(db),
+		LowStock:   tables.NewLowStockRepository(db),
+		Warehouses: tables.NewWarehouseRepository(db),
+		Reserve:    tables.NewReservationRepository(db),
+		Prices:     tables.NewPriceRepository(db),
+		Currency:   tables.NewCurrencyRepository(db),
+		Promotions: tables.NewPromotionRepository(db),
+		Carts:      tables.NewCartRepository(db),
+		Orders:     tables.NewOrderRepository(db),
+		Wallets:    tables.NewWalletRepository(db),
+		Users:      tables.NewUserRepository(db),
+		Tokens:     tables.NewRefreshTokenRepository(db),
+		APIKeys:    tables.NewAPIKeyRepository(db),
+		Tenants:    GormTenantRepositories,
+		JWT:        jwtKeys,
+		Storage:    store,
+		IDGen:      idgen,
 	}, nil
 }
 
@@ -126,6 +211,51 @@ func BadResponseExist(c *gin.Context, reason string) {
 }
 
 func BadResponse(rp RespParams) {
+	responseLogging(rp)
+	response := HTTPResponse{
+		Status:      false,
+		Description: rp.Reason,
+	}
+
+	rp.Context.JSON(http.StatusBadRequest, response)
+}
+
+func NotFoundResponse(rp RespParams) {
+	responseLogging(rp)
+	response := HTTPResponse{
+		Status:      false,
+		Description: rp.Reason,
+	}
+
+	rp.Context.JSON(http.StatusNotFound, response)
+}
+
+//UnauthorizedResponse 401 with ErrCodeUnauthorized, the client has to log in (again)
+func UnauthorizedResponse(rp RespParams) {
+	responseLogging(rp)
+	response := HTTPResponse{
+		Status:      false,
+		ErrorCode:   ErrCodeUnauthorized,
+		Description: rp.Reason,
+	}
+
+	rp.Context.Header("WWW-Authenticate", "Bearer")
+	rp.Context.AbortWithStatusJSON(http.StatusUnauthorized, response)
+}
+
+//ForbiddenResponse 403 with ErrCodeForbidden, the caller is known but lacks the permission
+func ForbiddenResponse(rp RespParams) {
+	responseLogging(rp)
+	response := HTTPResponse{
+		Status:      false,
+		ErrorCode:   ErrCodeForbidden,
+		Description: rp.Reason,
+	}
+
+	rp.Context.AbortWithStatusJSON(http.StatusForbidden, response)
+}
+
+func responseLogging(rp RespParams) {
 	switch rp.Severity {
 	case DEBUG:
 		rp.Log.Debug(rp.Section,
@@ -146,12 +276,6 @@ func BadResponse(rp RespParams) {
 			zap.String("description", rp.Reason),
 			zap.Error(rp.Error))
 	}
-	response := HTTPResponse{
-		Status:      false,
-		Description: rp.Reason,
-	}
-
-	rp.Context.JSON(http.StatusBadRequest, response)
 }
 
 func RepoBadResponse(rp RespParams) {
@@ -207,3 +331,26 @@ func NewMailer(host, username, password string, port int) Mailer {
 		Port:     port,
 	}
 }
+
+//Send sends a plain text mail, the smtp server is only authenticated against when Username is set
+func (m Mailer) Send(from string, to []string, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	msg := strings.Builder{}
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	if err := smtp.SendMail(addr, auth, from, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("send mail : %w", err)
+	}
+	return nil
+}
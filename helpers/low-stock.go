@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+)
+
+//SendLowStockAlerts mails one alert listing the products that fell below their reorder threshold since
+//the last run, alerted products are skipped until their stock recovers. Returns the number of products alerted
+func SendLowStockAlerts(ctx cfg.RepositoryContext, mailer Mailer, now time.Time) (int, error) {
+	handleErr := func(err error) (int, error) {
+		return 0, fmt.Errorf("low stock alert : %w", err)
+	}
+
+	if _, err := ctx.LowStock.Recover(); err != nil {
+		return handleErr(err)
+	}
+	products, err := ctx.LowStock.Pending()
+	if err != nil {
+		return handleErr(err)
+	}
+	if len(products) == 0 {
+		return 0, nil
+	}
+
+	body := strings.Builder{}
+	body.WriteString("The following products are below their reorder threshold:\n\n")
+	alerts := []tables.LowStockAlert{}
+	for _, p := range products {
+		fmt.Fprintf(&body, "- %s (%s) : quantity %d, threshold %d\n", p.ProductName, p.IDProduct, p.Quantity, p.ReorderThreshold)
+		alerts = append(alerts, tables.LowStockAlert{
+			IDProduct: p.IDProduct,
+			Quantity:  p.Quantity,
+			Threshold: p.ReorderThreshold,
+			AlertedAt: now,
+		})
+	}
+
+	subject := fmt.Sprintf("[%s] %d product(s) low on stock", ctx.Config.App.Name, len(products))
+	if err := mailer.Send(ctx.Config.Mail.From, ctx.Config.Mail.AlertTo, subject, body.String()); err != nil {
+		return handleErr(err)
+	}
+	if err := ctx.LowStock.MarkAlerted(alerts); err != nil {
+		return handleErr(err)
+	}
+	return len(alerts), nil
+}
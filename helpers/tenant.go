@@ -0,0 +1,171 @@
+package helpers
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+//TenantKey gin context key of the tenant id resolved by ResolveTenant
+const TenantKey = "tenant.id"
+
+//tenantPattern lower case letters, digits and -, fits the varchar(36) tenant_id columns
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,35}$`)
+
+//IsTenantID reports whether id can name a tenant
+func IsTenantID(id string) bool {
+	return tenantPattern.MatchString(id)
+}
+
+//ResolveTenant keeps the tenant of the request under TenantKey, declared after Authenticate.
+//An authenticated request belongs to the tenant of its token or api key, a subdomain or tenant header
+//naming another one is refused with 403. Requests without credentials take the subdomain or header, else the default tenant
+func ResolveTenant(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|helpers|resolve-tenant|"
+
+		tenant := requestTenant(ctx, c)
+		if tenant != "" && !IsTenantID(tenant) {
+			BadResponse(RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: DEBUG,
+				Section:  process + "request",
+				Reason:   "invalid tenant " + tenant,
+				Input:    c.Request.Host,
+			})
+			c.Abort()
+			return
+		}
+
+		if principal, ok := CurrentPrincipal(c); ok {
+			if tenant != "" && tenant != principal.Tenant {
+				ForbiddenResponse(RespParams{
+					Log:      ctx.Log,
+					Context:  c,
+					Severity: DEBUG,
+					Section:  process + "principal",
+					Reason:   "credentials of another tenant",
+					Input:    principal.Kind + " " + principal.ID + " " + tenant,
+				})
+				return
+			}
+			tenant = principal.Tenant
+		}
+		if tenant == "" {
+			tenant = tables.DefaultTenantID
+		}
+
+		c.Set(TenantKey, tenant)
+		c.Next()
+	}
+}
+
+//CurrentTenant tenant of the request, empty when the route doesn't resolve one
+func CurrentTenant(c *gin.Context) string {
+	return c.GetString(TenantKey)
+}
+
+//TenantContext ctx with the catalog, stock, promotion, cart, order and wallet repositories narrowed
+//by ctx.Tenants to the tenant of the request, a request without tenant sees none of their rows
+func TenantContext(ctx cfg.RepositoryContext, c *gin.Context) cfg.RepositoryContext {
+	return ctx.Tenants(ctx, CurrentTenant(c))
+}
+
+//GormTenantRepositories TenantRepositories over ctx.DB narrowed by WithTenant
+func GormTenantRepositories(ctx cfg.RepositoryContext, tenant string) cfg.RepositoryContext {
+	db := tables.WithTenant(ctx.DB, tenant)
+	ctx.Products = tables.NewProductRepository(db)
+	ctx.Categories = tables.NewCategoryRepository(db)
+	ctx.Warehouses = tables.NewWarehouseRepository(db)
+	ctx.Variants = tables.NewVariantRepository(db)
+	ctx.Stock = tables.NewStockRepository(db)
+	ctx.LowStock = tables.NewLowStockRepository(db)
+	ctx.Reserve = tables.NewReservationRepository(db)
+	ctx.Prices = tables.NewPriceRepository(db)
+	ctx.Currency = tables.NewCurrencyRepository(db)
+	ctx.Promotions = tables.NewPromotionRepository(db)
+	ctx.Carts = tables.NewCartRepository(db)
+	ctx.Orders = tables.NewOrderRepository(db)
+	ctx.Wallets = tables.NewWalletRepository(db)
+	return ctx
+}
+
+//MemoryTenantRepositories TenantRepositories keeping apart the in-memory repositories of every tenant,
+//a tenant starts with empty ones on its first request
+func MemoryTenantRepositories() cfg.TenantRepositories {
+	mu := sync.Mutex{}
+	stores := map[string]cfg.RepositoryContext{}
+	return func(ctx cfg.RepositoryContext, tenant string) cfg.RepositoryContext {
+		mu.Lock()
+		defer mu.Unlock()
+
+		store, ok := stores[tenant]
+		if !ok {
+			products := tables.NewProductMemoryRepository()
+			warehouses := tables.NewWarehouseMemoryRepository()
+			stock := tables.NewStockMemoryRepository(products, warehouses)
+			reserve := tables.NewReservationMemoryRepository(products, stock)
+			carts := tables.NewCartMemoryRepository()
+			orders := tables.NewOrderMemoryRepository(products, stock, reserve, carts)
+			store = cfg.RepositoryContext{
+				Products:   products,
+				Categories: tables.NewCategoryMemoryRepository(),
+				Warehouses: warehouses,
+				Variants:   tables.NewVariantMemoryRepository(),
+				Stock:      stock,
+				LowStock:   tables.NewLowStockMemoryRepository(products),
+				Reserve:    reserve,
+				Prices:     tables.NewPriceMemoryRepository(products),
+				Currency:   tables.NewCurrencyMemoryRepository(),
+				Promotions: tables.NewPromotionMemoryRepository(),
+				Carts:      carts,
+				Orders:     orders,
+				Wallets:    tables.NewWalletMemoryRepository(orders),
+			}
+			stores[tenant] = store
+		}
+
+		ctx.Products = store.Products
+		ctx.Categories = store.Categories
+		ctx.Warehouses = store.Warehouses
+		ctx.Variants = store.Variants
+		ctx.Stock = store.Stock
+		ctx.LowStock = store.LowStock
+		ctx.Reserve = store.Reserve
+		ctx.Prices = store.Prices
+		ctx.Currency = store.Currency
+		ctx.Promotions = store.Promotions
+		ctx.Carts = store.Carts
+		ctx.Orders = store.Orders
+		ctx.Wallets = store.Wallets
+		return ctx
+	}
+}
+
+//PerTenant builds handler over TenantContext on every request, used on the routes reaching tenant data
+func PerTenant(ctx cfg.RepositoryContext, handler func(cfg.RepositoryContext) gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handler(TenantContext(ctx, c))(c)
+	}
+}
+
+//requestTenant tenant asked for by the request, the subdomain below Config.Tenant.Domain before the tenant header
+func requestTenant(ctx cfg.RepositoryContext, c *gin.Context) string {
+	if domain := ctx.Config.Tenant.Domain; domain != "" {
+		host := strings.ToLower(c.Request.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if sub := strings.TrimSuffix(host, "."+domain); sub != host {
+			return sub
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(c.GetHeader(ctx.Config.Tenant.Header)))
+}
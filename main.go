@@ -3,15 +3,20 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	cfg "product-test/config"
+	tables "product-test/database"
 	"product-test/services"
+	"product-test/storage"
 
 	h "product-test/helpers"
 
@@ -29,6 +34,42 @@ func main() {
 		log.Fatal("can't init service context :", err)
 	}
 
+	//grant subcommand giving roles to a user, e.g. go run main.go grant <username> admin
+	if len(os.Args) > 1 && os.Args[1] == "grant" {
+		if err := Grant(ctx, os.Args[2:]); err != nil {
+			ctx.Log.Fatal("grant failed", zap.Error(err))
+		}
+		return
+	}
+
+	//migrate subcommand, e.g. go run main.go migrate up|down [steps]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := Migrate(ctx, os.Args[2:]); err != nil {
+			ctx.Log.Fatal("migration failed", zap.Error(err))
+		}
+		return
+	}
+	if ctx.Config.DB.AutoMigrate {
+		if err := Migrate(ctx, []string{"up"}); err != nil {
+			ctx.Log.Fatal("migration failed", zap.Error(err))
+		}
+	}
+
+	//expire stale stock reservations in the background
+	stopJobs := make(chan struct{})
+	go SweepReservations(ctx, stopJobs)
+
+	//scheduled prices
+	go SchedulePrices(ctx, stopJobs)
+
+	//low stock alerts by mail
+	if ctx.Config.Mail.Host != "" && len(ctx.Config.Mail.AlertTo) > 0 {
+		mail := ctx.Config.Mail
+		go AlertLowStock(ctx, h.NewMailer(mail.Host, mail.Username, mail.Password, mail.Port), stopJobs)
+	} else {
+		ctx.Log.Info("low stock alerts disabled, MAIL_HOST or LOW_STOCK_ALERT_TO not set")
+	}
+
 	//gin setup
 	gin.SetMode(gin.ReleaseMode)
 
@@ -47,6 +88,7 @@ func main() {
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	ctx.Log.Info("Shutdown " + ctx.Config.App.Name + " repository")
+	close(stopJobs)
 
 	cts, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -73,14 +115,258 @@ func Routing(ctx cfg.RepositoryContext) *gin.Engine {
 	}
 	p.Use(r)
 
+	//uploaded images of the local storage backend
+	if ctx.Config.Storage.Backend == storage.BackendLocal {
+		r.Static("/images", ctx.Config.Storage.LocalPath)
+	}
+
+	//services open without a token
+	public := r.Group("/services", h.ResolveTenant(ctx))
+	{
+		public.POST("/auth/login", services.Login(ctx))
+		public.POST("/auth/refresh", services.RefreshToken(ctx))
+		public.POST("/auth/logout", services.Logout(ctx))
+		public.POST("/user", services.RegisterUser(ctx))
+	}
+
 	//services
-	function := r.Group("/services")
+	function := r.Group("/services", h.Authenticate(ctx), h.ResolveTenant(ctx))
+	canRead := h.Authorize(ctx, h.PermCatalogRead)
+	canWrite := h.Authorize(ctx, h.PermCatalogWrite)
+	canStock := h.Authorize(ctx, h.PermStockWrite)
+	canManageUsers := h.Authorize(ctx, h.PermUsersManage)
+	canManageKeys := h.Authorize(ctx, h.PermAPIKeysManage)
+	canPlaceOrders := h.Authorize(ctx, h.PermOrdersPlace)
+	canManageOrders := h.Authorize(ctx, h.PermOrdersManage)
+	self := h.AuthorizeSelf(ctx, "id", h.PermUsersManage)
+	customer := h.AuthorizeCustomer(ctx, "customer", h.PermOrdersPlace, h.PermOrdersManage)
+
+	//handlers reaching products are built per request over the repositories of the request tenant
+	tenant := func(handler func(cfg.RepositoryContext) gin.HandlerFunc) gin.HandlerFunc {
+		return h.PerTenant(ctx, handler)
+	}
 
 	{
-		function.POST("/add-product", services.AddProduct(ctx))
-		function.GET("/list-product/:sort", services.ProductList(ctx))
+		function.POST("/add-product", canWrite, tenant(services.AddProduct))
+		function.GET("/list-product", canRead, tenant(services.ProductList))
+		function.GET("/list-product/:sort", canRead, tenant(services.ProductList))
+		function.GET("/search-product", canRead, tenant(services.SearchProduct))
+		function.GET("/product/:id", canRead, tenant(services.GetProduct))
+		function.PUT("/product/:id", canWrite, tenant(services.UpdateProduct))
+		function.PATCH("/product/:id", canWrite, tenant(services.UpdateProduct))
+		function.DELETE("/product/:id", canWrite, tenant(services.DeleteProduct))
+		function.POST("/product/:id/restore", canWrite, tenant(services.RestoreProduct))
+		function.GET("/product/:id/categories", canRead, tenant(services.ProductCategories))
+		function.PUT("/product/:id/categories", canWrite, tenant(services.SetProductCategories))
+		function.GET("/product/:id/variants", canRead, tenant(services.VariantList))
+		function.POST("/product/:id/variants", canWrite, tenant(services.AddVariant))
+		function.PUT("/product/:id/variants/:variant_id", canWrite, tenant(services.UpdateVariant))
+		function.POST("/product/:id/stock", canStock, tenant(services.AdjustStock))
+		function.GET("/product/:id/stock", canRead, tenant(services.StockHistory))
+		function.POST("/product/:id/stock/transfer", canStock, tenant(services.TransferStock))
+		function.PUT("/product/:id/reorder-threshold", canStock, tenant(services.SetReorderThreshold))
+		function.GET("/product/:id/price-history", canRead, tenant(services.PriceHistory))
+		function.POST("/product/:id/price-schedule", canWrite, tenant(services.AddPriceSchedule))
+		function.GET("/product/:id/price-schedule", canRead, tenant(services.PriceScheduleList))
+		function.DELETE("/price-schedule/:id", canWrite, tenant(services.CancelPriceSchedule))
+		function.GET("/product/:id/prices", canRead, tenant(services.ProductPrices))
+		function.PUT("/product/:id/prices/:currency", canWrite, tenant(services.SetProductPrice))
+		function.DELETE("/product/:id/prices/:currency", canWrite, tenant(services.DeleteProductPrice))
+		function.GET("/exchange-rate", canRead, tenant(services.ExchangeRateList))
+		function.PUT("/exchange-rate/:base/:quote", canWrite, tenant(services.SetExchangeRate))
+		function.DELETE("/exchange-rate/:base/:quote", canWrite, tenant(services.DeleteExchangeRate))
+		function.POST("/product/:id/reservations", canPlaceOrders, tenant(services.ReserveStock))
+		function.GET("/reservation/:id", canPlaceOrders, tenant(services.GetReservation))
+		function.POST("/reservation/:id/confirm", canStock, tenant(services.ConfirmReservation))
+		function.POST("/reservation/:id/release", canPlaceOrders, tenant(services.ReleaseReservation))
+
+		function.POST("/category", canWrite, tenant(services.AddCategory))
+		function.GET("/category", canRead, tenant(services.CategoryList))
+		function.GET("/category/:id", canRead, tenant(services.GetCategory))
+		function.PUT("/category/:id", canWrite, tenant(services.UpdateCategory))
+		function.DELETE("/category/:id", canWrite, tenant(services.DeleteCategory))
+
+		function.POST("/warehouse", canWrite, tenant(services.AddWarehouse))
+		function.GET("/warehouse", canRead, tenant(services.WarehouseList))
+		function.GET("/warehouse/:id", canRead, tenant(services.GetWarehouse))
+		function.PUT("/warehouse/:id", canWrite, tenant(services.UpdateWarehouse))
+
+		function.POST("/promotion", canWrite, tenant(services.AddPromotion))
+		function.GET("/promotion", canRead, tenant(services.PromotionList))
+		function.GET("/promotion/:id", canRead, tenant(services.GetPromotion))
+		function.PUT("/promotion/:id", canWrite, tenant(services.UpdatePromotion))
+		function.DELETE("/promotion/:id", canWrite, tenant(services.DeletePromotion))
+		function.POST("/pricing", canRead, tenant(services.Pricing))
+
+		function.POST("/cart", canPlaceOrders, tenant(services.AddCart))
+		function.GET("/cart/:id", canPlaceOrders, tenant(services.GetCart))
+		function.DELETE("/cart/:id", canPlaceOrders, tenant(services.DeleteCart))
+		function.POST("/cart/:id/items", canPlaceOrders, tenant(services.AddCartItem))
+		function.PUT("/cart/:id/items/:product_id", canPlaceOrders, tenant(services.SetCartItem))
+		function.DELETE("/cart/:id/items/:product_id", canPlaceOrders, tenant(services.RemoveCartItem))
+		function.POST("/order", canPlaceOrders, tenant(services.PlaceOrder))
+		function.GET("/order", canPlaceOrders, tenant(services.OrderList))
+		function.GET("/order/:id", canPlaceOrders, tenant(services.GetOrder))
+		function.POST("/order/:id/ship", canManageOrders, tenant(services.ShipOrder))
+		function.POST("/order/:id/cancel", canPlaceOrders, tenant(services.CancelOrder))
+
+		function.GET("/wallet/:customer", customer, tenant(services.GetWallet))
+		function.GET("/wallet/:customer/transactions", customer, tenant(services.WalletTransactions))
+		function.POST("/wallet/:customer/deposit", canManageOrders, tenant(services.DepositWallet))
+		function.POST("/wallet/:customer/withdraw", canManageOrders, tenant(services.WithdrawWallet))
+		function.POST("/wallet/:customer/pay", customer, tenant(services.PayOrderFromWallet))
+
+		function.GET("/user/:id", self, services.GetUser(ctx))
+		function.PUT("/user/:id", self, services.UpdateUser(ctx))
+		function.PUT("/user/:id/password", self, services.ChangePassword(ctx))
+		function.DELETE("/user/:id", self, services.DeactivateUser(ctx))
+		function.GET("/user/:id/roles", self, services.UserRoles(ctx))
+		function.PUT("/user/:id/roles", canManageUsers, services.SetUserRoles(ctx))
+
+		function.POST("/api-key", canManageKeys, services.AddAPIKey(ctx))
+		function.GET("/api-key", canManageKeys, services.APIKeyList(ctx))
+		function.GET("/api-key/:id", canManageKeys, services.GetAPIKey(ctx))
+		function.DELETE("/api-key/:id", canManageKeys, services.RevokeAPIKey(ctx))
 		//function.POST("/get-va", bri.GetBriva(ctx))
 	}
 
 	return r
 }
+
+//SweepReservations expires stale stock reservations every Reserve.SweepInterval until stop is closed
+func SweepReservations(ctx cfg.RepositoryContext, stop <-chan struct{}) {
+	ticker := time.NewTicker(ctx.Config.Reserve.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			expired, err := ctx.Reserve.Expire(now)
+			if err != nil {
+				ctx.Log.Warn("can't expire reservations", zap.Error(err))
+				continue
+			}
+			if expired > 0 {
+				ctx.Log.Info("reservations expired", zap.Int64("count", expired))
+			}
+		}
+	}
+}
+
+//SchedulePrices starts and ends the due price schedules every Price.ScheduleInterval until stop is closed
+func SchedulePrices(ctx cfg.RepositoryContext, stop <-chan struct{}) {
+	ticker := time.NewTicker(ctx.Config.Price.ScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			changed, err := ctx.Prices.ApplySchedules(now)
+			var failed tables.ScheduleErrors
+			if errors.As(err, &failed) {
+				for _, err := range failed {
+					ctx.Log.Warn("can't apply price schedule", zap.Error(err))
+				}
+			} else if err != nil {
+				ctx.Log.Warn("can't apply price schedules", zap.Error(err))
+			}
+			if changed > 0 {
+				ctx.Log.Info("price schedules applied", zap.Int("count", changed))
+			}
+		}
+	}
+}
+
+//AlertLowStock mails the low stock alerts every Mail.LowStockInterval until stop is closed
+func AlertLowStock(ctx cfg.RepositoryContext, mailer h.Mailer, stop <-chan struct{}) {
+	ticker := time.NewTicker(ctx.Config.Mail.LowStockInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			alerted, err := h.SendLowStockAlerts(ctx, mailer, now)
+			if err != nil {
+				ctx.Log.Warn("can't send low stock alerts", zap.Error(err))
+				continue
+			}
+			if alerted > 0 {
+				ctx.Log.Info("low stock alert sent", zap.Int("products", alerted))
+			}
+		}
+	}
+}
+
+func Migrate(ctx cfg.RepositoryContext, args []string) error {
+	migrator, err := tables.NewMigrator(ctx.DB)
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			ctx.Log.Info("migration applied", zap.Int("version", m.Version), zap.String("name", m.Name))
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("down steps must be a positive number")
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			ctx.Log.Info("migration reverted", zap.Int("version", m.Version), zap.String("name", m.Name))
+		}
+		return err
+	case "status":
+		status, err := migrator.Status()
+		for _, m := range status {
+			ctx.Log.Info("migration status", zap.Int("version", m.Version), zap.String("name", m.Name),
+				zap.Bool("applied", m.Applied), zap.Time("applied_at", m.AppliedAt))
+		}
+		return err
+	}
+
+	return fmt.Errorf("unknown migrate command %s, use up, down [steps] or status", command)
+}
+
+//Grant adds roles to the user named by the first argument, this is how the first admin is made
+func Grant(ctx cfg.RepositoryContext, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("use grant <username> <role> [role...]")
+	}
+	user, err := ctx.Users.GetByUsername(args[0])
+	if err != nil {
+		return fmt.Errorf("user %s : %w", args[0], err)
+	}
+	roles, err := ctx.Users.Roles(user.IDUser)
+	if err != nil {
+		return err
+	}
+	for _, role := range args[1:] {
+		if !h.IsRole(role) {
+			return fmt.Errorf("unknown role %s", role)
+		}
+		roles = append(roles, role)
+	}
+	if err := ctx.Users.SetRoles(user.IDUser, roles); err != nil {
+		return err
+	}
+	ctx.Log.Info("roles granted", zap.String("username", user.Username), zap.Strings("roles", args[1:]))
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	fx "product-test/functions"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+//testServer the routes of Routing over the in-memory repositories, requests authenticate
+//with the access token of a user of tokens named by the request
+type testServer struct {
+	ctx    cfg.RepositoryContext
+	router *gin.Engine
+	tokens map[string]string
+}
+
+//testResponse body written by the helpers responses, Data is the decoded json of the data
+type testResponse struct {
+	Code        int
+	Status      bool            `json:"status"`
+	ErrorCode   string          `json:"error_code"`
+	Description string          `json:"description"`
+	Data        json.RawMessage `json:"data"`
+}
+
+var (
+	signingKeyOnce sync.Once
+	signingKey     []byte
+)
+
+//testJWTKeys token keys of a test, the rsa key is generated once per run
+func testJWTKeys(t *testing.T) fx.JWTKeys {
+	t.Helper()
+	signingKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signingKey = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	})
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "test.pem"), signingKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := fx.LoadJWTKeys(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	ids, err := fx.NewIDGenerator(fx.IDGeneratorULID, rand.Reader, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cfg.RepositoryContext{
+		Users:   tables.NewUserMemoryRepository(),
+		Tokens:  tables.NewRefreshTokenMemoryRepository(),
+		APIKeys: tables.NewAPIKeyMemoryRepository(),
+		Tenants: h.MemoryTenantRepositories(),
+		JWT:     testJWTKeys(t),
+		IDGen:   ids,
+		Log:     zap.NewNop(),
+	}
+	ctx = ctx.Tenants(ctx, tables.DefaultTenantID)
+	ctx.Config.App.Location = time.UTC
+	ctx.Config.Price.Currency = "IDR"
+	ctx.Config.Storage.MaxImageSize = 1 << 20
+	ctx.Config.Reserve.TTL = time.Minute
+	ctx.Config.Reserve.MaxTTL = time.Hour
+	ctx.Config.Reserve.MaxOpen = 2
+	ctx.Config.Tenant.Header = "X-Tenant-ID"
+	ctx.Config.Auth.Issuer = "product-test"
+	ctx.Config.Auth.AccessTTL = time.Hour
+
+	gin.SetMode(gin.ReleaseMode)
+	s := &testServer{ctx: ctx, router: Routing(ctx), tokens: map[string]string{}}
+	s.addUser(t, "admin", tables.DefaultTenantID, tables.RoleAdmin)
+	s.addUser(t, "viewer", tables.DefaultTenantID, tables.RoleViewer)
+	s.addUser(t, "alice", tables.DefaultTenantID, tables.RoleCustomer)
+	s.addUser(t, "bob", tables.DefaultTenantID, tables.RoleCustomer)
+	return s
+}
+
+//addUser user of tenant with role, its id and username are name
+func (s *testServer) addUser(t *testing.T, name, tenant, role string) {
+	t.Helper()
+	u := tables.User{TenantID: tenant, Username: name, Email: name + "@example.com", Active: true, Roles: []string{role}}
+	if err := s.ctx.Users.Create(&u, func() (string, error) { return name, nil }); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	token, err := s.ctx.JWT.Sign(fx.JWTClaims{
+		ID:        "token-" + name,
+		Issuer:    s.ctx.Config.Auth.Issuer,
+		Subject:   u.IDUser,
+		Username:  u.Username,
+		Roles:     u.Roles,
+		Tenant:    tenant,
+		Use:       fx.TokenAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.ctx.Config.Auth.AccessTTL).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.tokens[name] = token
+}
+
+//do sends the request as user (anonymous when empty), headers are name, value pairs
+func (s *testServer) do(t *testing.T, user, method, url, body string, headers ...string) testResponse {
+	t.Helper()
+	req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("Authorization", "Bearer "+s.tokens[user])
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	resp := testResponse{Code: w.Code}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: %v %s", method, url, err, w.Body.String())
+	}
+	//GoodResponse sends the data as a json string
+	var data string
+	if err := json.Unmarshal(resp.Data, &data); err == nil {
+		resp.Data = json.RawMessage(data)
+	}
+	return resp
+}
+
+//expect fails the test unless the response has code, the data is decoded into v when given
+func (r testResponse) expect(t *testing.T, code int, v interface{}) {
+	t.Helper()
+	if r.Code != code {
+		t.Fatalf("expected %d, got %d %s %s", code, r.Code, r.ErrorCode, r.Description)
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		t.Fatalf("can't decode %s: %v", r.Data, err)
+	}
+}
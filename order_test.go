@@ -0,0 +1,165 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	tables "product-test/database"
+	"product-test/shared"
+)
+
+//placeTestOrder order of quantity units of the product placed by customer from a new cart
+func placeTestOrder(t *testing.T, s *testServer, customer, idProduct string, quantity int) shared.Order {
+	t.Helper()
+	cart := shared.Cart{}
+	s.do(t, customer, "POST", "/services/cart", "").expect(t, http.StatusOK, &cart)
+	item := `{"id_product":"` + idProduct + `","quantity":` + strconv.Itoa(quantity) + `}`
+	s.do(t, customer, "POST", "/services/cart/"+cart.IDCart+"/items", item).expect(t, http.StatusOK, nil)
+
+	order := shared.Order{}
+	s.do(t, customer, "POST", "/services/order", `{"id_cart":"`+cart.IDCart+`"}`).expect(t, http.StatusOK, &order)
+	return order
+}
+
+func TestCartsAndOrdersBelongToTheirCustomer(t *testing.T) {
+	s := newTestServer(t)
+	product := addTestProduct(t, s, `{"product_name":"tea","price":"100","description":"green","quantity":5}`)
+
+	cart := shared.Cart{}
+	s.do(t, "alice", "POST", "/services/cart", "").expect(t, http.StatusOK, &cart)
+	s.do(t, "bob", "GET", "/services/cart/"+cart.IDCart, "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "bob", "DELETE", "/services/cart/"+cart.IDCart, "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "admin", "GET", "/services/cart/"+cart.IDCart, "").expect(t, http.StatusOK, nil)
+	s.do(t, "viewer", "POST", "/services/cart", "").expect(t, http.StatusForbidden, nil)
+
+	order := placeTestOrder(t, s, "alice", product.IDProduct, 2)
+	if order.IDCustomer != "alice" || order.Status != tables.OrderPending {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	s.do(t, "bob", "GET", "/services/order/"+order.IDOrder, "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "bob", "POST", "/services/order/"+order.IDOrder+"/cancel", "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "alice", "POST", "/services/order/"+order.IDOrder+"/ship", "").expect(t, http.StatusForbidden, nil)
+
+	list := []shared.Order{}
+	s.do(t, "bob", "GET", "/services/order", "").expect(t, http.StatusOK, &list)
+	if len(list) != 0 {
+		t.Errorf("bob sees the orders of alice: %+v", list)
+	}
+	s.do(t, "alice", "GET", "/services/order", "").expect(t, http.StatusOK, &list)
+	if len(list) != 1 || list[0].IDOrder != order.IDOrder {
+		t.Errorf("unexpected orders of alice %+v", list)
+	}
+	s.do(t, "admin", "GET", "/services/order", "").expect(t, http.StatusOK, &list)
+	if len(list) != 1 {
+		t.Errorf("unexpected orders for admin %+v", list)
+	}
+}
+
+func TestWalletPaymentAndRefund(t *testing.T) {
+	s := newTestServer(t)
+	product := addTestProduct(t, s, `{"product_name":"tea","price":"100","description":"green","quantity":5}`)
+	order := placeTestOrder(t, s, "alice", product.IDProduct, 2)
+
+	s.do(t, "admin", "POST", "/services/wallet/alice/deposit", `{"amount":"500"}`).expect(t, http.StatusBadRequest, nil)
+	s.do(t, "admin", "POST", "/services/wallet/alice/deposit", `{"amount":"500"}`, "Idempotency-Key", "d1").
+		expect(t, http.StatusOK, nil)
+	s.do(t, "admin", "POST", "/services/wallet/ghost/deposit", `{"amount":"500"}`, "Idempotency-Key", "d2").
+		expect(t, http.StatusNotFound, nil)
+	s.do(t, "alice", "POST", "/services/wallet/alice/deposit", `{"amount":"500"}`, "Idempotency-Key", "d3").
+		expect(t, http.StatusForbidden, nil)
+
+	pay := `{"id_order":"` + order.IDOrder + `"}`
+	s.do(t, "bob", "POST", "/services/wallet/alice/pay", pay, "Idempotency-Key", "p1").expect(t, http.StatusForbidden, nil)
+	s.do(t, "bob", "GET", "/services/wallet/alice", "").expect(t, http.StatusForbidden, nil)
+	s.do(t, "alice", "POST", "/services/wallet/alice/pay", pay, "Idempotency-Key", "p1").expect(t, http.StatusOK, nil)
+
+	wallet := shared.Wallet{}
+	s.do(t, "alice", "GET", "/services/wallet/alice", "").expect(t, http.StatusOK, &wallet)
+	if wallet.Balance != (shared.Money{Amount: 30000, Currency: "IDR"}) {
+		t.Fatalf("unexpected balance after payment %+v", wallet.Balance)
+	}
+	paid := shared.Order{}
+	s.do(t, "alice", "GET", "/services/order/"+order.IDOrder, "").expect(t, http.StatusOK, &paid)
+	if paid.Status != tables.OrderPaid {
+		t.Fatalf("order is %s after payment", paid.Status)
+	}
+
+	cancelled := shared.Order{}
+	s.do(t, "alice", "POST", "/services/order/"+order.IDOrder+"/cancel", "").expect(t, http.StatusOK, &cancelled)
+	if cancelled.Status != tables.OrderCancelled {
+		t.Fatalf("order is %s after cancel", cancelled.Status)
+	}
+	s.do(t, "alice", "GET", "/services/wallet/alice", "").expect(t, http.StatusOK, &wallet)
+	if wallet.Balance != (shared.Money{Amount: 50000, Currency: "IDR"}) {
+		t.Errorf("unexpected balance after refund %+v", wallet.Balance)
+	}
+	stocked := shared.Product{}
+	s.do(t, "admin", "GET", "/services/product/"+product.IDProduct, "").expect(t, http.StatusOK, &stocked)
+	if stocked.Quantity != 5 {
+		t.Errorf("quantity is %d after cancel", stocked.Quantity)
+	}
+}
+
+func TestWalletPaysOnlyOwnOrders(t *testing.T) {
+	s := newTestServer(t)
+	product := addTestProduct(t, s, `{"product_name":"tea","price":"100","description":"green","quantity":5}`)
+	order := placeTestOrder(t, s, "alice", product.IDProduct, 1)
+
+	s.do(t, "admin", "POST", "/services/wallet/bob/deposit", `{"amount":"500"}`, "Idempotency-Key", "d1").
+		expect(t, http.StatusOK, nil)
+	pay := `{"id_order":"` + order.IDOrder + `"}`
+	s.do(t, "bob", "POST", "/services/wallet/bob/pay", pay, "Idempotency-Key", "p1").expect(t, http.StatusBadRequest, nil)
+
+	wallet := shared.Wallet{}
+	s.do(t, "bob", "GET", "/services/wallet/bob", "").expect(t, http.StatusOK, &wallet)
+	if wallet.Balance != (shared.Money{Amount: 50000, Currency: "IDR"}) {
+		t.Errorf("balance of bob moved %+v", wallet.Balance)
+	}
+}
+
+func TestCancelReturnsStockToItsWarehouse(t *testing.T) {
+	s := newTestServer(t)
+	product := addTestProduct(t, s, `{"product_name":"tea","price":"100","description":"green","quantity":1}`)
+	east := shared.Warehouse{}
+	s.do(t, "admin", "POST", "/services/warehouse", `{"code":"EAST","name":"East"}`).expect(t, http.StatusOK, &east)
+	receipt := `{"type":"receipt","quantity":5,"warehouse_id":"` + east.IDWarehouse + `"}`
+	s.do(t, "admin", "POST", "/services/product/"+product.IDProduct+"/stock", receipt).expect(t, http.StatusOK, nil)
+
+	order := placeTestOrder(t, s, "alice", product.IDProduct, 2)
+	s.do(t, "alice", "POST", "/services/order/"+order.IDOrder+"/cancel", "").expect(t, http.StatusOK, nil)
+
+	stocked := shared.Product{}
+	s.do(t, "admin", "GET", "/services/product/"+product.IDProduct, "").expect(t, http.StatusOK, &stocked)
+	for _, level := range stocked.Stock {
+		if level.Code == "EAST" && level.Quantity != 5 || level.Code != "EAST" && level.Quantity != 1 {
+			t.Errorf("unexpected stock after cancel %+v", stocked.Stock)
+		}
+	}
+}
+
+func TestReservationsBelongToTheirCustomer(t *testing.T) {
+	s := newTestServer(t)
+	product := addTestProduct(t, s, `{"product_name":"tea","price":"100","description":"green","quantity":5}`)
+	url := "/services/product/" + product.IDProduct + "/reservations"
+
+	reservation := shared.Reservation{}
+	s.do(t, "alice", "POST", url, `{"quantity":1}`).expect(t, http.StatusOK, &reservation)
+	if reservation.IDCustomer != "alice" {
+		t.Fatalf("unexpected reservation %+v", reservation)
+	}
+	s.do(t, "bob", "GET", "/services/reservation/"+reservation.IDReservation, "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "bob", "POST", "/services/reservation/"+reservation.IDReservation+"/release", "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "admin", "GET", "/services/reservation/"+reservation.IDReservation, "").expect(t, http.StatusOK, nil)
+
+	s.do(t, "alice", "POST", url, `{"quantity":1}`).expect(t, http.StatusOK, nil)
+	s.do(t, "alice", "POST", url, `{"quantity":1}`).expect(t, http.StatusBadRequest, nil)
+	s.do(t, "bob", "POST", url, `{"quantity":1}`).expect(t, http.StatusOK, nil)
+
+	released := shared.Reservation{}
+	s.do(t, "alice", "POST", "/services/reservation/"+reservation.IDReservation+"/release", "").expect(t, http.StatusOK, &released)
+	if released.Status != tables.ReservationReleased {
+		t.Errorf("unexpected reservation %+v", released)
+	}
+	s.do(t, "alice", "POST", url, `{"quantity":1}`).expect(t, http.StatusOK, nil)
+}
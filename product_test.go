@@ -0,0 +1,235 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+)
+
+//addTestProduct product added by admin through the add-product route
+func addTestProduct(t *testing.T, s *testServer, body string) shared.Product {
+	t.Helper()
+	product := shared.Product{}
+	s.do(t, "admin", "POST", "/services/add-product", body).expect(t, http.StatusOK, &product)
+	return product
+}
+
+func TestAddAndGetProduct(t *testing.T) {
+	s := newTestServer(t)
+	added := addTestProduct(t, s, `{"product_name":"tea","price":"15000.50","description":"green","quantity":4}`)
+	if added.IDProduct == "" || added.Quantity != 4 || added.Price != "15000.5" ||
+		added.PriceMoney != (shared.Money{Amount: 1500050, Currency: "IDR"}) {
+		t.Fatalf("unexpected product %+v", added)
+	}
+
+	product := shared.Product{}
+	s.do(t, "viewer", "GET", "/services/product/"+added.IDProduct, "").expect(t, http.StatusOK, &product)
+	if product.ProductName != "tea" || product.Description != "green" || !product.Active {
+		t.Errorf("unexpected product %+v", product)
+	}
+
+	s.do(t, "viewer", "GET", "/services/product/missing", "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "", "GET", "/services/product/"+added.IDProduct, "").expect(t, http.StatusUnauthorized, nil)
+	s.do(t, "admin", "POST", "/services/add-product", `{"price":100,"description":"green","quantity":1}`).
+		expect(t, http.StatusBadRequest, nil)
+}
+
+func TestListProductSortsAndFilters(t *testing.T) {
+	s := newTestServer(t)
+	addTestProduct(t, s, `{"product_name":"tea","price":1000,"description":"green","quantity":1}`)
+	addTestProduct(t, s, `{"product_name":"coffee","price":3000,"description":"black","quantity":1}`)
+	addTestProduct(t, s, `{"product_name":"cocoa","price":2000,"description":"dark","quantity":1}`)
+
+	list := []shared.Product{}
+	s.do(t, "viewer", "GET", "/services/list-product?sort=-price&price_gte=2000", "").expect(t, http.StatusOK, &list)
+	if len(list) != 2 || list[0].ProductName != "coffee" || list[1].ProductName != "cocoa" {
+		t.Errorf("unexpected products %+v", list)
+	}
+	s.do(t, "viewer", "GET", "/services/list-product/a-z", "").expect(t, http.StatusOK, &list)
+	if len(list) != 3 || list[0].ProductName != "cocoa" || list[2].ProductName != "tea" {
+		t.Errorf("unexpected products %+v", list)
+	}
+	s.do(t, "viewer", "GET", "/services/list-product?sort=secret", "").expect(t, http.StatusBadRequest, nil)
+}
+
+func TestListPriceFiltersTakeMajorUnits(t *testing.T) {
+	s := newTestServer(t)
+	cheap := addTestProduct(t, s, `{"product_name":"tea","price":"1000","description":"green","quantity":1}`)
+	addTestProduct(t, s, `{"product_name":"coffee","price":"50000.50","description":"black","quantity":1}`)
+	addTestProduct(t, s, `{"product_name":"cocoa","price":"10","currency":"USD","description":"dark","quantity":1}`)
+
+	list := []shared.Product{}
+	s.do(t, "viewer", "GET", "/services/list-product?price_lte=50000", "").expect(t, http.StatusOK, &list)
+	if len(list) != 1 || list[0].IDProduct != cheap.IDProduct {
+		t.Errorf("unexpected products under 50000 %+v", list)
+	}
+	s.do(t, "viewer", "GET", "/services/list-product?price_gte=5&currency_eq=USD", "").expect(t, http.StatusOK, &list)
+	if len(list) != 1 || list[0].ProductName != "cocoa" {
+		t.Errorf("unexpected products over 5 USD %+v", list)
+	}
+	s.do(t, "viewer", "GET", "/services/list-product?price_gte=abc", "").expect(t, http.StatusBadRequest, nil)
+}
+
+func TestDeleteAndRestoreProduct(t *testing.T) {
+	s := newTestServer(t)
+	added := addTestProduct(t, s, `{"product_name":"tea","price":1000,"description":"green","quantity":1}`)
+	url := "/services/product/" + added.IDProduct
+
+	s.do(t, "admin", "DELETE", url, "").expect(t, http.StatusOK, nil)
+	s.do(t, "admin", "GET", url, "").expect(t, http.StatusNotFound, nil)
+	list := []shared.Product{}
+	s.do(t, "admin", "GET", "/services/list-product", "").expect(t, http.StatusOK, &list)
+	if len(list) != 0 {
+		t.Errorf("deleted product is listed %+v", list)
+	}
+
+	restored := shared.Product{}
+	s.do(t, "admin", "POST", url+"/restore", "").expect(t, http.StatusOK, &restored)
+	if !restored.Active {
+		t.Errorf("unexpected product %+v", restored)
+	}
+	s.do(t, "admin", "GET", url, "").expect(t, http.StatusOK, nil)
+}
+
+func TestSearchProduct(t *testing.T) {
+	s := newTestServer(t)
+	addTestProduct(t, s, `{"product_name":"green tea","price":1000,"description":"leaves","quantity":1}`)
+	addTestProduct(t, s, `{"product_name":"coffee","price":3000,"description":"beans","quantity":1}`)
+
+	results := []shared.ProductSearchResult{}
+	s.do(t, "viewer", "GET", "/services/search-product?q=te", "").expect(t, http.StatusOK, &results)
+	if len(results) != 1 || results[0].Highlight.ProductName != "green <mark>tea</mark>" {
+		t.Errorf("unexpected results %+v", results)
+	}
+	s.do(t, "viewer", "GET", "/services/search-product?q=", "").expect(t, http.StatusBadRequest, nil)
+}
+
+func TestSearchHighlightIsEscaped(t *testing.T) {
+	s := newTestServer(t)
+	addTestProduct(t, s, `{"product_name":"tea <b>","price":"100","description":"green & fresh","quantity":1}`)
+
+	results := []shared.ProductSearchResult{}
+	s.do(t, "viewer", "GET", "/services/search-product?q=tea", "").expect(t, http.StatusOK, &results)
+	if len(results) != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[0].Highlight.ProductName != "<mark>tea</mark> &lt;b&gt;" {
+		t.Errorf("unexpected name highlight %q", results[0].Highlight.ProductName)
+	}
+	if results[0].Highlight.Description != "green &amp; fresh" {
+		t.Errorf("unexpected description highlight %q", results[0].Highlight.Description)
+	}
+}
+
+func TestProductPermissions(t *testing.T) {
+	s := newTestServer(t)
+	body := `{"product_name":"tea","price":100,"description":"green","quantity":1}`
+
+	resp := s.do(t, "", "POST", "/services/add-product", body)
+	resp.expect(t, http.StatusUnauthorized, nil)
+	resp = s.do(t, "viewer", "POST", "/services/add-product", body)
+	resp.expect(t, http.StatusForbidden, nil)
+	if resp.ErrorCode != h.ErrCodeForbidden {
+		t.Errorf("expected error code %s, got %s", h.ErrCodeForbidden, resp.ErrorCode)
+	}
+	s.do(t, "alice", "POST", "/services/add-product", body).expect(t, http.StatusForbidden, nil)
+}
+
+func TestUpdateProductKeepsQuantity(t *testing.T) {
+	s := newTestServer(t)
+	added := addTestProduct(t, s, `{"product_name":"tea","price":100,"description":"green","quantity":4}`)
+	url := "/services/product/" + added.IDProduct
+
+	s.do(t, "admin", "PUT", url, `{"product_name":"tea","price":100,"description":"green","quantity":9}`).
+		expect(t, http.StatusBadRequest, nil)
+
+	updated := shared.Product{}
+	s.do(t, "admin", "PUT", url, `{"product_name":"black tea","price":120,"description":"black"}`).
+		expect(t, http.StatusOK, &updated)
+	if updated.ProductName != "black tea" || updated.Quantity != 4 {
+		t.Errorf("unexpected product %+v", updated)
+	}
+
+	//a sold out product can still be edited
+	s.do(t, "admin", "POST", url+"/stock", `{"type":"sale","quantity":4}`).expect(t, http.StatusOK, nil)
+	s.do(t, "admin", "PUT", url, `{"product_name":"sold out tea","price":120,"description":"black"}`).
+		expect(t, http.StatusOK, &updated)
+	if updated.ProductName != "sold out tea" || updated.Quantity != 0 {
+		t.Errorf("unexpected product %+v", updated)
+	}
+}
+
+func TestAddProductRefusesNegativeQuantity(t *testing.T) {
+	s := newTestServer(t)
+	s.do(t, "admin", "POST", "/services/add-product", `{"product_name":"tea","price":100,"description":"green","quantity":-5}`).
+		expect(t, http.StatusBadRequest, nil)
+}
+
+func TestAdjustStockRecordsCaller(t *testing.T) {
+	s := newTestServer(t)
+	added := addTestProduct(t, s, `{"product_name":"tea","price":1000,"description":"green","quantity":4}`)
+	url := "/services/product/" + added.IDProduct + "/stock"
+
+	movement := shared.StockMovement{}
+	s.do(t, "admin", "POST", url, `{"type":"sale","quantity":3,"reason":"shop"}`).expect(t, http.StatusOK, &movement)
+	if movement.QuantityChange != -3 || movement.QuantityAfter != 1 || movement.Actor != "user:admin" {
+		t.Errorf("unexpected movement %+v", movement)
+	}
+	s.do(t, "admin", "POST", url, `{"type":"sale","quantity":2}`).expect(t, http.StatusBadRequest, nil)
+	s.do(t, "viewer", "POST", url, `{"type":"receipt","quantity":2}`).expect(t, http.StatusForbidden, nil)
+
+	history := []shared.StockMovement{}
+	s.do(t, "viewer", "GET", url, "").expect(t, http.StatusOK, &history)
+	if len(history) != 1 || history[0].IDMovement != movement.IDMovement {
+		t.Errorf("unexpected history %+v", history)
+	}
+}
+
+func TestReservationsHoldAvailableQuantity(t *testing.T) {
+	s := newTestServer(t)
+	added := addTestProduct(t, s, `{"product_name":"tea","price":1000,"description":"green","quantity":4}`)
+	url := "/services/product/" + added.IDProduct
+
+	reservation := shared.Reservation{}
+	s.do(t, "admin", "POST", url+"/reservations", `{"quantity":3}`).expect(t, http.StatusOK, &reservation)
+	s.do(t, "admin", "POST", url+"/reservations", `{"quantity":2}`).expect(t, http.StatusBadRequest, nil)
+
+	product := shared.Product{}
+	s.do(t, "admin", "GET", url, "").expect(t, http.StatusOK, &product)
+	if product.Quantity != 4 || product.AvailableQuantity != 1 {
+		t.Errorf("unexpected product %+v", product)
+	}
+
+	s.do(t, "admin", "POST", "/services/reservation/"+reservation.IDReservation+"/release", "").expect(t, http.StatusOK, nil)
+	s.do(t, "admin", "POST", "/services/reservation/"+reservation.IDReservation+"/release", "").expect(t, http.StatusBadRequest, nil)
+	s.do(t, "admin", "GET", url, "").expect(t, http.StatusOK, &product)
+	if product.AvailableQuantity != 4 {
+		t.Errorf("unexpected product after release %+v", product)
+	}
+}
+
+func TestTenantsSeeOnlyTheirProducts(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser(t, "eve", "acme", tables.RoleAdmin)
+	own := addTestProduct(t, s, `{"product_name":"tea","price":"100","description":"green","quantity":1}`)
+	other := shared.Product{}
+	s.do(t, "eve", "POST", "/services/add-product", `{"product_name":"tea","price":"100","description":"acme","quantity":1}`).
+		expect(t, http.StatusOK, &other)
+
+	s.do(t, "admin", "GET", "/services/product/"+other.IDProduct, "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "eve", "GET", "/services/product/"+own.IDProduct, "").expect(t, http.StatusNotFound, nil)
+	s.do(t, "admin", "GET", "/services/product/"+own.IDProduct, "", "X-Tenant-ID", "acme").expect(t, http.StatusForbidden, nil)
+
+	list := []shared.Product{}
+	s.do(t, "eve", "GET", "/services/list-product", "").expect(t, http.StatusOK, &list)
+	if len(list) != 1 || list[0].IDProduct != other.IDProduct {
+		t.Errorf("unexpected products of acme %+v", list)
+	}
+	s.do(t, "viewer", "GET", "/services/search-product?q=tea", "").expect(t, http.StatusOK, &list)
+	if len(list) != 1 || list[0].IDProduct != own.IDProduct {
+		t.Errorf("unexpected search results of the default tenant %+v", list)
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//AddAPIKey creates an api key, the key is in this response only since just its hmac is stored
+func AddAPIKey(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-api-key|"
+		now := time.Now()
+		input := shared.ParamAPIKey{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		key, err := validateAPIKey(ctx, input, now)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		secret := make([]byte, 32)
+		_, err = rand.Read(secret)
+		if err == nil {
+			key.KeyHash = h.HashAPIKey(ctx.Config.Auth.APIKeySecret, hex.EncodeToString(secret))
+			if principal, ok := h.CurrentPrincipal(c); ok && principal.Kind == h.PrincipalUser {
+				key.CreatedBy = &principal.ID
+			}
+			key.TenantID = h.CurrentTenant(c)
+			key.CreatedDate = now
+			err = ctx.APIKeys.Create(&key, ctx.IDGen.NewID)
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		data := apiKeyResponse(ctx, key)
+		data.Key = h.APIKeyPrefix + key.IDKey + "_" + hex.EncodeToString(secret)
+		h.GoodResponse(c, data)
+	}
+}
+
+func validateAPIKey(ctx cfg.RepositoryContext, input shared.ParamAPIKey, now time.Time) (tables.APIKey, error) {
+	handleErr := func(err error) (tables.APIKey, error) {
+		return tables.APIKey{}, err
+	}
+
+	key := tables.APIKey{Name: strings.TrimSpace(input.Name)}
+	if err := h.NameRule(key.Name); err != nil {
+		return handleErr(err)
+	}
+
+	for _, scope := range input.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !h.IsAPIKeyScope(scope) {
+			return handleErr(fmt.Errorf("unknown scope %s, use %s, %s or %s", scope, h.PermCatalogRead, h.PermCatalogWrite, h.PermStockWrite))
+		}
+		if !containsString(key.Scopes, scope) {
+			key.Scopes = append(key.Scopes, scope)
+		}
+	}
+	if len(key.Scopes) == 0 {
+		return handleErr(errors.New("scopes is required, cannot be empty"))
+	}
+
+	if strings.TrimSpace(input.ExpiresAt) != "" {
+		expiresAt, err := parseScheduleTime(input.ExpiresAt, ctx.Config.App.Location)
+		if err != nil {
+			return handleErr(fmt.Errorf("expires_at %w", err))
+		}
+		if !expiresAt.After(now) {
+			return handleErr(errors.New("expires_at must be in the future"))
+		}
+		key.ExpiresAt = &expiresAt
+	}
+	return key, nil
+}
+
+//findAPIKey loads an api key of the request tenant by id and writes the error response itself when it can't
+func findAPIKey(ctx cfg.RepositoryContext, c *gin.Context, process, id string) (tables.APIKey, bool) {
+	key, err := ctx.APIKeys.GetByID(id)
+	if err == nil && key.TenantID != h.CurrentTenant(c) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "api key not found",
+				Input:    id,
+			})
+			return tables.APIKey{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.APIKey{}, false
+	}
+
+	return key, true
+}
+
+func apiKeyResponse(ctx cfg.RepositoryContext, row tables.APIKey) shared.APIKey {
+	loc := ctx.Config.App.Location
+	inLocation := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		local := t.In(loc)
+		return &local
+	}
+
+	data := shared.APIKey{
+		IDKey:       row.IDKey,
+		TenantID:    row.TenantID,
+		Name:        row.Name,
+		Scopes:      append([]string{}, row.Scopes...),
+		CreatedBy:   row.CreatedBy,
+		ExpiresAt:   inLocation(row.ExpiresAt),
+		LastUsedAt:  inLocation(row.LastUsedAt),
+		RevokedAt:   inLocation(row.RevokedAt),
+		CreatedDate: row.CreatedDate.In(loc),
+	}
+	sort.Strings(data.Scopes)
+	return data
+}
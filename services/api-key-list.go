@@ -0,0 +1,53 @@
+package services
+
+import (
+	"net/http"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//APIKeyList every api key of the tenant newest first, revoked and expired keys included
+func APIKeyList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|api-key-list|"
+
+		keys, err := ctx.APIKeys.List(h.CurrentTenant(c))
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+			})
+			return
+		}
+
+		data := []shared.APIKey{}
+		for _, row := range keys {
+			data = append(data, apiKeyResponse(ctx, row))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+		})
+	}
+}
+
+func GetAPIKey(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-api-key|"
+
+		key, ok := findAPIKey(ctx, c, process, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, apiKeyResponse(ctx, key))
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"time"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+//RevokeAPIKey revokes the key for good, callers using it get 401 from then on
+func RevokeAPIKey(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|revoke-api-key|"
+		now := time.Now()
+		id := c.Param("id")
+
+		key, ok := findAPIKey(ctx, c, process, id)
+		if !ok {
+			return
+		}
+
+		if err := ctx.APIKeys.Revoke(key.IDKey, now); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		if key.RevokedAt == nil {
+			key.RevokedAt = &now
+		}
+		h.GoodResponse(c, apiKeyResponse(ctx, key))
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	fx "product-test/functions"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//Login gives an access and a refresh token to an active user with the right password
+func Login(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|login|"
+		now := time.Now()
+		input := shared.ParamLogin{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		//the same answer for unknown users, deactivated users and wrong passwords
+		username := strings.TrimSpace(input.Username)
+		user, err := ctx.Users.GetByUsername(username)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "get-by-username",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    username,
+			})
+			return
+		}
+		if err != nil || !user.Active || !h.CheckPasswordHash(input.Password, user.Password) {
+			h.UnauthorizedResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "credentials",
+				Reason:   "wrong username or password",
+				Input:    username,
+			})
+			return
+		}
+
+		token, refresh, err := issueTokens(ctx, user, now)
+		if err == nil {
+			err = ctx.Tokens.Create(&refresh)
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    username,
+			})
+			return
+		}
+
+		h.GoodResponse(c, token)
+	}
+}
+
+//issueTokens signed access token carrying the roles of user and its refresh token,
+//the refresh token row still has to be stored
+func issueTokens(ctx cfg.RepositoryContext, user tables.User, now time.Time) (shared.Token, tables.RefreshToken, error) {
+	handleErr := func(err error) (shared.Token, tables.RefreshToken, error) {
+		return shared.Token{}, tables.RefreshToken{}, err
+	}
+
+	auth := ctx.Config.Auth
+	roles, err := ctx.Users.Roles(user.IDUser)
+	if err != nil {
+		return handleErr(err)
+	}
+	accessID, err := ctx.IDGen.NewID()
+	if err != nil {
+		return handleErr(err)
+	}
+	access, err := ctx.JWT.Sign(fx.JWTClaims{
+		ID:        accessID,
+		Issuer:    auth.Issuer,
+		Subject:   user.IDUser,
+		Username:  user.Username,
+		Roles:     roles,
+		Tenant:    user.TenantID,
+		Use:       fx.TokenAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(auth.AccessTTL).Unix(),
+	})
+	if err != nil {
+		return handleErr(err)
+	}
+
+	refreshID, err := ctx.IDGen.NewID()
+	if err != nil {
+		return handleErr(err)
+	}
+	row := tables.RefreshToken{IDToken: refreshID, IDUser: user.IDUser, ExpiresAt: now.Add(auth.RefreshTTL), CreatedDate: now}
+	refresh, err := ctx.JWT.Sign(fx.JWTClaims{
+		ID:        refreshID,
+		Issuer:    auth.Issuer,
+		Subject:   user.IDUser,
+		Use:       fx.TokenRefresh,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: row.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return handleErr(err)
+	}
+
+	token := shared.Token{
+		AccessToken:      access,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(auth.AccessTTL / time.Second),
+		RefreshToken:     refresh,
+		RefreshExpiresIn: int(auth.RefreshTTL / time.Second),
+	}
+	return token, row, nil
+}
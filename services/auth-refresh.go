@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	fx "product-test/functions"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//RefreshToken trades a refresh token for new tokens, the used refresh token is revoked. Using a revoked
+//refresh token again means it leaked, every refresh token of the user is revoked then
+func RefreshToken(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|refresh-token|"
+		now := time.Now()
+		input := shared.ParamRefreshToken{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		stored, ok := findRefreshToken(ctx, c, process, input.RefreshToken, now)
+		if !ok {
+			return
+		}
+
+		if stored.RevokedAt != nil {
+			if err := ctx.Tokens.RevokeUser(stored.IDUser, now); err != nil {
+				h.BadLogging(h.RespParams{
+					Log:      ctx.Log,
+					Severity: h.ERROR,
+					Section:  process + "revoke-user",
+					Error:    err,
+					Input:    stored.IDUser,
+				})
+			}
+			h.UnauthorizedResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.WARN,
+				Section:  process + "reused",
+				Reason:   "refresh token is revoked",
+				Input:    stored.IDToken,
+			})
+			return
+		}
+
+		user, err := ctx.Users.GetByID(stored.IDUser)
+		if err == nil && !user.Active {
+			err = gorm.ErrRecordNotFound
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.UnauthorizedResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "user",
+				Reason:   "user is deactivated",
+				Input:    stored.IDUser,
+			})
+			return
+		}
+
+		var token shared.Token
+		var next tables.RefreshToken
+		if err == nil {
+			token, next, err = issueTokens(ctx, user, now)
+		}
+		if err == nil {
+			err = ctx.Tokens.Rotate(stored.IDToken, &next, now)
+		}
+		if errors.Is(err, tables.ErrTokenRevoked) {
+			h.UnauthorizedResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "rotate",
+				Reason:   err.Error(),
+				Input:    stored.IDToken,
+			})
+			return
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    stored.IDToken,
+			})
+			return
+		}
+
+		h.GoodResponse(c, token)
+	}
+}
+
+//Logout revokes the refresh token, the access token stays valid until it expires
+func Logout(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|logout|"
+		now := time.Now()
+		input := shared.ParamRefreshToken{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		stored, ok := findRefreshToken(ctx, c, process, input.RefreshToken, now)
+		if !ok {
+			return
+		}
+
+		if err := ctx.Tokens.Revoke(stored.IDToken, now); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    stored.IDToken,
+			})
+			return
+		}
+
+		h.GoodResponse(c, nil)
+	}
+}
+
+//findRefreshToken checks the signature of a refresh token and loads its row,
+//writes the error response itself when it can't
+func findRefreshToken(ctx cfg.RepositoryContext, c *gin.Context, process, token string, now time.Time) (tables.RefreshToken, bool) {
+	claims, err := ctx.JWT.Parse(token, now)
+	if err == nil && (claims.Use != fx.TokenRefresh || claims.Issuer != ctx.Config.Auth.Issuer) {
+		err = errors.New("not a refresh token of this service")
+	}
+	if err != nil {
+		h.UnauthorizedResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "parse",
+			Error:    err,
+			Reason:   "invalid refresh token",
+		})
+		return tables.RefreshToken{}, false
+	}
+
+	stored, err := ctx.Tokens.GetByID(claims.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.UnauthorizedResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "get-by-id",
+			Reason:   "invalid refresh token",
+			Input:    claims.ID,
+		})
+		return tables.RefreshToken{}, false
+	}
+	if err != nil {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    claims.ID,
+		})
+		return tables.RefreshToken{}, false
+	}
+
+	return stored, true
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//AddCart creates an empty cart of the caller
+func AddCart(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-cart|"
+		now := time.Now()
+
+		principal, _ := h.CurrentPrincipal(c)
+		cart := tables.Cart{IDCustomer: principal.ID, CreatedDate: now, UpdatedDate: now}
+		if err := ctx.Carts.Create(&cart, ctx.IDGen.NewID); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+			})
+			return
+		}
+
+		data, ok := cartResponse(ctx, c, process, cart, "")
+		if !ok {
+			return
+		}
+		h.GoodResponse(c, data)
+	}
+}
+
+//AddCartItem adds quantity (default 1) of a product to the cart, an item already in the cart is increased
+func AddCartItem(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-cart-item|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamCartItem{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+		if input.Quantity == 0 {
+			input.Quantity = 1
+		}
+
+		item, ok := validateCartItem(ctx, c, process, id, input)
+		if !ok {
+			return
+		}
+		item.CreatedDate = now
+		if _, err := ctx.Carts.AddItem(item); err != nil {
+			cartItemError(ctx, c, process, err, input)
+			return
+		}
+
+		cart, ok := findCart(ctx, c, process, id)
+		if !ok {
+			return
+		}
+		data, ok := cartResponse(ctx, c, process, cart, "")
+		if !ok {
+			return
+		}
+		h.GoodResponse(c, data)
+	}
+}
+
+//validateCartItem the cart and the active product of the item must exist, the quantity must be positive.
+//It writes the error response itself when they don't
+func validateCartItem(ctx cfg.RepositoryContext, c *gin.Context, process, id string, input shared.ParamCartItem) (tables.CartItem, bool) {
+	if input.Quantity < 0 {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "validate",
+			Reason:   "quantity must be a positive number",
+			Input:    input,
+		})
+		return tables.CartItem{}, false
+	}
+	if err := h.MustNotEmpty(input.IDProduct, "id_product"); err != nil {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "validate",
+			Reason:   err.Error(),
+			Input:    input,
+		})
+		return tables.CartItem{}, false
+	}
+	if _, ok := findCart(ctx, c, process, id); !ok {
+		return tables.CartItem{}, false
+	}
+	if _, ok := findProduct(ctx, c, process, input.IDProduct, false); !ok {
+		return tables.CartItem{}, false
+	}
+	return tables.CartItem{IDCart: id, IDProduct: input.IDProduct, Quantity: input.Quantity}, true
+}
+
+//cartItemError missing carts or items are answered with 404
+func cartItemError(ctx cfg.RepositoryContext, c *gin.Context, process string, err error, input interface{}) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.NotFoundResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "result",
+			Reason:   "cart item not found",
+			Input:    input,
+		})
+		return
+	}
+	h.BadResponse(h.RespParams{
+		Log:      ctx.Log,
+		Context:  c,
+		Severity: h.ERROR,
+		Section:  process + "result",
+		Error:    err,
+		Reason:   err.Error(),
+		Input:    input,
+	})
+}
+
+//findCart loads a cart of the caller with its items and writes the error response itself when it can't,
+//the carts of other customers are answered like missing ones unless the caller holds orders:manage
+func findCart(ctx cfg.RepositoryContext, c *gin.Context, process, id string) (tables.Cart, bool) {
+	cart, err := ctx.Carts.GetByID(id)
+	if err == nil && !customerAccess(c, cart.IDCustomer) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "cart not found",
+				Input:    id,
+			})
+			return tables.Cart{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.Cart{}, false
+	}
+
+	return cart, true
+}
+
+//customerAccess reports whether the caller is the user customer or holds orders:manage
+func customerAccess(c *gin.Context, customer string) bool {
+	principal, ok := h.CurrentPrincipal(c)
+	if !ok {
+		return false
+	}
+	if principal.Can(h.PermOrdersManage) {
+		return true
+	}
+	return customer != "" && principal.Kind == h.PrincipalUser && principal.ID == customer
+}
+
+//cartProducts products of the cart items in the order of the items, soft deleted products included.
+//It writes the error response itself when it can't
+func cartProducts(ctx cfg.RepositoryContext, c *gin.Context, process string, cart tables.Cart) ([]tables.Product, bool) {
+	products := []tables.Product{}
+	for _, item := range cart.Items {
+		product, ok := findProduct(ctx, c, process, item.IDProduct, true)
+		if !ok {
+			return nil, false
+		}
+		products = append(products, product)
+	}
+	return products, true
+}
+
+//cartResponse items of the cart priced with the running promotions and coupon.
+//It writes the error response itself when it can't
+func cartResponse(ctx cfg.RepositoryContext, c *gin.Context, process string, cart tables.Cart, coupon string) (shared.Cart, bool) {
+	products, ok := cartProducts(ctx, c, process, cart)
+	if !ok {
+		return shared.Cart{}, false
+	}
+	pricer, ok := loadPricer(ctx, c, process, products, coupon)
+	if !ok {
+		return shared.Cart{}, false
+	}
+
+	items := []pricedItem{}
+	for i, item := range cart.Items {
+		items = append(items, pricedItem{product: products[i], unitPrice: products[i].Price, quantity: item.Quantity})
+	}
+	pricing := pricer.pricing(items)
+	return shared.Cart{
+		IDCart:      cart.IDCart,
+		Lines:       pricing.Lines,
+		Totals:      pricing.Totals,
+		UpdatedDate: cart.UpdatedDate.In(ctx.Config.App.Location),
+	}, true
+}
+
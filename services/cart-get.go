@@ -0,0 +1,26 @@
+package services
+
+import (
+	cfg "product-test/config"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+//GetCart cart priced with the running promotions and the optional ?coupon=
+func GetCart(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-cart|"
+
+		cart, ok := findCart(ctx, c, process, c.Param("id"))
+		if !ok {
+			return
+		}
+		data, ok := cartResponse(ctx, c, process, cart, c.Query("coupon"))
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, data)
+	}
+}
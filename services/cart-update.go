@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//SetCartItem sets the quantity of a product in the cart, the item is added when missing
+func SetCartItem(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|set-cart-item|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamCartItem{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+		input.IDProduct = c.Param("product_id")
+		if input.Quantity == 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   "quantity must be at least 1, remove the item instead",
+				Input:    input,
+			})
+			return
+		}
+
+		item, ok := validateCartItem(ctx, c, process, id, input)
+		if !ok {
+			return
+		}
+		item.CreatedDate = now
+		if err := ctx.Carts.SetItem(item); err != nil {
+			cartItemError(ctx, c, process, err, input)
+			return
+		}
+
+		cart, ok := findCart(ctx, c, process, id)
+		if !ok {
+			return
+		}
+		data, ok := cartResponse(ctx, c, process, cart, "")
+		if !ok {
+			return
+		}
+		h.GoodResponse(c, data)
+	}
+}
+
+func RemoveCartItem(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|remove-cart-item|"
+		id := c.Param("id")
+		productID := c.Param("product_id")
+
+		if _, ok := findCart(ctx, c, process, id); !ok {
+			return
+		}
+		if err := ctx.Carts.RemoveItem(id, productID, time.Now()); err != nil {
+			cartItemError(ctx, c, process, err, productID)
+			return
+		}
+
+		cart, ok := findCart(ctx, c, process, id)
+		if !ok {
+			return
+		}
+		data, ok := cartResponse(ctx, c, process, cart, "")
+		if !ok {
+			return
+		}
+		h.GoodResponse(c, data)
+	}
+}
+
+func DeleteCart(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|delete-cart|"
+		id := c.Param("id")
+
+		if _, ok := findCart(ctx, c, process, id); !ok {
+			return
+		}
+		if err := ctx.Carts.Delete(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				h.NotFoundResponse(h.RespParams{
+					Log:      ctx.Log,
+					Context:  c,
+					Severity: h.DEBUG,
+					Section:  process + "result",
+					Reason:   "cart not found",
+					Input:    id,
+				})
+				return
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, nil)
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	shared "product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AddCategory(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-category|"
+		now := time.Now()
+		input := shared.ParamCategory{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		if err := h.NameRule(input.Name); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "name-rule",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		category := tables.Category{
+			Name:        input.Name,
+			CreatedDate: now,
+			UpdatedDate: now,
+		}
+		if input.ParentID != "" {
+			category.ParentID = &input.ParentID
+		}
+		if err := ctx.Categories.Create(&category, ctx.IDGen.NewID); err != nil {
+			reason := err.Error()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				reason = "parent category not found"
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   reason,
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, categoryResponse(category))
+	}
+}
+
+//findCategory loads a category by id and writes the error response itself when it can't
+func findCategory(ctx cfg.RepositoryContext, c *gin.Context, process, id string) (tables.Category, bool) {
+	category, err := ctx.Categories.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "category not found",
+				Input:    id,
+			})
+			return tables.Category{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.Category{}, false
+	}
+
+	return category, true
+}
+
+func categoryResponse(row tables.Category) shared.Category {
+	return shared.Category{
+		IDCategory: row.IDCategory,
+		Name:       row.Name,
+		ParentID:   row.ParentID,
+		Children:   []shared.Category{},
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DeleteCategory(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|delete-category|"
+		id := c.Param("id")
+
+		if _, ok := findCategory(ctx, c, process, id); !ok {
+			return
+		}
+
+		if err := ctx.Categories.Delete(id); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrCategoryHasChildren) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, nil)
+	}
+}
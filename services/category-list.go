@@ -0,0 +1,96 @@
+package services
+
+import (
+	"net/http"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//CategoryList whole category tree, root categories with their children nested
+func CategoryList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|category-list|"
+
+		list, err := ctx.Categories.List()
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   categoryTree(list, nil),
+		})
+	}
+}
+
+//GetCategory single category with its sub tree
+func GetCategory(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-category|"
+		id := c.Param("id")
+
+		if _, ok := findCategory(ctx, c, process, id); !ok {
+			return
+		}
+
+		list, err := ctx.Categories.Descendants(id)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "descendants",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		//descendants are ordered by path, the first one is the category itself
+		node := categoryResponse(list[0])
+		node.Children = categoryTree(list[1:], &id)
+		h.GoodResponse(c, node)
+	}
+}
+
+//categoryTree nests the categories below parent (nil for the roots)
+func categoryTree(list []tables.Category, parent *string) []shared.Category {
+	children := map[string][]tables.Category{}
+	for _, row := range list {
+		key := ""
+		if row.ParentID != nil {
+			key = *row.ParentID
+		}
+		children[key] = append(children[key], row)
+	}
+
+	var build func(key string) []shared.Category
+	build = func(key string) []shared.Category {
+		nodes := []shared.Category{}
+		for _, row := range children[key] {
+			node := categoryResponse(row)
+			node.Children = build(row.IDCategory)
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+
+	if parent == nil {
+		return build("")
+	}
+	return build(*parent)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+	shared "product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//UpdateCategory renames or moves a category, an empty parent_id moves it to the root
+func UpdateCategory(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|update-category|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamCategory{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		category, ok := findCategory(ctx, c, process, id)
+		if !ok {
+			return
+		}
+
+		if err := h.NameRule(input.Name); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "name-rule",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		category.Name = input.Name
+		category.ParentID = nil
+		if input.ParentID != "" {
+			category.ParentID = &input.ParentID
+		}
+		category.UpdatedDate = now
+		if err := ctx.Categories.Update(&category); err != nil {
+			reason := err.Error()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				reason = "parent category not found"
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   reason,
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, categoryResponse(category))
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func ExchangeRateList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|exchange-rate-list|"
+
+		list, err := ctx.Currency.Rates()
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+			})
+			return
+		}
+
+		data := []shared.ExchangeRate{}
+		for _, row := range list {
+			data = append(data, exchangeRateResponse(ctx, row))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  len(data),
+		})
+	}
+}
+
+//SetExchangeRate sets the units of :quote bought by one unit of :base, products priced in :quote
+//are converted to :base with the inverse when no :base/:quote rate is set
+func SetExchangeRate(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|set-exchange-rate|"
+		now := time.Now()
+		input := shared.ParamExchangeRate{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		base, quote, ok := currencyPair(ctx, c, process)
+		if !ok {
+			return
+		}
+		rate, err := shared.ParseRate(string(input.Rate))
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		row := tables.ExchangeRate{Base: base, Quote: quote, Rate: rate.FloatString(12), UpdatedDate: now}
+		if err := ctx.Currency.SetRate(row); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, exchangeRateResponse(ctx, row))
+	}
+}
+
+func DeleteExchangeRate(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|delete-exchange-rate|"
+
+		base, quote, ok := currencyPair(ctx, c, process)
+		if !ok {
+			return
+		}
+
+		err := ctx.Currency.DeleteRate(base, quote)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "result",
+				Reason:   "exchange rate not found",
+				Input:    base + "/" + quote,
+			})
+			return
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    base + "/" + quote,
+			})
+			return
+		}
+
+		h.GoodResponse(c, base+"/"+quote)
+	}
+}
+
+//currencyPair reads the :base and :quote params, two different known currencies
+func currencyPair(ctx cfg.RepositoryContext, c *gin.Context, process string) (string, string, bool) {
+	base, err := shared.Currency(c.Param("base"))
+	quote, quoteErr := shared.Currency(c.Param("quote"))
+	if err == nil {
+		err = quoteErr
+	}
+	if err == nil && base == quote {
+		err = errors.New("base and quote currency must differ")
+	}
+	if err != nil {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "currency",
+			Reason:   err.Error(),
+			Input:    c.Param("base") + "/" + c.Param("quote"),
+		})
+		return "", "", false
+	}
+	return base, quote, true
+}
+
+func exchangeRateResponse(ctx cfg.RepositoryContext, row tables.ExchangeRate) shared.ExchangeRate {
+	return shared.ExchangeRate{
+		Base:        row.Base,
+		Quote:       row.Quote,
+		Rate:        row.Rate,
+		UpdatedDate: row.UpdatedDate.In(ctx.Config.App.Location),
+	}
+}
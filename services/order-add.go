@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//PlaceOrder places the items of a cart as a pending order of the caller priced with the running promotions and coupon,
+//the units are taken out of stock and the cart is deleted. Every product of an order must be priced in one currency
+func PlaceOrder(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|place-order|"
+		now := time.Now()
+		input := shared.ParamOrder{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		cart, ok := findCart(ctx, c, process, input.IDCart)
+		if !ok {
+			return
+		}
+		products, ok := cartProducts(ctx, c, process, cart)
+		if !ok {
+			return
+		}
+		if err := validateOrderProducts(products); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+		pricer, ok := loadPricer(ctx, c, process, products, input.Coupon)
+		if !ok {
+			return
+		}
+
+		principal, _ := h.CurrentPrincipal(c)
+		order := tables.Order{IDCustomer: principal.ID, Currency: products[0].Currency, CreatedDate: now}
+		if coupon := couponCode(input.Coupon); coupon != "" {
+			order.CouponCode = &coupon
+		}
+		for i, item := range cart.Items {
+			result := pricer.price(products[i], products[i].Price, item.Quantity)
+			line := tables.OrderLine{
+				IDProduct:   item.IDProduct,
+				ProductName: products[i].ProductName,
+				Quantity:    item.Quantity,
+				UnitPrice:   result.UnitPrice,
+				Discount:    result.Discount,
+				Total:       result.Total,
+			}
+			if p := result.Promotion; p != nil {
+				line.IDPromotion, line.PromotionName, line.PromotionKind = &p.IDPromotion, &p.Name, &p.Kind
+			}
+			order.Lines = append(order.Lines, line)
+			order.Subtotal += result.UnitPrice * result.Quantity
+			order.Discount += result.Discount
+			order.Total += result.Total
+		}
+
+		if err := ctx.Orders.Place(&order, cart.IDCart, h.CurrentActor(c), ctx.IDGen.NewID); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrInsufficientStock) || errors.Is(err, tables.ErrOrderPriceChanged) ||
+				errors.Is(err, tables.ErrProductUnavailable) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, orderResponse(ctx, order))
+	}
+}
+
+//validateOrderProducts an order needs at least one product, every one active and priced in the same currency
+func validateOrderProducts(products []tables.Product) error {
+	if len(products) == 0 {
+		return errors.New("cart is empty")
+	}
+	for _, p := range products {
+		if !p.Active {
+			return fmt.Errorf("product %s : %w", p.IDProduct, tables.ErrProductUnavailable)
+		}
+		if p.Currency != products[0].Currency {
+			return fmt.Errorf("products priced in %s and %s can't be in one order", products[0].Currency, p.Currency)
+		}
+	}
+	return nil
+}
+
+func orderResponse(ctx cfg.RepositoryContext, row tables.Order) shared.Order {
+	loc := ctx.Config.App.Location
+	data := shared.Order{
+		IDOrder:     row.IDOrder,
+		IDCustomer:  row.IDCustomer,
+		Status:      row.Status,
+		CouponCode:  row.CouponCode,
+		Lines:       []shared.OrderLine{},
+		Subtotal:    money(row.Subtotal, row.Currency),
+		Discount:    money(row.Discount, row.Currency),
+		Total:       money(row.Total, row.Currency),
+		CreatedDate: row.CreatedDate.In(loc),
+		PaidAt:      localTime(row.PaidDate, loc),
+		ShippedAt:   localTime(row.ShippedDate, loc),
+		CancelledAt: localTime(row.CancelledDate, loc),
+	}
+	for _, line := range row.Lines {
+		orderLine := shared.OrderLine{
+			IDProduct:   line.IDProduct,
+			ProductName: line.ProductName,
+			Quantity:    line.Quantity,
+			UnitPrice:   money(line.UnitPrice, row.Currency),
+			Subtotal:    money(line.UnitPrice*line.Quantity, row.Currency),
+			Discount:    money(line.Discount, row.Currency),
+			Total:       money(line.Total, row.Currency),
+		}
+		if line.IDPromotion != nil {
+			orderLine.Promotion = &shared.AppliedPromotion{IDPromotion: *line.IDPromotion}
+			if line.PromotionName != nil {
+				orderLine.Promotion.Name = *line.PromotionName
+			}
+			if line.PromotionKind != nil {
+				orderLine.Promotion.Kind = *line.PromotionKind
+			}
+		}
+		data.Lines = append(data.Lines, orderLine)
+	}
+	return data
+}
+
+func localTime(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(loc)
+	return &local
+}
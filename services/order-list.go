@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var orderStatuses = map[string]bool{
+	tables.OrderPending:   true,
+	tables.OrderPaid:      true,
+	tables.OrderShipped:   true,
+	tables.OrderCancelled: true,
+}
+
+//OrderList orders of the caller newest first, every customer's for orders:manage, ?status= keeps the orders in that status
+func OrderList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|order-list|"
+		status := c.Query("status")
+		if status != "" && !orderStatuses[status] {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "status",
+				Reason:   "status must be pending, paid, shipped or cancelled",
+				Input:    status,
+			})
+			return
+		}
+
+		page, err := queryInt(c, "page")
+		if err != nil || page < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "page",
+				Reason:   "page must be a positive number",
+				Input:    c.Query("page"),
+			})
+			return
+		}
+		size, err := queryInt(c, "size")
+		if err != nil || size < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "size",
+				Reason:   "size must be a positive number",
+				Input:    c.Query("size"),
+			})
+			return
+		}
+
+		customer := ""
+		if principal, _ := h.CurrentPrincipal(c); !principal.Can(h.PermOrdersManage) {
+			customer = principal.ID
+		}
+		list, total, err := ctx.Orders.List(customer, status, page, size)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+			})
+			return
+		}
+
+		data := []shared.Order{}
+		for _, row := range list {
+			data = append(data, orderResponse(ctx, row))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  total,
+		})
+	}
+}
+
+func GetOrder(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-order|"
+
+		order, ok := findOrder(ctx, c, process, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, orderResponse(ctx, order))
+	}
+}
+
+//findOrder loads an order of the caller and writes the error response itself when it can't,
+//the orders of other customers are answered like missing ones unless the caller holds orders:manage
+func findOrder(ctx cfg.RepositoryContext, c *gin.Context, process, id string) (tables.Order, bool) {
+	order, err := ctx.Orders.GetByID(id)
+	if err == nil && !customerAccess(c, order.IDCustomer) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "order not found",
+				Input:    id,
+			})
+			return tables.Order{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.Order{}, false
+	}
+	return order, true
+}
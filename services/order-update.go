@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//ShipOrder marks a paid order shipped
+func ShipOrder(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return moveOrder(ctx, "|services|ship-order|", tables.OrderShipped)
+}
+
+//CancelOrder cancels a pending or paid order of the caller, returns its units to stock and refunds a wallet payment
+func CancelOrder(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return moveOrder(ctx, "|services|cancel-order|", tables.OrderCancelled)
+}
+
+func moveOrder(ctx cfg.RepositoryContext, process, status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		if _, ok := findOrder(ctx, c, process, id); !ok {
+			return
+		}
+		order, err := ctx.Orders.Move(id, status, h.CurrentActor(c), time.Now(), ctx.IDGen.NewID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "result",
+				Reason:   "order not found",
+				Input:    id,
+			})
+			return
+		}
+		if err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrOrderTransition) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, orderResponse(ctx, order))
+	}
+}
@@ -5,11 +5,8 @@ import (
 	tables "product-test/database"
 	h "product-test/helpers"
 	shared "product-test/shared"
-	"strconv"
 	"time"
 
-	"product-test/tools"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,16 +14,15 @@ func AddProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		process := "|services|add-product|"
 		now := time.Now()
-		randNum := tools.RandomNumber(100000, 999999)
-		strRandNum := strconv.Itoa(randNum)
 		input := shared.ParamProduct{}
+		limitProductBody(ctx, c)
 		if err := c.Bind(&input); err != nil {
 			h.BadResponse(h.RespParams{
 				Log:      ctx.Log,
 				Context:  c,
 				Severity: h.DEBUG,
 				Section:  process + "bind",
-				Reason:   "missing input",
+				Reason:   productBindReason(ctx, err),
 			})
 			return
 		}
@@ -44,8 +40,25 @@ func AddProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
 			return
 		}
 
-		//price
-		if err := h.NotZero(input.Price, "price"); err != nil {
+		//price, in minor units of the product currency
+		currency := ctx.Config.Price.Currency
+		if input.Currency != "" {
+			currency = input.Currency
+		}
+		currency, err := shared.Currency(currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "currency",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+		price, err := parsePrice(input.Price, currency)
+		if err != nil {
 			h.BadResponse(h.RespParams{
 				Log:      ctx.Log,
 				Context:  c,
@@ -71,20 +84,59 @@ func AddProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
 		}
 
 		//quantity
-		if err := h.NotZero(input.Quantity, "quantity"); err != nil {
+		if input.Quantity <= 0 {
 			h.BadResponse(h.RespParams{
 				Log:      ctx.Log,
 				Context:  c,
 				Severity: h.DEBUG,
 				Section:  process + "harga-mustnotempty",
+				Reason:   "quantity must be greater than zero",
+				Input:    input,
+			})
+			return
+		}
+
+		//image, optional multipart file
+		image, err := readProductImage(ctx, c)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "image",
 				Reason:   err.Error(),
 				Input:    input,
 			})
 			return
 		}
 
-		product := tables.Product{}
-		if err := product.Create(ctx.DB, strRandNum, input.ProductName, input.Description, input.Price, input.Quantity, now, true); err != nil {
+		product := tables.Product{
+			ProductName: input.ProductName,
+			Price:       price,
+			Currency:    currency,
+			Description: input.Description,
+			Quantity:    input.Quantity,
+			CreatedDate: now,
+			Active:      true,
+		}
+		if image != nil {
+			key, err := storeProductImage(ctx, image)
+			if err != nil {
+				h.BadResponse(h.RespParams{
+					Log:      ctx.Log,
+					Context:  c,
+					Severity: h.ERROR,
+					Section:  process + "image-store",
+					Error:    err,
+					Reason:   err.Error(),
+					Input:    input,
+				})
+				return
+			}
+			product.ImageKey = key
+		}
+		if err := ctx.Products.Create(&product, ctx.IDGen.NewID); err != nil {
+			deleteProductImage(ctx, product.ImageKey)
 			h.BadResponse(h.RespParams{
 				Log:      ctx.Log,
 				Context:  c,
@@ -97,7 +149,20 @@ func AddProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
 			return
 		}
 
-		//Account Information
-		h.GoodResponse(c, nil)
+		data, err := productResponses(ctx, []tables.Product{product})
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "response",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, data[0])
 	}
 }
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//SetProductCategories replaces the categories a product belongs to
+func SetProductCategories(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|set-product-categories|"
+		id := c.Param("id")
+		input := shared.ParamProductCategories{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		if _, ok := findProduct(ctx, c, process, id, false); !ok {
+			return
+		}
+
+		if len(input.CategoryIDs) == 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "category-ids-mustnotempty",
+				Reason:   "category_ids is required, cannot be empty",
+				Input:    input,
+			})
+			return
+		}
+
+		ids := []string{}
+		seen := map[string]bool{}
+		for _, categoryID := range input.CategoryIDs {
+			if seen[categoryID] {
+				continue
+			}
+			seen[categoryID] = true
+			if _, err := ctx.Categories.GetByID(categoryID); err != nil {
+				reason := err.Error()
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					reason = "category " + categoryID + " not found"
+				}
+				h.BadResponse(h.RespParams{
+					Log:      ctx.Log,
+					Context:  c,
+					Severity: h.DEBUG,
+					Section:  process + "category",
+					Error:    err,
+					Reason:   reason,
+					Input:    input,
+				})
+				return
+			}
+			ids = append(ids, categoryID)
+		}
+
+		if err := ctx.Products.SetCategories(id, ids); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, ids)
+	}
+}
+
+func ProductCategories(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|product-categories|"
+		id := c.Param("id")
+
+		if _, ok := findProduct(ctx, c, process, id, includeInactive(c)); !ok {
+			return
+		}
+
+		ids, err := ctx.Products.CategoryIDs(id)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		data := []shared.Category{}
+		for _, categoryID := range ids {
+			category, err := ctx.Categories.GetByID(categoryID)
+			if err != nil {
+				continue
+			}
+			data = append(data, categoryResponse(category))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+		})
+	}
+}
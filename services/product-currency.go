@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//ProductPrices price of a product in its own currency and its price list
+func ProductPrices(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|product-prices|"
+		id := c.Param("id")
+
+		product, ok := findProduct(ctx, c, process, id, true)
+		if !ok {
+			return
+		}
+
+		list, err := ctx.Currency.Prices([]string{id})
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		data := shared.ProductPrices{IDProduct: id, Price: money(product.Price, product.Currency), Prices: []shared.Money{}}
+		for _, row := range list {
+			data.Prices = append(data.Prices, money(row.Price, row.Currency))
+		}
+		h.GoodResponse(c, data)
+	}
+}
+
+//SetProductPrice sets the price of a product in another currency, it wins over the exchange rate conversion
+func SetProductPrice(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|set-product-price|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamProductPrice{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		product, ok := findProduct(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		currency, err := shared.Currency(c.Param("currency"))
+		if err == nil && currency == product.Currency {
+			err = fmt.Errorf("%s is the product currency, update the product price instead", currency)
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "currency",
+				Reason:   err.Error(),
+				Input:    c.Param("currency"),
+			})
+			return
+		}
+		price, err := parsePrice(input.Price, currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		if err := ctx.Currency.SetPrice(tables.ProductPrice{IDProduct: id, Currency: currency, Price: price, UpdatedDate: now}); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, money(price, currency))
+	}
+}
+
+//DeleteProductPrice removes a price list entry, the currency falls back to the exchange rate conversion
+func DeleteProductPrice(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|delete-product-price|"
+		id := c.Param("id")
+
+		if _, ok := findProduct(ctx, c, process, id, true); !ok {
+			return
+		}
+
+		currency, err := shared.Currency(c.Param("currency"))
+		if err == nil {
+			err = ctx.Currency.DeletePrice(id, currency)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "result",
+				Reason:   "price not found",
+				Input:    c.Param("currency"),
+			})
+			return
+		}
+		if err != nil {
+			severity := h.ERROR
+			if errors.Is(err, shared.ErrUnknownCurrency) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    c.Param("currency"),
+			})
+			return
+		}
+
+		h.GoodResponse(c, currency)
+	}
+}
+
+//localCurrency reads the optional ?currency= of the product endpoints, an unknown code is answered with 400
+func localCurrency(ctx cfg.RepositoryContext, c *gin.Context, process string) (string, bool) {
+	raw := c.Query("currency")
+	if raw == "" {
+		return "", true
+	}
+	currency, err := shared.Currency(raw)
+	if err != nil {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "currency",
+			Reason:   err.Error(),
+			Input:    raw,
+		})
+		return "", false
+	}
+	return currency, true
+}
+
+//localPrices sets the local price of every product response, data must be in the order of rows.
+//Products without a price list entry or exchange rate for the currency are left without one
+func localPrices(ctx cfg.RepositoryContext, rows []tables.Product, data []shared.Product, currency string) error {
+	if currency == "" {
+		return nil
+	}
+	ids := []string{}
+	for _, row := range rows {
+		ids = append(ids, row.IDProduct)
+	}
+	converter, err := newPriceConverter(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if price, ok := converter.price(row, currency); ok {
+			data[i].LocalPrice = &price
+		}
+	}
+	return nil
+}
+
+//priceConverter price lists of a set of products and every exchange rate
+type priceConverter struct {
+	lists map[string]map[string]int
+	rates map[[2]string]*big.Rat
+}
+
+func newPriceConverter(ctx cfg.RepositoryContext, ids []string) (priceConverter, error) {
+	converter := priceConverter{lists: map[string]map[string]int{}, rates: map[[2]string]*big.Rat{}}
+	prices, err := ctx.Currency.Prices(ids)
+	if err != nil {
+		return converter, err
+	}
+	for _, p := range prices {
+		if converter.lists[p.IDProduct] == nil {
+			converter.lists[p.IDProduct] = map[string]int{}
+		}
+		converter.lists[p.IDProduct][p.Currency] = p.Price
+	}
+
+	rates, err := ctx.Currency.Rates()
+	if err != nil {
+		return converter, err
+	}
+	for _, r := range rates {
+		rate, err := shared.ParseRate(r.Rate)
+		if err != nil {
+			return converter, fmt.Errorf("exchange rate %s/%s : %w", r.Base, r.Quote, err)
+		}
+		converter.rates[[2]string{r.Base, r.Quote}] = rate
+	}
+	return converter, nil
+}
+
+//price of the product in currency, its price list entry first then its price converted with
+//the product currency to currency rate or the inverse of the opposite one
+func (pc priceConverter) price(p tables.Product, currency string) (shared.Money, bool) {
+	if p.Currency == currency {
+		return money(p.Price, currency), true
+	}
+	if price, ok := pc.lists[p.IDProduct][currency]; ok {
+		return money(price, currency), true
+	}
+	rate, ok := pc.rates[[2]string{p.Currency, currency}]
+	if !ok {
+		inverse, ok := pc.rates[[2]string{currency, p.Currency}]
+		if !ok {
+			return shared.Money{}, false
+		}
+		rate = new(big.Rat).Inv(inverse)
+	}
+	converted, err := money(p.Price, p.Currency).Convert(rate, currency)
+	return converted, err == nil
+}
+
+//money price in minor units of currency
+func money(amount int, currency string) shared.Money {
+	return shared.Money{Amount: int64(amount), Currency: currency}
+}
+
+//parsePrice positive price in major units of currency, returned in minor units
+func parsePrice(value shared.Decimal, currency string) (int, error) {
+	if err := h.MustNotEmpty(strings.TrimSpace(string(value)), "price"); err != nil {
+		return 0, err
+	}
+	m, err := shared.ParseMoney(value, currency)
+	if err != nil {
+		return 0, fmt.Errorf("price %w", err)
+	}
+	if m.Amount <= 0 {
+		return 0, errors.New("price must be greater than 0")
+	}
+	return int(m.Amount), nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"time"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DeleteProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|delete-product|"
+		now := time.Now()
+		id := c.Param("id")
+
+		product, ok := findProduct(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		if err := ctx.Products.SetActive(product.IDProduct, false, now); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, nil)
+	}
+}
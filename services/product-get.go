@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-product|"
+		id := c.Param("id")
+
+		currency, ok := localCurrency(ctx, c, process)
+		if !ok {
+			return
+		}
+		product, ok := findProduct(ctx, c, process, id, includeInactive(c))
+		if !ok {
+			return
+		}
+
+		data, err := productResponses(ctx, []tables.Product{product})
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "variants",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+		if err := localPrices(ctx, []tables.Product{product}, data, currency); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "local-price",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, data[0])
+	}
+}
+
+//findProduct loads a product by id and writes the error response itself when it can't,
+//inactive products are reported as not found unless includeInactive is set
+func findProduct(ctx cfg.RepositoryContext, c *gin.Context, process, id string, includeInactive bool) (tables.Product, bool) {
+	product, err := ctx.Products.GetByID(id)
+	if err == nil && !product.Active && !includeInactive {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "product not found",
+				Input:    id,
+			})
+			return tables.Product{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.Product{}, false
+	}
+
+	return product, true
+}
+
+//includeInactive reads the include_inactive query option used by admins to see soft deleted products,
+//callers without catalog:write never see them
+func includeInactive(c *gin.Context) bool {
+	return inactiveOption(c, h.PermCatalogWrite)
+}
+
+//inactiveOption include_inactive query option, only honoured when the principal holds permission
+func inactiveOption(c *gin.Context, permission string) bool {
+	principal, ok := h.CurrentPrincipal(c)
+	if !ok || !principal.Can(permission) {
+		return false
+	}
+	val, _ := strconv.ParseBool(c.Query("include_inactive"))
+	return val
+}
+
+func productResponse(ctx cfg.RepositoryContext, row tables.Product) shared.Product {
+	price := money(row.Price, row.Currency)
+	return shared.Product{
+		IDProduct:   row.IDProduct,
+		ProductName: row.ProductName,
+		Price:       price.Number(),
+		PriceMoney:  price,
+		Description: row.Description,
+		Quantity:    row.Quantity,
+		Active:      row.Active,
+		Image:       productImageURLs(ctx, row.ImageKey),
+
+		AvailableQuantity: row.Quantity,
+		ReorderThreshold:  row.ReorderThreshold,
+	}
+}
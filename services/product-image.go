@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	cfg "product-test/config"
+	fx "product-test/functions"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+//productImage image sent with the optional multipart "image" field of add/update product
+type productImage struct {
+	Data        []byte
+	ContentType string
+}
+
+//formOverhead room for the other form fields and the multipart framing next to the image
+const formOverhead = 1 << 20
+
+//limitProductBody caps the body of add/update product before the form is parsed, a bigger upload
+//fails while being read instead of being read or spooled to disk in full first
+func limitProductBody(ctx cfg.RepositoryContext, c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(ctx.Config.Storage.MaxImageSize)+formOverhead)
+}
+
+//productBindReason response reason of a failed bind, telling an upload over the limit apart
+func productBindReason(ctx cfg.RepositoryContext, err error) string {
+	if strings.Contains(err.Error(), "request body too large") {
+		return fmt.Sprintf("image is bigger than %d bytes", ctx.Config.Storage.MaxImageSize)
+	}
+	return "missing input"
+}
+
+//readProductImage reads and validates the optional image field, returns nil when no image was sent
+func readProductImage(ctx cfg.RepositoryContext, c *gin.Context) (*productImage, error) {
+	header, err := c.FormFile("image")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header.Size > int64(ctx.Config.Storage.MaxImageSize) {
+		return nil, fmt.Errorf("image is bigger than %d bytes", ctx.Config.Storage.MaxImageSize)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, err := fx.DetectImage(data)
+	if err != nil {
+		return nil, err
+	}
+	return &productImage{Data: data, ContentType: contentType}, nil
+}
+
+//storeProductImage uploads the original and its thumbnails under products/{id}/ and returns the key of the original
+func storeProductImage(ctx cfg.RepositoryContext, img *productImage) (string, error) {
+	thumbs, thumbType, err := fx.Thumbnails(img.Data, img.ContentType)
+	if err != nil {
+		return "", err
+	}
+	id, err := ctx.IDGen.NewID()
+	if err != nil {
+		return "", err
+	}
+
+	key := "products/" + id + "/original" + fx.ImageContentTypes[img.ContentType]
+	if err := ctx.Storage.Put(key, img.ContentType, img.Data); err != nil {
+		return "", err
+	}
+	for name, data := range thumbs {
+		thumbKey := path.Join(path.Dir(key), name+fx.ImageContentTypes[thumbType])
+		if err := ctx.Storage.Put(thumbKey, thumbType, data); err != nil {
+			deleteProductImage(ctx, key)
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+//imageKeys key of the original and of every thumbnail stored with it
+func imageKeys(key string) map[string]string {
+	thumbExt := fx.ImageContentTypes["image/jpeg"]
+	if path.Ext(key) == fx.ImageContentTypes["image/png"] {
+		thumbExt = fx.ImageContentTypes["image/png"]
+	}
+
+	keys := map[string]string{"original": key}
+	for name := range fx.ThumbnailSizes {
+		keys[name] = path.Join(path.Dir(key), name+thumbExt)
+	}
+	return keys
+}
+
+//deleteProductImage best effort removal of an image and its thumbnails, failures are only logged
+func deleteProductImage(ctx cfg.RepositoryContext, key string) {
+	if key == "" {
+		return
+	}
+	for _, k := range imageKeys(key) {
+		if err := ctx.Storage.Delete(k); err != nil {
+			ctx.Log.Warn("delete product image", zap.String("key", k), zap.Error(err))
+		}
+	}
+}
+
+//productImageURLs public url of the original and of every thumbnail, nil for a product without image
+func productImageURLs(ctx cfg.RepositoryContext, key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+	urls := map[string]string{}
+	for name, k := range imageKeys(key) {
+		urls[name] = ctx.Storage.URL(k)
+	}
+	return urls
+}
@@ -2,155 +2,131 @@ package services
 
 import (
 	"net/http"
+	"strconv"
+
 	cfg "product-test/config"
 	tables "product-test/database"
 	h "product-test/helpers"
-	"product-test/shared"
 
 	"github.com/gin-gonic/gin"
 )
 
+//sortModes sort order of every /list-product/:sort mode, kept as alias of the sort query
+var sortModes = map[string][]tables.SortField{
+	"new":  {{Column: "created_datetime", Desc: true}},
+	"high": {{Column: "price", Desc: true}},
+	"low":  {{Column: "price"}},
+	"a-z":  {{Column: "product_name"}},
+	"z-a":  {{Column: "product_name", Desc: true}},
+}
+
+//ProductList lists products, e.g. /list-product?sort=-price,product_name&price_gte=1000&quantity_gt=0 (price in major units
+//of the default currency), category_id keeps products of that category and all of its sub categories
 func ProductList(ctx cfg.RepositoryContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		process := "|services|product-list|"
-		p := tables.Product{}
-		sort := c.Param("sort")
 
-		if sort == "new" {
-			list, err := p.ProductListTime(ctx.DB)
-			if err != nil {
-				h.BadResponse(h.RespParams{
-					Log:      ctx.Log,
-					Context:  c,
-					Severity: h.DEBUG,
-					Section:  process + "bind",
-					Reason:   "missing input",
+		if mode := c.Param("sort"); mode != "" {
+			if _, ok := sortModes[mode]; !ok {
+				c.JSON(http.StatusOK, gin.H{
+					"status": false,
+					"data":   nil,
 				})
 				return
 			}
-			data := []shared.Product{}
-			for _, row := range list {
-				data = append(data, shared.Product{
-					IDProduct:   row.IDProduct,
-					ProductName: row.ProductName,
-					Price:       row.Price,
-					Description: row.Description,
-					Quantity:    row.Quantity,
-				})
-			}
-			c.JSON(http.StatusOK, gin.H{
-				"status": true,
-				"data":   data,
-			})
-		} else if sort == "high" {
-			list, err := p.ProductPriceHigh(ctx.DB)
-			if err != nil {
-				h.BadResponse(h.RespParams{
-					Log:      ctx.Log,
-					Context:  c,
-					Severity: h.DEBUG,
-					Section:  process + "bind",
-					Reason:   "missing input",
-				})
-				return
-			}
-			data := []shared.Product{}
-			for _, row := range list {
-				data = append(data, shared.Product{
-					IDProduct:   row.IDProduct,
-					ProductName: row.ProductName,
-					Price:       row.Price,
-					Description: row.Description,
-					Quantity:    row.Quantity,
-				})
-			}
-			c.JSON(http.StatusOK, gin.H{
-				"status": true,
-				"data":   data,
-			})
-		} else if sort == "low" {
-			list, err := p.ProductPriceLow(ctx.DB)
-			if err != nil {
-				h.BadResponse(h.RespParams{
-					Log:      ctx.Log,
-					Context:  c,
-					Severity: h.DEBUG,
-					Section:  process + "bind",
-					Reason:   "missing input",
-				})
-				return
-			}
-			data := []shared.Product{}
-			for _, row := range list {
-				data = append(data, shared.Product{
-					IDProduct:   row.IDProduct,
-					ProductName: row.ProductName,
-					Price:       row.Price,
-					Description: row.Description,
-					Quantity:    row.Quantity,
-				})
-			}
-			c.JSON(http.StatusOK, gin.H{
-				"status": true,
-				"data":   data,
+		}
+
+		currency, ok := localCurrency(ctx, c, process)
+		if !ok {
+			return
+		}
+
+		query, err := parseProductQuery(c, ctx.Config.Price.Currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "query",
+				Reason:   err.Error(),
+				Input:    c.Request.URL.RawQuery,
 			})
-		} else if sort == "a-z" {
-			list, err := p.ProductNameAZ(ctx.DB)
+			return
+		}
+
+		if categoryID := c.Query("category_id"); categoryID != "" {
+			categories, err := ctx.Categories.Descendants(categoryID)
 			if err != nil {
 				h.BadResponse(h.RespParams{
 					Log:      ctx.Log,
 					Context:  c,
 					Severity: h.DEBUG,
-					Section:  process + "bind",
-					Reason:   "missing input",
+					Section:  process + "category",
+					Error:    err,
+					Reason:   "category not found",
+					Input:    categoryID,
 				})
 				return
 			}
-			data := []shared.Product{}
-			for _, row := range list {
-				data = append(data, shared.Product{
-					IDProduct:   row.IDProduct,
-					ProductName: row.ProductName,
-					Price:       row.Price,
-					Description: row.Description,
-					Quantity:    row.Quantity,
-				})
+			for _, category := range categories {
+				query.CategoryIDs = append(query.CategoryIDs, category.IDCategory)
 			}
-			c.JSON(http.StatusOK, gin.H{
-				"status": true,
-				"data":   data,
+		}
+
+		page, err := ctx.Products.List(query)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    query,
 			})
-		} else if sort == "z-a" {
-			list, err := p.ProductNameZA(ctx.DB)
-			if err != nil {
-				h.BadResponse(h.RespParams{
-					Log:      ctx.Log,
-					Context:  c,
-					Severity: h.DEBUG,
-					Section:  process + "bind",
-					Reason:   "missing input",
-				})
-				return
-			}
-			data := []shared.Product{}
-			for _, row := range list {
-				data = append(data, shared.Product{
-					IDProduct:   row.IDProduct,
-					ProductName: row.ProductName,
-					Price:       row.Price,
-					Description: row.Description,
-					Quantity:    row.Quantity,
-				})
-			}
-			c.JSON(http.StatusOK, gin.H{
-				"status": true,
-				"data":   data,
+			return
+		}
+
+		data, err := productResponses(ctx, page.Products)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "variants",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    query,
 			})
-		} else {
-			c.JSON(http.StatusOK, gin.H{
-				"status": false,
-				"data":   nil,
+			return
+		}
+		if err := localPrices(ctx, page.Products, data, currency); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "local-price",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    query,
 			})
+			return
 		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":      true,
+			"data":        data,
+			"total":       page.Total,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
+		})
+	}
+}
+
+//queryInt reads an optional numeric query parameter, missing value is 0
+func queryInt(c *gin.Context, key string) (int, error) {
+	val := c.Query(key)
+	if val == "" {
+		return 0, nil
 	}
+	return strconv.Atoi(val)
 }
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//scheduleLayouts accepted layouts of a schedule time without offset, read in the app timezone
+var scheduleLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+//PriceHistory price changes of a product, newest first
+func PriceHistory(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|price-history|"
+		id := c.Param("id")
+
+		page, err := queryInt(c, "page")
+		if err != nil || page < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "page",
+				Reason:   "page must be a positive number",
+				Input:    c.Query("page"),
+			})
+			return
+		}
+		size, err := queryInt(c, "size")
+		if err != nil || size < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "size",
+				Reason:   "size must be a positive number",
+				Input:    c.Query("size"),
+			})
+			return
+		}
+
+		product, ok := findProduct(ctx, c, process, id, true)
+		if !ok {
+			return
+		}
+
+		list, total, err := ctx.Prices.History(id, page, size)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		data := []shared.PriceChange{}
+		for _, row := range list {
+			oldPrice, newPrice := money(row.OldPrice, product.Currency), money(row.NewPrice, product.Currency)
+			data = append(data, shared.PriceChange{
+				IDProduct:     row.IDProduct,
+				OldPrice:      oldPrice.Number(),
+				NewPrice:      newPrice.Number(),
+				OldPriceMoney: oldPrice,
+				NewPriceMoney: newPrice,
+				Source:        row.Source,
+				IDSchedule:    row.IDSchedule,
+				ChangedAt:     row.ChangedAt.In(ctx.Config.App.Location),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  total,
+		})
+	}
+}
+
+//AddPriceSchedule schedules a future price of a product, it can't overlap another pending or active schedule
+func AddPriceSchedule(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-price-schedule|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamPriceSchedule{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		product, ok := findProduct(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		schedule, err := validatePriceSchedule(input, product.Currency, ctx.Config.App.Location, now)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		schedule.IDProduct = id
+		schedule.CreatedDate = now
+		schedule.UpdatedDate = now
+		if err := ctx.Prices.CreateSchedule(&schedule, ctx.IDGen.NewID); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrScheduleOverlap) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, priceScheduleResponse(ctx, schedule, product.Currency))
+	}
+}
+
+//PriceScheduleList every price schedule of a product ordered by effective_from
+func PriceScheduleList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|price-schedule-list|"
+		id := c.Param("id")
+
+		product, ok := findProduct(ctx, c, process, id, true)
+		if !ok {
+			return
+		}
+
+		list, err := ctx.Prices.Schedules(id)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		data := []shared.PriceSchedule{}
+		for _, row := range list {
+			data = append(data, priceScheduleResponse(ctx, row, product.Currency))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  len(data),
+		})
+	}
+}
+
+//CancelPriceSchedule cancels a schedule not started yet
+func CancelPriceSchedule(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|cancel-price-schedule|"
+		id := c.Param("id")
+
+		schedule, err := ctx.Prices.CancelSchedule(id, time.Now())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "result",
+				Reason:   "price schedule not found",
+				Input:    id,
+			})
+			return
+		}
+		if err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrScheduleNotPending) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		product, err := ctx.Products.GetByID(schedule.IDProduct)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "product",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, priceScheduleResponse(ctx, schedule, product.Currency))
+	}
+}
+
+//validatePriceSchedule schedule of the input with its price in minor units of currency
+func validatePriceSchedule(input shared.ParamPriceSchedule, currency string, loc *time.Location, now time.Time) (tables.PriceSchedule, error) {
+	price, err := parsePrice(input.Price, currency)
+	if err != nil {
+		return tables.PriceSchedule{}, err
+	}
+	from, err := parseScheduleTime(input.EffectiveFrom, loc)
+	if err != nil {
+		return tables.PriceSchedule{}, fmt.Errorf("effective_from %w", err)
+	}
+	if !from.After(now) {
+		return tables.PriceSchedule{}, errors.New("effective_from must be in the future")
+	}
+
+	schedule := tables.PriceSchedule{Price: price, EffectiveFrom: from}
+	if strings.TrimSpace(input.EffectiveTo) != "" {
+		to, err := parseScheduleTime(input.EffectiveTo, loc)
+		if err != nil {
+			return tables.PriceSchedule{}, fmt.Errorf("effective_to %w", err)
+		}
+		if !to.After(from) {
+			return tables.PriceSchedule{}, errors.New("effective_to must be after effective_from")
+		}
+		schedule.EffectiveTo = &to
+	}
+	return schedule, nil
+}
+
+//parseScheduleTime RFC3339 keeps its own offset, the other layouts are read in loc
+func parseScheduleTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("can't be empty")
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range scheduleLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("must be a time like 2006-01-02 15:04:05 or RFC3339")
+}
+
+//priceScheduleResponse currency of the schedule product
+func priceScheduleResponse(ctx cfg.RepositoryContext, row tables.PriceSchedule, currency string) shared.PriceSchedule {
+	price := money(row.Price, currency)
+	data := shared.PriceSchedule{
+		IDSchedule:    row.IDSchedule,
+		IDProduct:     row.IDProduct,
+		Price:         price.Number(),
+		PriceMoney:    price,
+		EffectiveFrom: row.EffectiveFrom.In(ctx.Config.App.Location),
+		Status:        row.Status,
+	}
+	if row.PreviousPrice != nil {
+		previous := money(*row.PreviousPrice, currency)
+		number := previous.Number()
+		data.PreviousPrice, data.PreviousPriceMoney = &number, &previous
+	}
+	if row.EffectiveTo != nil {
+		to := row.EffectiveTo.In(ctx.Config.App.Location)
+		data.EffectiveTo = &to
+	}
+	return data
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tables "product-test/database"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//defaultSort sort used by /list-product when no sort is given
+const defaultSort = "-created_datetime"
+
+//filterAliases friendlier names for common filters
+var filterAliases = map[string][2]string{
+	"created_after":  {"created_datetime", "gt"},
+	"created_before": {"created_datetime", "lt"},
+}
+
+//filterSuffixes suffix of a <column>_<operator> query key, longest first so _gte wins over _gt
+var filterSuffixes = []string{"gte", "lte", "gt", "lt", "eq", "ne"}
+
+//parseProductQuery reads sort, filters and pagination of the list endpoint.
+//Filters use <column>_<operator> keys (price_gte=1000), unknown columns are rejected.
+//Price filters take major units of currency_eq or else of currency and keep the products priced in it
+func parseProductQuery(c *gin.Context, currency string) (tables.ProductQuery, error) {
+	handleErr := func(err error) (tables.ProductQuery, error) {
+		return tables.ProductQuery{}, err
+	}
+
+	query := tables.ProductQuery{
+		IncludeInactive: includeInactive(c),
+		Cursor:          c.Query("cursor"),
+		WarehouseID:     c.Query("warehouse_id"),
+	}
+
+	var err error
+	if query.Page, err = queryInt(c, "page"); err != nil || query.Page < 0 {
+		return handleErr(fmt.Errorf("page must be a positive number"))
+	}
+	if query.Size, err = queryInt(c, "size"); err != nil || query.Size < 0 {
+		return handleErr(fmt.Errorf("size must be a positive number"))
+	}
+
+	if mode := c.Param("sort"); mode != "" {
+		sort, ok := sortModes[mode]
+		if !ok {
+			return handleErr(fmt.Errorf("unknown sort %s", mode))
+		}
+		query.Sort = sort
+	} else {
+		raw := c.DefaultQuery("sort", defaultSort)
+		if query.Sort, err = tables.ParseProductSort(raw); err != nil {
+			return handleErr(err)
+		}
+	}
+
+	prices := []tables.ProductFilter{}
+	for key, values := range c.Request.URL.Query() {
+		column, operator, ok := filterKey(key)
+		if !ok {
+			continue
+		}
+		for _, raw := range values {
+			if column == "price" {
+				prices = append(prices, tables.ProductFilter{Column: column, Operator: operator, Value: raw})
+				continue
+			}
+			f, err := tables.ParseProductFilter(column, operator, raw)
+			if err != nil {
+				return handleErr(err)
+			}
+			query.Filters = append(query.Filters, f)
+		}
+	}
+	if len(prices) == 0 {
+		return query, nil
+	}
+
+	narrowed := false
+	for _, f := range query.Filters {
+		if f.Column == "currency" && f.Operator == "eq" {
+			currency, narrowed = f.Value.(string), true
+		}
+	}
+	for _, f := range prices {
+		m, err := shared.ParseMoney(shared.Decimal(f.Value.(string)), currency)
+		if err != nil {
+			return handleErr(fmt.Errorf("price %w", err))
+		}
+		if f, err = tables.ParseProductFilter(f.Column, f.Operator, strconv.FormatInt(m.Amount, 10)); err != nil {
+			return handleErr(err)
+		}
+		query.Filters = append(query.Filters, f)
+	}
+	if !narrowed {
+		query.Filters = append(query.Filters, tables.ProductFilter{Column: "currency", Operator: "eq", Value: currency})
+	}
+	return query, nil
+}
+
+func filterKey(key string) (string, string, bool) {
+	if alias, ok := filterAliases[key]; ok {
+		return alias[0], alias[1], true
+	}
+	for _, suffix := range filterSuffixes {
+		if strings.HasSuffix(key, "_"+suffix) {
+			return strings.TrimSuffix(key, "_"+suffix), suffix, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RestoreProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|restore-product|"
+		now := time.Now()
+		id := c.Param("id")
+
+		product, ok := findProduct(ctx, c, process, id, true)
+		if !ok {
+			return
+		}
+
+		if product.Active {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "active",
+				Reason:   "product is not deleted",
+				Input:    id,
+			})
+			return
+		}
+
+		if err := ctx.Products.SetActive(id, true, now); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		product.Active = true
+		product.UpdatedDate = now
+		data, err := productResponses(ctx, []tables.Product{product})
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "response",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, data[0])
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SearchProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|search-product|"
+		text := strings.TrimSpace(c.Query("q"))
+
+		if tables.PrefixTSQuery(text) == "" {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "q-mustnotempty",
+				Reason:   "q is required, cannot be empty",
+				Input:    text,
+			})
+			return
+		}
+
+		currency, ok := localCurrency(ctx, c, process)
+		if !ok {
+			return
+		}
+
+		page, err := queryInt(c, "page")
+		if err != nil || page < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "page",
+				Reason:   "page must be a positive number",
+				Input:    c.Query("page"),
+			})
+			return
+		}
+		size, err := queryInt(c, "size")
+		if err != nil || size < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "size",
+				Reason:   "size must be a positive number",
+				Input:    c.Query("size"),
+			})
+			return
+		}
+
+		list, total, err := ctx.Products.Search(text, includeInactive(c), page, size)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    text,
+			})
+			return
+		}
+
+		rows := []tables.Product{}
+		for _, row := range list {
+			rows = append(rows, row.Product)
+		}
+		products, err := productResponses(ctx, rows)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "response",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    text,
+			})
+			return
+		}
+
+		if err := localPrices(ctx, rows, products, currency); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "local-price",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    text,
+			})
+			return
+		}
+
+		data := []shared.ProductSearchResult{}
+		for i, row := range list {
+			data = append(data, shared.ProductSearchResult{
+				Product: products[i],
+				Rank:    row.Rank,
+				Highlight: shared.ProductHighlight{
+					ProductName: row.NameHighlight,
+					Description: row.DescriptionHighlight,
+				},
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  total,
+		})
+	}
+}
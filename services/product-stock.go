@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//AdjustStock records a stock movement and applies it to the product quantity
+func AdjustStock(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|adjust-stock|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamStock{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+		change, err := tables.MovementChange(input.Type, input.Quantity)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		if _, ok := findProduct(ctx, c, process, id, false); !ok {
+			return
+		}
+
+		movement := tables.StockMovement{
+			IDProduct:      id,
+			IDWarehouse:    input.WarehouseID,
+			MovementType:   input.Type,
+			QuantityChange: change,
+			Reason:         input.Reason,
+			Actor:          h.CurrentActor(c),
+			CreatedDate:    now,
+		}
+		if ok := adjustStock(ctx, c, process, &movement, input); !ok {
+			return
+		}
+
+		h.GoodResponse(c, stockMovementResponse(movement))
+	}
+}
+
+//StockHistory movements of a product newest first, paged with page and size
+func StockHistory(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|stock-history|"
+		id := c.Param("id")
+
+		page, err := queryInt(c, "page")
+		if err != nil || page < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "page",
+				Reason:   "page must be a positive number",
+				Input:    c.Query("page"),
+			})
+			return
+		}
+		size, err := queryInt(c, "size")
+		if err != nil || size < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "size",
+				Reason:   "size must be a positive number",
+				Input:    c.Query("size"),
+			})
+			return
+		}
+
+		if _, ok := findProduct(ctx, c, process, id, true); !ok {
+			return
+		}
+
+		list, total, err := ctx.Stock.History(id, page, size)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		data := []shared.StockMovement{}
+		for _, row := range list {
+			data = append(data, stockMovementResponse(row))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  total,
+		})
+	}
+}
+
+//TransferStock moves stock of a product between two warehouses, the product total stays the same
+func TransferStock(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|transfer-stock|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamStockTransfer{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		if err := validateTransfer(input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		if _, ok := findProduct(ctx, c, process, id, false); !ok {
+			return
+		}
+
+		transfer := tables.StockTransfer{
+			IDProduct:     id,
+			FromWarehouse: input.FromWarehouse,
+			ToWarehouse:   input.ToWarehouse,
+			Quantity:      input.Quantity,
+			Reason:        input.Reason,
+			Actor:         h.CurrentActor(c),
+			CreatedDate:   now,
+		}
+		list, err := ctx.Stock.Transfer(transfer, ctx.IDGen.NewID)
+		if err != nil {
+			stockError(ctx, c, process+"stock-transfer", err, input)
+			return
+		}
+
+		data := []shared.StockMovement{}
+		for _, row := range list {
+			data = append(data, stockMovementResponse(row))
+		}
+		h.GoodResponse(c, data)
+	}
+}
+
+func validateTransfer(input shared.ParamStockTransfer) error {
+	if err := h.MustNotEmpty(input.FromWarehouse, "from_warehouse"); err != nil {
+		return err
+	}
+	if err := h.MustNotEmpty(input.ToWarehouse, "to_warehouse"); err != nil {
+		return err
+	}
+	if input.FromWarehouse == input.ToWarehouse {
+		return errors.New("from_warehouse and to_warehouse must differ")
+	}
+	if input.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	return nil
+}
+
+//adjustStock applies the movement and writes the error response itself when it can't
+func adjustStock(ctx cfg.RepositoryContext, c *gin.Context, process string, movement *tables.StockMovement, input interface{}) bool {
+	err := ctx.Stock.Adjust(movement, ctx.IDGen.NewID)
+	if err != nil {
+		stockError(ctx, c, process+"stock-adjust", err, input)
+		return false
+	}
+	return true
+}
+
+//stockError writes the response of a failed stock change
+func stockError(ctx cfg.RepositoryContext, c *gin.Context, section string, err error, input interface{}) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		h.NotFoundResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  section,
+			Reason:   "product or warehouse not found",
+			Input:    input,
+		})
+	case errors.Is(err, tables.ErrInsufficientStock):
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  section,
+			Reason:   err.Error(),
+			Input:    input,
+		})
+	default:
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  section,
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    input,
+		})
+	}
+}
+
+func stockMovementResponse(row tables.StockMovement) shared.StockMovement {
+	return shared.StockMovement{
+		IDMovement:     row.IDMovement,
+		IDProduct:      row.IDProduct,
+		IDWarehouse:    row.IDWarehouse,
+		Type:           row.MovementType,
+		QuantityChange: row.QuantityChange,
+		QuantityAfter:  row.QuantityAfter,
+		Reason:         row.Reason,
+		Actor:          row.Actor,
+		CreatedDate:    row.CreatedDate,
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//SetReorderThreshold sets the quantity below which a low stock alert is mailed, 0 disables it
+func SetReorderThreshold(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|set-reorder-threshold|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamReorderThreshold{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		if input.ReorderThreshold < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   "reorder_threshold can't be negative",
+				Input:    input,
+			})
+			return
+		}
+
+		product, ok := findProduct(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		if err := ctx.LowStock.SetThreshold(id, input.ReorderThreshold, now); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+		product.ReorderThreshold = input.ReorderThreshold
+		product.UpdatedDate = now
+
+		data, err := productResponses(ctx, []tables.Product{product})
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "response",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, data[0])
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	shared "product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//UpdateProduct handles both PUT and PATCH, on PATCH empty fields keep their stored value.
+//The quantity only changes through the stock ledger of AdjustStock, it's left out or sent unchanged
+func UpdateProduct(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|update-product|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamProduct{}
+		limitProductBody(ctx, c)
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   productBindReason(ctx, err),
+			})
+			return
+		}
+
+		product, ok := findProduct(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		if c.Request.Method == http.MethodPatch {
+			if input.ProductName == "" {
+				input.ProductName = product.ProductName
+			}
+			if input.Price == "" {
+				input.Price = shared.Decimal(money(product.Price, product.Currency).String())
+			}
+			if input.Description == "" {
+				input.Description = product.Description
+			}
+		}
+
+		if input.Quantity != 0 && input.Quantity != product.Quantity {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "quantity",
+				Reason:   "quantity can't be updated, use POST /services/product/" + id + "/stock",
+				Input:    input,
+			})
+			return
+		}
+		input.Quantity = product.Quantity
+
+		price, err := validateProduct(input, product.Currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		//image, optional multipart file
+		image, err := readProductImage(ctx, c)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "image",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		oldImageKey := product.ImageKey
+		if image != nil {
+			key, err := storeProductImage(ctx, image)
+			if err != nil {
+				h.BadResponse(h.RespParams{
+					Log:      ctx.Log,
+					Context:  c,
+					Severity: h.ERROR,
+					Section:  process + "image-store",
+					Error:    err,
+					Reason:   err.Error(),
+					Input:    input,
+				})
+				return
+			}
+			product.ImageKey = key
+		}
+
+		product.ProductName = input.ProductName
+		product.Price = price
+		product.Description = input.Description
+		product.UpdatedDate = now
+		if err := ctx.Products.Update(&product); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			if product.ImageKey != oldImageKey {
+				deleteProductImage(ctx, product.ImageKey)
+			}
+			return
+		}
+		if product.ImageKey != oldImageKey {
+			deleteProductImage(ctx, oldImageKey)
+		}
+
+		data, err := productResponses(ctx, []tables.Product{product})
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "response",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, data[0])
+	}
+}
+
+//validateProduct returns the price in minor units of currency, the currency of a product can't change
+func validateProduct(input shared.ParamProduct, currency string) (int, error) {
+	if err := h.MustNotEmpty(input.ProductName, "product-name"); err != nil {
+		return 0, err
+	}
+	if input.Currency != "" && !strings.EqualFold(strings.TrimSpace(input.Currency), currency) {
+		return 0, fmt.Errorf("currency can't be changed from %s, use the product price list for other currencies", currency)
+	}
+	price, err := parsePrice(input.Price, currency)
+	if err != nil {
+		return 0, err
+	}
+	if err := h.MustNotEmpty(input.Description, "description"); err != nil {
+		return 0, err
+	}
+	return price, nil
+}
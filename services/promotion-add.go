@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AddPromotion(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-promotion|"
+		now := time.Now()
+		input := shared.ParamPromotion{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		promotion, err := validatePromotion(ctx, input, now)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		promotion.CreatedDate = now
+		promotion.UpdatedDate = now
+		if err := ctx.Promotions.Create(&promotion, ctx.IDGen.NewID); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateCoupon) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, promotionResponse(ctx, promotion))
+	}
+}
+
+//validatePromotion promotion of the input, its targets must exist
+func validatePromotion(ctx cfg.RepositoryContext, input shared.ParamPromotion, now time.Time) (tables.Promotion, error) {
+	handleErr := func(err error) (tables.Promotion, error) {
+		return tables.Promotion{}, err
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if err := h.MustNotEmpty(input.Name, "name"); err != nil {
+		return handleErr(err)
+	}
+	if len(input.Name) > 100 {
+		return handleErr(errors.New("name need at most 100 characters"))
+	}
+
+	promotion := tables.Promotion{Name: input.Name, Kind: strings.ToLower(strings.TrimSpace(input.Kind)), Active: true}
+	switch promotion.Kind {
+	case tables.PromotionPercentage:
+		if input.Percent < 1 || input.Percent > 100 {
+			return handleErr(errors.New("percent must be between 1 and 100"))
+		}
+		promotion.Value = input.Percent
+	case tables.PromotionFixed:
+		currency, err := shared.Currency(input.Currency)
+		if err != nil {
+			return handleErr(err)
+		}
+		amount, err := parsePrice(input.Amount, currency)
+		if err != nil {
+			return handleErr(fmt.Errorf("amount : %w", err))
+		}
+		promotion.Value = amount
+		promotion.Currency = currency
+	case tables.PromotionBuyXGetY:
+		if input.BuyQuantity < 1 || input.GetQuantity < 1 {
+			return handleErr(errors.New("buy_quantity and get_quantity must be at least 1"))
+		}
+		promotion.BuyQuantity = input.BuyQuantity
+		promotion.GetQuantity = input.GetQuantity
+	default:
+		return handleErr(fmt.Errorf("kind must be one of %s, %s or %s", tables.PromotionPercentage, tables.PromotionFixed, tables.PromotionBuyXGetY))
+	}
+
+	if coupon := couponCode(input.CouponCode); coupon != "" {
+		if len(coupon) > 50 {
+			return handleErr(errors.New("coupon_code need at most 50 characters"))
+		}
+		promotion.CouponCode = &coupon
+	}
+
+	promotion.StartsAt = now
+	if strings.TrimSpace(input.StartsAt) != "" {
+		startsAt, err := parseScheduleTime(input.StartsAt, ctx.Config.App.Location)
+		if err != nil {
+			return handleErr(fmt.Errorf("starts_at %w", err))
+		}
+		promotion.StartsAt = startsAt
+	}
+	if strings.TrimSpace(input.EndsAt) != "" {
+		endsAt, err := parseScheduleTime(input.EndsAt, ctx.Config.App.Location)
+		if err != nil {
+			return handleErr(fmt.Errorf("ends_at %w", err))
+		}
+		if !endsAt.After(promotion.StartsAt) {
+			return handleErr(errors.New("ends_at must be after starts_at"))
+		}
+		promotion.EndsAt = &endsAt
+	}
+	if input.Active != nil {
+		promotion.Active = *input.Active
+	}
+
+	seen := map[string]bool{}
+	for _, id := range input.ProductIDs {
+		if seen["p"+id] {
+			continue
+		}
+		seen["p"+id] = true
+		if _, err := ctx.Products.GetByID(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				err = fmt.Errorf("product %s not found", id)
+			}
+			return handleErr(err)
+		}
+		promotion.Targets = append(promotion.Targets, tables.PromotionTarget{TargetType: tables.TargetProduct, IDTarget: id})
+	}
+	for _, id := range input.CategoryIDs {
+		if seen["c"+id] {
+			continue
+		}
+		seen["c"+id] = true
+		if _, err := ctx.Categories.GetByID(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				err = fmt.Errorf("category %s not found", id)
+			}
+			return handleErr(err)
+		}
+		promotion.Targets = append(promotion.Targets, tables.PromotionTarget{TargetType: tables.TargetCategory, IDTarget: id})
+	}
+
+	return promotion, nil
+}
+
+//couponCode coupon codes are matched case insensitively
+func couponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+//findPromotion loads a promotion by id and writes the error response itself when it can't
+func findPromotion(ctx cfg.RepositoryContext, c *gin.Context, process, id string) (tables.Promotion, bool) {
+	promotion, err := ctx.Promotions.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "promotion not found",
+				Input:    id,
+			})
+			return tables.Promotion{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.Promotion{}, false
+	}
+
+	return promotion, true
+}
+
+func promotionResponse(ctx cfg.RepositoryContext, row tables.Promotion) shared.Promotion {
+	data := shared.Promotion{
+		IDPromotion: row.IDPromotion,
+		Name:        row.Name,
+		Kind:        row.Kind,
+		BuyQuantity: row.BuyQuantity,
+		GetQuantity: row.GetQuantity,
+		CouponCode:  row.CouponCode,
+		StartsAt:    row.StartsAt.In(ctx.Config.App.Location),
+		Active:      row.Active,
+		ProductIDs:  []string{},
+		CategoryIDs: []string{},
+	}
+	switch row.Kind {
+	case tables.PromotionPercentage:
+		data.Percent = row.Value
+	case tables.PromotionFixed:
+		amount := money(row.Value, row.Currency)
+		data.Amount = &amount
+	}
+	if row.EndsAt != nil {
+		endsAt := row.EndsAt.In(ctx.Config.App.Location)
+		data.EndsAt = &endsAt
+	}
+	for _, t := range row.Targets {
+		if t.TargetType == tables.TargetProduct {
+			data.ProductIDs = append(data.ProductIDs, t.IDTarget)
+		} else {
+			data.CategoryIDs = append(data.CategoryIDs, t.IDTarget)
+		}
+	}
+	return data
+}
+
+func appliedPromotion(row *tables.Promotion) *shared.AppliedPromotion {
+	if row == nil {
+		return nil
+	}
+	return &shared.AppliedPromotion{
+		IDPromotion: row.IDPromotion,
+		Name:        row.Name,
+		Kind:        row.Kind,
+		CouponCode:  row.CouponCode,
+	}
+}
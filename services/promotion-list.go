@@ -0,0 +1,54 @@
+package services
+
+import (
+	"net/http"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//PromotionList every promotion newest first, running or not
+func PromotionList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|promotion-list|"
+
+		list, err := ctx.Promotions.List()
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+			})
+			return
+		}
+
+		data := []shared.Promotion{}
+		for _, row := range list {
+			data = append(data, promotionResponse(ctx, row))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  len(data),
+		})
+	}
+}
+
+func GetPromotion(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-promotion|"
+
+		promotion, ok := findPromotion(ctx, c, process, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, promotionResponse(ctx, promotion))
+	}
+}
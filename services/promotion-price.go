@@ -0,0 +1,218 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//Pricing effective price of a product or the lines of a cart with the best running promotion of every line,
+//totals are summed per currency as products may be priced in different ones
+func Pricing(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|pricing|"
+		input := shared.ParamPricing{}
+		if err := c.Bind(&input); err != nil || len(input.Items) == 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input, items can't be empty",
+			})
+			return
+		}
+
+		items := []pricedItem{}
+		products := []tables.Product{}
+		for _, item := range input.Items {
+			if item.Quantity < 0 {
+				h.BadResponse(h.RespParams{
+					Log:      ctx.Log,
+					Context:  c,
+					Severity: h.DEBUG,
+					Section:  process + "validate",
+					Reason:   "quantity must be a positive number",
+					Input:    item,
+				})
+				return
+			}
+			if item.Quantity == 0 {
+				item.Quantity = 1
+			}
+
+			product, ok := findProduct(ctx, c, process, item.IDProduct, false)
+			if !ok {
+				return
+			}
+			price := product.Price
+			if item.IDVariant != "" {
+				variant, ok := findVariant(ctx, c, process, product.IDProduct, item.IDVariant)
+				if !ok {
+					return
+				}
+				price = variant.EffectivePrice(product)
+			}
+			items = append(items, pricedItem{product: product, idVariant: item.IDVariant, unitPrice: price, quantity: item.Quantity})
+			products = append(products, product)
+		}
+
+		pricer, ok := loadPricer(ctx, c, process, products, input.Coupon)
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, pricer.pricing(items))
+	}
+}
+
+//pricedItem line of a cart or pricing request, unitPrice is the product or variant price
+type pricedItem struct {
+	product   tables.Product
+	idVariant string
+	unitPrice int
+	quantity  int
+}
+
+//promotionPricer running promotions with the category paths of the priced products
+type promotionPricer struct {
+	promotions []tables.Promotion
+	paths      map[string][]string
+}
+
+//newPromotionPricer loads the promotions running at now, the product categories are only
+//read when a promotion targets categories
+func newPromotionPricer(ctx cfg.RepositoryContext, products []tables.Product, now time.Time, coupon string) (promotionPricer, error) {
+	pricer := promotionPricer{paths: map[string][]string{}}
+	promotions, err := ctx.Promotions.Running(now, coupon)
+	if err != nil {
+		return pricer, err
+	}
+	pricer.promotions = promotions
+
+	byCategory := false
+	for _, p := range promotions {
+		for _, t := range p.Targets {
+			byCategory = byCategory || t.TargetType == tables.TargetCategory
+		}
+	}
+	if !byCategory || len(products) == 0 {
+		return pricer, nil
+	}
+
+	ids := []string{}
+	for _, p := range products {
+		ids = append(ids, p.IDProduct)
+	}
+	categoryIDs, err := ctx.Products.CategoryIDsOf(ids)
+	if err != nil {
+		return pricer, err
+	}
+	categories, err := ctx.Categories.List()
+	if err != nil {
+		return pricer, err
+	}
+	paths := map[string]string{}
+	for _, category := range categories {
+		paths[category.IDCategory] = category.Path
+	}
+	for product, list := range categoryIDs {
+		for _, id := range list {
+			if path, ok := paths[id]; ok {
+				pricer.paths[product] = append(pricer.paths[product], path)
+			}
+		}
+	}
+	return pricer, nil
+}
+
+//loadPricer promotion pricer of the products, an unknown or not running coupon is answered with 400.
+//It writes the error response itself when it can't
+func loadPricer(ctx cfg.RepositoryContext, c *gin.Context, process string, products []tables.Product, coupon string) (promotionPricer, bool) {
+	code := couponCode(coupon)
+	pricer, err := newPromotionPricer(ctx, products, time.Now(), code)
+	if err != nil {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "promotions",
+			Error:    err,
+			Reason:   err.Error(),
+		})
+		return pricer, false
+	}
+	if code != "" && !pricer.hasCoupon(code) {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "coupon",
+			Reason:   "coupon is not valid",
+			Input:    coupon,
+		})
+		return pricer, false
+	}
+	return pricer, true
+}
+
+//hasCoupon reports whether a running promotion has the coupon code
+func (pp promotionPricer) hasCoupon(coupon string) bool {
+	for _, p := range pp.promotions {
+		if p.CouponCode != nil && strings.EqualFold(*p.CouponCode, coupon) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pp promotionPricer) price(product tables.Product, unitPrice, quantity int) tables.PromotionResult {
+	return tables.BestPromotion(tables.PromotionItem{
+		Product:       product,
+		UnitPrice:     unitPrice,
+		Quantity:      quantity,
+		CategoryPaths: pp.paths[product.IDProduct],
+	}, pp.promotions)
+}
+
+//pricing lines of the items with their totals per currency, in the order of the first line of each currency
+func (pp promotionPricer) pricing(items []pricedItem) shared.Pricing {
+	data := shared.Pricing{Lines: []shared.PricingLine{}, Totals: []shared.PricingTotal{}}
+	totals := map[string]int{}
+	for _, item := range items {
+		product := item.product
+		result := pp.price(product, item.unitPrice, item.quantity)
+		data.Lines = append(data.Lines, shared.PricingLine{
+			IDProduct:   product.IDProduct,
+			IDVariant:   item.idVariant,
+			ProductName: product.ProductName,
+			Quantity:    result.Quantity,
+			UnitPrice:   money(result.UnitPrice, product.Currency),
+			Subtotal:    money(result.UnitPrice*result.Quantity, product.Currency),
+			Discount:    money(result.Discount, product.Currency),
+			Total:       money(result.Total, product.Currency),
+			Promotion:   appliedPromotion(result.Promotion),
+		})
+
+		if _, ok := totals[product.Currency]; !ok {
+			totals[product.Currency] = len(data.Totals)
+			data.Totals = append(data.Totals, shared.PricingTotal{
+				Currency: product.Currency,
+				Subtotal: money(0, product.Currency),
+				Discount: money(0, product.Currency),
+				Total:    money(0, product.Currency),
+			})
+		}
+		total := &data.Totals[totals[product.Currency]]
+		total.Subtotal.Amount += int64(result.UnitPrice * result.Quantity)
+		total.Discount.Amount += int64(result.Discount)
+		total.Total.Amount += int64(result.Total)
+	}
+	return data
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	shared "product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//UpdatePromotion replaces the whole promotion with its targets, starts_at defaults to the stored one
+func UpdatePromotion(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|update-promotion|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamPromotion{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		stored, ok := findPromotion(ctx, c, process, id)
+		if !ok {
+			return
+		}
+
+		promotion, err := validatePromotion(ctx, input, stored.StartsAt)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		promotion.IDPromotion = id
+		promotion.CreatedDate = stored.CreatedDate
+		promotion.UpdatedDate = now
+		if err := ctx.Promotions.Update(&promotion); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateCoupon) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, promotionResponse(ctx, promotion))
+	}
+}
+
+func DeletePromotion(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|delete-promotion|"
+		id := c.Param("id")
+
+		if _, ok := findPromotion(ctx, c, process, id); !ok {
+			return
+		}
+
+		if err := ctx.Promotions.Delete(id); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, nil)
+	}
+}
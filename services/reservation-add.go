@@ -0,0 +1,82 @@
+package services
+
+import (
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//ReserveStock holds quantity units of the product for ttl seconds without touching its quantity,
+//the reservation belongs to the caller
+func ReserveStock(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|reserve-stock|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamReservation{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		//quantity
+		if input.Quantity <= 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "quantity",
+				Reason:   "quantity must be positive",
+				Input:    input,
+			})
+			return
+		}
+
+		//ttl
+		ttl := time.Duration(input.TTL) * time.Second
+		if input.TTL == 0 {
+			ttl = ctx.Config.Reserve.TTL
+		}
+		if ttl <= 0 || ttl > ctx.Config.Reserve.MaxTTL {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "ttl",
+				Reason:   "ttl must be between 1 and " + ctx.Config.Reserve.MaxTTL.String(),
+				Input:    input,
+			})
+			return
+		}
+
+		if _, ok := findProduct(ctx, c, process, id, false); !ok {
+			return
+		}
+
+		principal, _ := h.CurrentPrincipal(c)
+		reservation := tables.Reservation{
+			IDProduct:   id,
+			IDCustomer:  principal.ID,
+			Quantity:    input.Quantity,
+			ExpiresAt:   now.Add(ttl),
+			CreatedDate: now,
+		}
+		if err := ctx.Reserve.Reserve(&reservation, ctx.Config.Reserve.MaxOpen, ctx.IDGen.NewID); err != nil {
+			reservationError(ctx, c, process+"reserve", err, input)
+			return
+		}
+
+		h.GoodResponse(c, reservationResponse(reservation, now))
+	}
+}
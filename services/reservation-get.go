@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetReservation(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-reservation|"
+		id := c.Param("id")
+
+		reservation, ok := findReservation(ctx, c, process, id)
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, reservationResponse(reservation, time.Now()))
+	}
+}
+
+//findReservation loads a reservation of the caller, reservations of other customers are only open to
+//orders:manage and reported as not found otherwise. It writes the error response itself when it can't
+func findReservation(ctx cfg.RepositoryContext, c *gin.Context, process, id string) (tables.Reservation, bool) {
+	reservation, err := ctx.Reserve.GetByID(id)
+	if err == nil && !customerAccess(c, reservation.IDCustomer) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		reservationError(ctx, c, process+"get-by-id", err, id)
+		return tables.Reservation{}, false
+	}
+	return reservation, true
+}
+
+//reservationError writes the response of a failed reservation call
+func reservationError(ctx cfg.RepositoryContext, c *gin.Context, section string, err error, input interface{}) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		h.NotFoundResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  section,
+			Reason:   "reservation or product not found",
+			Input:    input,
+		})
+	case errors.Is(err, tables.ErrInsufficientStock), errors.Is(err, tables.ErrReservationClosed), errors.Is(err, tables.ErrReservationExpired),
+		errors.Is(err, tables.ErrReservationLimit):
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  section,
+			Reason:   err.Error(),
+			Input:    input,
+		})
+	default:
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  section,
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    input,
+		})
+	}
+}
+
+func reservationResponse(row tables.Reservation, now time.Time) shared.Reservation {
+	return shared.Reservation{
+		IDReservation: row.IDReservation,
+		IDProduct:     row.IDProduct,
+		IDCustomer:    row.IDCustomer,
+		Quantity:      row.Quantity,
+		Status:        row.StatusAt(now),
+		ExpiresAt:     row.ExpiresAt,
+	}
+}
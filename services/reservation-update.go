@@ -0,0 +1,47 @@
+package services
+
+import (
+	"time"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+//ConfirmReservation turns the reservation into a sale, the product quantity is decremented through the stock ledger
+func ConfirmReservation(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|confirm-reservation|"
+		now := time.Now()
+		id := c.Param("id")
+
+		reservation, err := ctx.Reserve.Confirm(id, h.CurrentActor(c), now, ctx.IDGen.NewID)
+		if err != nil {
+			reservationError(ctx, c, process+"confirm", err, id)
+			return
+		}
+
+		h.GoodResponse(c, reservationResponse(reservation, now))
+	}
+}
+
+//ReleaseReservation gives the held units back to the available quantity, customers release their own reservations
+func ReleaseReservation(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|release-reservation|"
+		now := time.Now()
+		id := c.Param("id")
+
+		if _, ok := findReservation(ctx, c, process, id); !ok {
+			return
+		}
+		reservation, err := ctx.Reserve.Release(id, now)
+		if err != nil {
+			reservationError(ctx, c, process+"release", err, id)
+			return
+		}
+
+		h.GoodResponse(c, reservationResponse(reservation, now))
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//RegisterUser creates an active viewer account, the password is stored as its bcrypt hash
+func RegisterUser(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|register-user|"
+		now := time.Now()
+		input := shared.ParamUser{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		//the password is left out of the logged input
+		logged := shared.ParamUserProfile{Email: input.Email, FullName: input.FullName, Phone: input.Phone}
+		profile := logged
+		input.Username = strings.TrimSpace(input.Username)
+		err := h.UsernameRule(input.Username)
+		if err == nil {
+			err = h.PasswordRule(input.Password)
+		}
+		if err == nil {
+			profile, err = validateUserProfile(profile)
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    logged,
+			})
+			return
+		}
+
+		hash, err := h.HashPassword(input.Password)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "hash-password",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    logged,
+			})
+			return
+		}
+
+		user := tables.User{
+			TenantID:    h.CurrentTenant(c),
+			Username:    input.Username,
+			Email:       profile.Email,
+			Password:    hash,
+			FullName:    profile.FullName,
+			Phone:       profile.Phone,
+			Active:      true,
+			Roles:       []string{tables.RoleViewer},
+			CreatedDate: now,
+			UpdatedDate: now,
+		}
+		if err := ctx.Users.Create(&user, ctx.IDGen.NewID); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateUsername) || errors.Is(err, tables.ErrDuplicateEmail) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    logged,
+			})
+			return
+		}
+
+		h.GoodResponse(c, userResponse(ctx, user))
+	}
+}
+
+//validateUserProfile trimmed profile, email lower cased, phone optional
+func validateUserProfile(input shared.ParamUserProfile) (shared.ParamUserProfile, error) {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	input.FullName = strings.TrimSpace(input.FullName)
+	input.Phone = strings.TrimSpace(input.Phone)
+	if err := h.EmailRule(input.Email); err != nil {
+		return shared.ParamUserProfile{}, err
+	}
+	if err := h.FullnameRule(input.FullName); err != nil {
+		return shared.ParamUserProfile{}, err
+	}
+	if input.Phone != "" {
+		if err := h.PhoneRule(input.Phone); err != nil {
+			return shared.ParamUserProfile{}, err
+		}
+	}
+	return input, nil
+}
+
+//findUser loads a user by id and writes the error response itself when it can't,
+//deactivated users are reported as not found unless includeInactive is set, users of other tenants always are
+func findUser(ctx cfg.RepositoryContext, c *gin.Context, process, id string, includeInactive bool) (tables.User, bool) {
+	user, err := ctx.Users.GetByID(id)
+	if err == nil && (!user.Active && !includeInactive || user.TenantID != h.CurrentTenant(c)) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "user not found",
+				Input:    id,
+			})
+			return tables.User{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.User{}, false
+	}
+
+	return user, true
+}
+
+func userResponse(ctx cfg.RepositoryContext, row tables.User) shared.User {
+	data := shared.User{
+		IDUser:      row.IDUser,
+		TenantID:    row.TenantID,
+		Username:    row.Username,
+		Email:       row.Email,
+		FullName:    row.FullName,
+		Phone:       row.Phone,
+		Active:      row.Active,
+		CreatedDate: row.CreatedDate.In(ctx.Config.App.Location),
+	}
+	if row.DeactivatedDate != nil {
+		deactivatedDate := row.DeactivatedDate.In(ctx.Config.App.Location)
+		data.DeactivatedDate = &deactivatedDate
+	}
+	return data
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"time"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+//DeactivateUser soft deletes the account and revokes its refresh tokens, its username and email stay taken
+func DeactivateUser(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|deactivate-user|"
+		now := time.Now()
+		id := c.Param("id")
+
+		user, ok := findUser(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		err := ctx.Users.Deactivate(user.IDUser, now)
+		if err == nil {
+			err = ctx.Tokens.RevokeUser(user.IDUser, now)
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, nil)
+	}
+}
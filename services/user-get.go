@@ -0,0 +1,21 @@
+package services
+
+import (
+	cfg "product-test/config"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetUser(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-user|"
+
+		user, ok := findUser(ctx, c, process, c.Param("id"), inactiveOption(c, h.PermUsersManage))
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, userResponse(ctx, user))
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UserRoles(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|user-roles|"
+		id := c.Param("id")
+
+		if _, ok := findUser(ctx, c, process, id, true); !ok {
+			return
+		}
+
+		roles, err := ctx.Users.Roles(id)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, userRolesResponse(id, roles))
+	}
+}
+
+//SetUserRoles replaces the roles of a user, they are in the tokens the user gets from its next login or refresh
+func SetUserRoles(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|set-user-roles|"
+		id := c.Param("id")
+		input := shared.ParamUserRoles{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		if _, ok := findUser(ctx, c, process, id, false); !ok {
+			return
+		}
+
+		roles, err := validateRoles(input.Roles)
+		//an admin taking its own admin role could leave nobody able to manage users
+		principal, _ := h.CurrentPrincipal(c)
+		if err == nil && principal.Kind == h.PrincipalUser && principal.ID == id && !containsString(roles, tables.RoleAdmin) {
+			err = errors.New("you can't remove your own admin role")
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		if err := ctx.Users.SetRoles(id, roles); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, userRolesResponse(id, roles))
+	}
+}
+
+//validateRoles lower cased known roles without duplicates
+func validateRoles(input []string) ([]string, error) {
+	roles := []string{}
+	for _, role := range input {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !h.IsRole(role) {
+			return nil, fmt.Errorf("unknown role %s, use %s, %s, %s or %s", role, tables.RoleAdmin, tables.RoleCatalogManager, tables.RoleCustomer, tables.RoleViewer)
+		}
+		if !containsString(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+func containsString(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
+
+func userRolesResponse(id string, roles []string) shared.UserRoles {
+	return shared.UserRoles{
+		IDUser:      id,
+		Roles:       roles,
+		Permissions: h.RolePermissions(roles),
+	}
+}
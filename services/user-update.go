@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+//UpdateUser changes email, full name and phone of an active user
+func UpdateUser(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|update-user|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamUserProfile{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		user, ok := findUser(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		profile, err := validateUserProfile(input)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		user.Email = profile.Email
+		user.FullName = profile.FullName
+		user.Phone = profile.Phone
+		user.UpdatedDate = now
+		if err := ctx.Users.Update(&user); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateEmail) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, userResponse(ctx, user))
+	}
+}
+
+//ChangePassword replaces the password of an active user after checking the old one and revokes its refresh tokens
+func ChangePassword(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|change-password|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamUserPassword{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		user, ok := findUser(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+
+		//passwords are never logged, only the user id
+		if !h.CheckPasswordHash(input.OldPassword, user.Password) {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "old-password",
+				Reason:   "old password is wrong",
+				Input:    id,
+			})
+			return
+		}
+		err := h.PasswordRule(input.NewPassword)
+		if err == nil && input.NewPassword == input.OldPassword {
+			err = errors.New("new password must be different from the old password")
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		hash, err := h.HashPassword(input.NewPassword)
+		if err == nil {
+			err = ctx.Users.SetPassword(id, hash, now)
+		}
+		if err == nil {
+			err = ctx.Tokens.RevokeUser(id, now)
+		}
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		h.GoodResponse(c, nil)
+	}
+}
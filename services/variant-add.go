@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	shared "product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AddVariant(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-variant|"
+		now := time.Now()
+		id := c.Param("id")
+		input, ok := bindVariant(ctx, c, process)
+		if !ok {
+			return
+		}
+
+		product, ok := findProduct(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+		price, err := variantPrice(input, product.Currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "price",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		variant := tables.Variant{
+			IDProduct:   id,
+			SKU:         input.SKU,
+			Attributes:  input.Attributes,
+			Quantity:    input.Quantity,
+			CreatedDate: now,
+			UpdatedDate: now,
+			Price:       price,
+		}
+		if err := ctx.Variants.Create(&variant, ctx.IDGen.NewID); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateSKU) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, variantResponse(variant, product))
+	}
+}
+
+//bindVariant binds and validates the variant input, attributes come from json or attributes[name] form fields
+func bindVariant(ctx cfg.RepositoryContext, c *gin.Context, process string) (shared.ParamVariant, bool) {
+	input := shared.ParamVariant{}
+	if err := c.Bind(&input); err != nil {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "bind",
+			Reason:   "missing input",
+		})
+		return shared.ParamVariant{}, false
+	}
+	if len(input.Attributes) == 0 {
+		input.Attributes = c.PostFormMap("attributes")
+	}
+
+	if err := validateVariant(input); err != nil {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "validate",
+			Reason:   err.Error(),
+			Input:    input,
+		})
+		return shared.ParamVariant{}, false
+	}
+
+	return input, true
+}
+
+func validateVariant(input shared.ParamVariant) error {
+	if err := h.MustNotEmpty(input.SKU, "sku"); err != nil {
+		return err
+	}
+	if len(input.SKU) > 50 {
+		return fmt.Errorf("sku need 1-50 characters")
+	}
+	if input.Quantity < 0 {
+		return fmt.Errorf("quantity cannot be negative")
+	}
+	return nil
+}
+
+//findVariant loads a variant of the product and writes the error response itself when it can't
+func findVariant(ctx cfg.RepositoryContext, c *gin.Context, process, productID, id string) (tables.Variant, bool) {
+	variant, err := ctx.Variants.GetByID(id)
+	if err == nil && variant.IDProduct != productID {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "variant not found",
+				Input:    id,
+			})
+			return tables.Variant{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.Variant{}, false
+	}
+
+	return variant, true
+}
+
+//variantPrice price in minor units of the product currency, nil when the variant sells at the product price
+func variantPrice(input shared.ParamVariant, currency string) (*int, error) {
+	if strings.TrimSpace(string(input.Price)) == "" {
+		return nil, nil
+	}
+	m, err := shared.ParseMoney(input.Price, currency)
+	if err != nil {
+		return nil, fmt.Errorf("price %w", err)
+	}
+	if m.Amount < 0 {
+		return nil, fmt.Errorf("price cannot be negative")
+	}
+	if m.Amount == 0 {
+		return nil, nil
+	}
+	price := int(m.Amount)
+	return &price, nil
+}
+
+func variantResponse(row tables.Variant, product tables.Product) shared.Variant {
+	attributes := map[string]string(row.Attributes)
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	price := money(row.EffectivePrice(product), product.Currency)
+	return shared.Variant{
+		IDVariant:  row.IDVariant,
+		IDProduct:  row.IDProduct,
+		SKU:        row.SKU,
+		Attributes: attributes,
+		Price:      price.Number(),
+		PriceMoney: price,
+		Quantity:   row.Quantity,
+	}
+}
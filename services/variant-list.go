@@ -0,0 +1,130 @@
+package services
+
+import (
+	"net/http"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+func VariantList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|variant-list|"
+		id := c.Param("id")
+
+		product, ok := findProduct(ctx, c, process, id, includeInactive(c))
+		if !ok {
+			return
+		}
+
+		list, err := ctx.Variants.ListByProducts([]string{id})
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    id,
+			})
+			return
+		}
+
+		data := []shared.Variant{}
+		for _, row := range list {
+			data = append(data, variantResponse(row, product))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+		})
+	}
+}
+
+//productResponses product responses with the available quantity, the stock per warehouse and
+//the variant price range and stock of products having variants
+func productResponses(ctx cfg.RepositoryContext, rows []tables.Product) ([]shared.Product, error) {
+	ids := []string{}
+	for _, row := range rows {
+		ids = append(ids, row.IDProduct)
+	}
+	variants, err := ctx.Variants.ListByProducts(ids)
+	if err != nil {
+		return nil, err
+	}
+	summaries := tables.SummarizeVariants(rows, variants)
+	reserved, err := ctx.Reserve.Reserved(ids, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	stock, err := warehouseStock(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	pricer, err := newPromotionPricer(ctx, rows, time.Now(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	data := []shared.Product{}
+	for _, row := range rows {
+		product := productResponse(ctx, row)
+		product.AvailableQuantity -= reserved[row.IDProduct]
+		if product.AvailableQuantity < 0 {
+			product.AvailableQuantity = 0
+		}
+		result := pricer.price(row, row.Price, 1)
+		product.OriginalPrice = money(result.UnitPrice, row.Currency)
+		product.FinalPrice = money(result.Total, row.Currency)
+		product.Promotion = appliedPromotion(result.Promotion)
+		product.Stock = stock[row.IDProduct]
+		if product.Stock == nil {
+			product.Stock = []shared.WarehouseStock{}
+		}
+		if s, ok := summaries[row.IDProduct]; ok {
+			minPrice, maxPrice := money(s.MinPrice, row.Currency), money(s.MaxPrice, row.Currency)
+			product.VariantSummary = &shared.VariantSummary{
+				Count:         s.Count,
+				MinPrice:      minPrice.Number(),
+				MaxPrice:      maxPrice.Number(),
+				MinPriceMoney: minPrice,
+				MaxPriceMoney: maxPrice,
+				TotalStock:    s.TotalStock,
+			}
+		}
+		data = append(data, product)
+	}
+	return data, nil
+}
+
+//warehouseStock stock levels of the products per warehouse keyed by product id
+func warehouseStock(ctx cfg.RepositoryContext, ids []string) (map[string][]shared.WarehouseStock, error) {
+	stock := map[string][]shared.WarehouseStock{}
+	levels, err := ctx.Stock.Levels(ids)
+	if err != nil || len(levels) == 0 {
+		return stock, err
+	}
+	warehouses, err := ctx.Warehouses.List()
+	if err != nil {
+		return nil, err
+	}
+	codes := map[string]string{}
+	for _, w := range warehouses {
+		codes[w.IDWarehouse] = w.Code
+	}
+
+	for _, level := range levels {
+		stock[level.IDProduct] = append(stock[level.IDProduct], shared.WarehouseStock{
+			IDWarehouse: level.IDWarehouse,
+			Code:        codes[level.IDWarehouse],
+			Quantity:    level.Quantity,
+		})
+	}
+	return stock, nil
+}
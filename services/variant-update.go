@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UpdateVariant(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|update-variant|"
+		now := time.Now()
+		id := c.Param("id")
+		input, ok := bindVariant(ctx, c, process)
+		if !ok {
+			return
+		}
+
+		product, ok := findProduct(ctx, c, process, id, false)
+		if !ok {
+			return
+		}
+		price, err := variantPrice(input, product.Currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "price",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+		variant, ok := findVariant(ctx, c, process, id, c.Param("variant_id"))
+		if !ok {
+			return
+		}
+
+		variant.SKU = input.SKU
+		variant.Attributes = input.Attributes
+		variant.Quantity = input.Quantity
+		variant.Price = price
+		variant.UpdatedDate = now
+		if err := ctx.Variants.Update(&variant); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateSKU) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, variantResponse(variant, product))
+	}
+}
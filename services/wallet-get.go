@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetWallet(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-wallet|"
+
+		wallet, ok := findWallet(ctx, c, process, c.Param("customer"))
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, walletResponse(ctx, wallet))
+	}
+}
+
+//WalletTransactions deposits, withdrawals and payments of a wallet newest first
+func WalletTransactions(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|wallet-transactions|"
+		customer := c.Param("customer")
+
+		page, err := queryInt(c, "page")
+		if err != nil || page < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "page",
+				Reason:   "page must be a positive number",
+				Input:    c.Query("page"),
+			})
+			return
+		}
+		size, err := queryInt(c, "size")
+		if err != nil || size < 0 {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "size",
+				Reason:   "size must be a positive number",
+				Input:    c.Query("size"),
+			})
+			return
+		}
+
+		if _, ok := findWallet(ctx, c, process, customer); !ok {
+			return
+		}
+
+		list, total, err := ctx.Wallets.Transactions(customer, page, size)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    customer,
+			})
+			return
+		}
+
+		data := []shared.WalletTransaction{}
+		for _, row := range list {
+			data = append(data, walletTransactionResponse(ctx, row))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+			"total":  total,
+		})
+	}
+}
+
+//findWallet loads the wallet of a customer and writes the error response itself when it can't
+func findWallet(ctx cfg.RepositoryContext, c *gin.Context, process, customer string) (tables.Wallet, bool) {
+	wallet, err := ctx.Wallets.GetByID(customer)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "wallet not found",
+				Input:    customer,
+			})
+			return tables.Wallet{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    customer,
+		})
+		return tables.Wallet{}, false
+	}
+
+	return wallet, true
+}
+
+func walletResponse(ctx cfg.RepositoryContext, row tables.Wallet) shared.Wallet {
+	return shared.Wallet{
+		IDCustomer:  row.IDCustomer,
+		Balance:     money(row.Balance, row.Currency),
+		UpdatedDate: row.UpdatedDate.In(ctx.Config.App.Location),
+	}
+}
+
+func walletTransactionResponse(ctx cfg.RepositoryContext, row tables.WalletTransaction) shared.WalletTransaction {
+	data := shared.WalletTransaction{
+		IDTransaction: row.IDTransaction,
+		Kind:          row.Kind,
+		Amount:        money(row.Amount, row.Currency),
+		BalanceAfter:  money(row.BalanceAfter, row.Currency),
+		IDOrder:       row.IDOrder,
+		Entries:       []shared.WalletEntry{},
+		CreatedDate:   row.CreatedDate.In(ctx.Config.App.Location),
+	}
+	for _, e := range row.Entries {
+		data.Entries = append(data.Entries, shared.WalletEntry{Account: e.Account, Amount: money(e.Amount, row.Currency)})
+	}
+	return data
+}
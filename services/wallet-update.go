@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//idempotencyHeader key sent by the client with every wallet transaction, a retry with the same key
+//gets the first response back with the Idempotent-Replayed header instead of moving money again
+const idempotencyHeader = "Idempotency-Key"
+
+//DepositWallet adds money to the wallet of a customer, the wallet is opened in the default currency
+//on the first deposit to a user of the tenant. Deposits follow helpers.AmountDepoRule in major units of the wallet currency
+func DepositWallet(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|deposit-wallet|"
+		customer := c.Param("customer")
+		input := shared.ParamWalletAmount{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		currency := ctx.Config.Price.Currency
+		wallet, err := ctx.Wallets.GetByID(customer)
+		if err == nil {
+			currency = wallet.Currency
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			//wallets are only opened for the users of the tenant
+			if _, ok := findUser(ctx, c, process, customer, false); !ok {
+				return
+			}
+		} else {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "get-by-id",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    customer,
+			})
+			return
+		}
+
+		amount, err := depositAmount(input.Amount, currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		transaction := tables.WalletTransaction{Kind: tables.WalletDeposit, Amount: amount, Currency: currency}
+		applyWallet(ctx, c, process, customer, transaction, input)
+	}
+}
+
+//WithdrawWallet takes money out of the wallet of a customer, following helpers.WithdrawRule
+func WithdrawWallet(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|withdraw-wallet|"
+		customer := c.Param("customer")
+		input := shared.ParamWalletAmount{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		wallet, ok := findWallet(ctx, c, process, customer)
+		if !ok {
+			return
+		}
+		amount, err := parseAmount(input.Amount, wallet.Currency)
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		transaction := tables.WalletTransaction{Kind: tables.WalletWithdrawal, Amount: amount, Currency: wallet.Currency}
+		applyWallet(ctx, c, process, customer, transaction, input)
+	}
+}
+
+//PayOrderFromWallet pays the total of a pending order placed by the customer from its wallet and marks the order paid,
+//the balance is checked with helpers.WithdrawRule
+func PayOrderFromWallet(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|pay-order-from-wallet|"
+		customer := c.Param("customer")
+		input := shared.ParamWalletPayment{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+		if err := h.MustNotEmpty(input.IDOrder, "id_order"); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		if _, ok := findWallet(ctx, c, process, customer); !ok {
+			return
+		}
+
+		transaction := tables.WalletTransaction{Kind: tables.WalletPayment, IDOrder: &input.IDOrder}
+		applyWallet(ctx, c, process, customer, transaction, input)
+	}
+}
+
+//applyWallet stores the transaction under the idempotency key of the request and writes the response
+func applyWallet(ctx cfg.RepositoryContext, c *gin.Context, process, customer string, transaction tables.WalletTransaction, input interface{}) {
+	key := strings.TrimSpace(c.GetHeader(idempotencyHeader))
+	if key == "" || len(key) > 100 {
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "idempotency-key",
+			Reason:   idempotencyHeader + " header is required, at most 100 characters",
+			Input:    input,
+		})
+		return
+	}
+
+	//refused keeps the error of the rule so it is answered as a validation error
+	var refused error
+	check := func(balance, amount int) error {
+		refused = h.WithdrawRule(balance, amount)
+		return refused
+	}
+
+	transaction.IDCustomer = customer
+	transaction.IdempotencyKey = key
+	transaction.CreatedDate = time.Now()
+	err := ctx.Wallets.Apply(&transaction, check, ctx.IDGen.NewID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.NotFoundResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.DEBUG,
+			Section:  process + "result",
+			Reason:   "wallet or order not found",
+			Input:    input,
+		})
+		return
+	}
+	if err != nil {
+		severity := h.ERROR
+		if (refused != nil && errors.Is(err, refused)) || errors.Is(err, tables.ErrIdempotencyConflict) ||
+			errors.Is(err, tables.ErrWalletCurrency) || errors.Is(err, tables.ErrInsufficientBalance) ||
+			errors.Is(err, tables.ErrOrderTransition) || errors.Is(err, tables.ErrNothingToPay) ||
+			errors.Is(err, tables.ErrOrderCustomer) {
+			severity = h.DEBUG
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: severity,
+			Section:  process + "result",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    input,
+		})
+		return
+	}
+
+	if transaction.Replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
+	h.GoodResponse(c, walletTransactionResponse(ctx, transaction))
+}
+
+//depositAmount deposit in minor units of currency, the whole major units have to pass helpers.AmountDepoRule
+//(an amount with minor digits is checked with the major units below and above it)
+func depositAmount(value shared.Decimal, currency string) (int, error) {
+	amount, err := parseAmount(value, currency)
+	if err != nil {
+		return 0, err
+	}
+	whole, exact := money(amount, currency).Major()
+	if exact || whole > 0 {
+		if err := h.AmountDepoRule(int(whole)); err != nil {
+			return 0, err
+		}
+	}
+	if !exact {
+		if err := h.AmountDepoRule(int(whole) + 1); err != nil {
+			return 0, err
+		}
+	}
+	return amount, nil
+}
+
+//parseAmount positive amount in major units of currency, returned in minor units
+func parseAmount(value shared.Decimal, currency string) (int, error) {
+	if err := h.MustNotEmpty(strings.TrimSpace(string(value)), "amount"); err != nil {
+		return 0, err
+	}
+	m, err := shared.ParseMoney(value, currency)
+	if err != nil {
+		return 0, fmt.Errorf("amount %w", err)
+	}
+	if m.Amount <= 0 {
+		return 0, errors.New("amount must be greater than 0")
+	}
+	return int(m.Amount), nil
+}
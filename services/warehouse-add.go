@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	shared "product-test/shared"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AddWarehouse(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|add-warehouse|"
+		now := time.Now()
+		input := shared.ParamWarehouse{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+		if err := validateWarehouse(input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		warehouse := tables.Warehouse{
+			Code:        input.Code,
+			Name:        input.Name,
+			CreatedDate: now,
+			UpdatedDate: now,
+		}
+		if err := ctx.Warehouses.Create(&warehouse, ctx.IDGen.NewID); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateWarehouseCode) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, warehouseResponse(warehouse))
+	}
+}
+
+func validateWarehouse(input shared.ParamWarehouse) error {
+	if err := h.MustNotEmpty(input.Code, "code"); err != nil {
+		return err
+	}
+	if len(input.Code) > 20 {
+		return fmt.Errorf("code need at most 20 characters")
+	}
+	return h.NameRule(input.Name)
+}
+
+//findWarehouse loads a warehouse by id and writes the error response itself when it can't
+func findWarehouse(ctx cfg.RepositoryContext, c *gin.Context, process, id string) (tables.Warehouse, bool) {
+	warehouse, err := ctx.Warehouses.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.NotFoundResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "get-by-id",
+				Reason:   "warehouse not found",
+				Input:    id,
+			})
+			return tables.Warehouse{}, false
+		}
+		h.BadResponse(h.RespParams{
+			Log:      ctx.Log,
+			Context:  c,
+			Severity: h.ERROR,
+			Section:  process + "get-by-id",
+			Error:    err,
+			Reason:   err.Error(),
+			Input:    id,
+		})
+		return tables.Warehouse{}, false
+	}
+
+	return warehouse, true
+}
+
+func warehouseResponse(row tables.Warehouse) shared.Warehouse {
+	return shared.Warehouse{
+		IDWarehouse: row.IDWarehouse,
+		Code:        row.Code,
+		Name:        row.Name,
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"net/http"
+
+	cfg "product-test/config"
+	h "product-test/helpers"
+	"product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WarehouseList(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|warehouse-list|"
+
+		list, err := ctx.Warehouses.List()
+		if err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.ERROR,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+			})
+			return
+		}
+
+		data := []shared.Warehouse{}
+		for _, row := range list {
+			data = append(data, warehouseResponse(row))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"data":   data,
+		})
+	}
+}
+
+func GetWarehouse(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|get-warehouse|"
+
+		warehouse, ok := findWarehouse(ctx, c, process, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		h.GoodResponse(c, warehouseResponse(warehouse))
+	}
+}
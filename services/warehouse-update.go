@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	cfg "product-test/config"
+	tables "product-test/database"
+	h "product-test/helpers"
+	shared "product-test/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UpdateWarehouse(ctx cfg.RepositoryContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		process := "|services|update-warehouse|"
+		now := time.Now()
+		id := c.Param("id")
+		input := shared.ParamWarehouse{}
+		if err := c.Bind(&input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "bind",
+				Reason:   "missing input",
+			})
+			return
+		}
+
+		warehouse, ok := findWarehouse(ctx, c, process, id)
+		if !ok {
+			return
+		}
+
+		input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+		if err := validateWarehouse(input); err != nil {
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: h.DEBUG,
+				Section:  process + "validate",
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		warehouse.Code = input.Code
+		warehouse.Name = input.Name
+		warehouse.UpdatedDate = now
+		if err := ctx.Warehouses.Update(&warehouse); err != nil {
+			severity := h.ERROR
+			if errors.Is(err, tables.ErrDuplicateWarehouseCode) {
+				severity = h.DEBUG
+			}
+			h.BadResponse(h.RespParams{
+				Log:      ctx.Log,
+				Context:  c,
+				Severity: severity,
+				Section:  process + "result",
+				Error:    err,
+				Reason:   err.Error(),
+				Input:    input,
+			})
+			return
+		}
+
+		h.GoodResponse(c, warehouseResponse(warehouse))
+	}
+}
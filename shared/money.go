@@ -0,0 +1,180 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+//currencyExponents ISO 4217 minor unit digits of the supported currencies
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "CNY": 2, "EUR": 2, "GBP": 2, "HKD": 2, "IDR": 2, "INR": 2, "JPY": 0,
+	"KRW": 0, "KWD": 3, "MYR": 2, "PHP": 2, "SGD": 2, "THB": 2, "TWD": 2, "USD": 2, "VND": 0,
+}
+
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+//Currency upper cased ISO 4217 code, an error for codes not in the supported list
+func Currency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := currencyExponents[code]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return code, nil
+}
+
+//Decimal amount sent by clients, json accepts both 1500 and "1500.50", always in major units
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*d = Decimal(n.String())
+	return nil
+}
+
+//Money amount in minor units of an ISO 4217 currency (IDR 1500.50 is Amount 150050)
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+//ParseMoney reads a decimal amount in major units, digits past the currency minor unit are
+//rounded half away from zero (USD 1.005 is 1.01)
+func ParseMoney(value Decimal, currency string) (Money, error) {
+	currency, err := Currency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	s := strings.TrimSpace(string(value))
+	if !decimalPattern.MatchString(s) {
+		return Money{}, fmt.Errorf("%q is not a decimal amount", s)
+	}
+	r, _ := new(big.Rat).SetString(s)
+	amount, err := round(r.Mul(r, scale(currency)))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+//ParseRate reads a positive exchange rate like 0.0000645
+func ParseRate(value string) (*big.Rat, error) {
+	s := strings.TrimSpace(value)
+	if !decimalPattern.MatchString(s) {
+		return nil, fmt.Errorf("%q is not a decimal rate", s)
+	}
+	r, _ := new(big.Rat).SetString(s)
+	if r.Sign() <= 0 {
+		return nil, errors.New("rate must be greater than 0")
+	}
+	return r, nil
+}
+
+//Convert to another currency, rate is the units of to bought by one unit of m.Currency,
+//the result is rounded half away from zero to the minor unit of to
+func (m Money) Convert(rate *big.Rat, to string) (Money, error) {
+	to, err := Currency(to)
+	if err != nil {
+		return Money{}, err
+	}
+	if _, err := Currency(m.Currency); err != nil {
+		return Money{}, err
+	}
+	r := new(big.Rat).SetInt64(m.Amount)
+	r.Quo(r, scale(m.Currency))
+	r.Mul(r, rate)
+	r.Mul(r, scale(to))
+	amount, err := round(r)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: to}, nil
+}
+
+//String major units with the currency minor digits, e.g. 1500.50
+func (m Money) String() string {
+	exp := currencyExponents[m.Currency]
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+	pow := int64(1)
+	for i := 0; i < exp; i++ {
+		pow *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/pow, exp, amount%pow)
+}
+
+//Number amount in major units as a json number without trailing zero digits, 15000 or 19.9, the numeric
+//price fields kept for clients reading the whole rupiah ints from before currencies
+func (m Money) Number() json.Number {
+	s := m.String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return json.Number(s)
+}
+
+//Major whole major units of the amount (truncated) and whether it has no minor digits left
+func (m Money) Major() (int64, bool) {
+	pow := int64(1)
+	for i := 0; i < currencyExponents[m.Currency]; i++ {
+		pow *= 10
+	}
+	return m.Amount / pow, m.Amount%pow == 0
+}
+
+//MarshalJSON {"amount": 150050, "currency": "IDR", "value": "1500.50"}, amount in minor units
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+		Value    string `json:"value"`
+	}{m.Amount, m.Currency, m.String()})
+}
+
+func scale(currency string) *big.Rat {
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(currencyExponents[currency])), nil)
+	return new(big.Rat).SetInt(pow)
+}
+
+//round half away from zero
+func round(r *big.Rat) (int64, error) {
+	num := new(big.Int).Abs(r.Num())
+	q, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return 0, errors.New("amount out of range")
+	}
+	return q.Int64(), nil
+}
@@ -1,8 +1,175 @@
 package shared
 
+//ParamProduct price in major units of currency (1500.50), currency is set when the product is created
+//and defaults to DEFAULT_CURRENCY
 type ParamProduct struct {
-	ProductName string `json:"product_name" form:"product_name" url:"product_name"`
-	Price       int    `json:"price" form:"price" url:"price"`
-	Description string `json:"description" form:"description" url:"description"`
+	ProductName string  `json:"product_name" form:"product_name" url:"product_name"`
+	Price       Decimal `json:"price" form:"price" url:"price"`
+	Currency    string  `json:"currency" form:"currency" url:"currency"`
+	Description string  `json:"description" form:"description" url:"description"`
+	Quantity    int     `json:"quantity" form:"quantity" url:"quantity"`
+}
+
+type ParamCategory struct {
+	Name     string `json:"name" form:"name" url:"name"`
+	ParentID string `json:"parent_id" form:"parent_id" url:"parent_id"`
+}
+
+type ParamProductCategories struct {
+	CategoryIDs []string `json:"category_ids" form:"category_ids" url:"category_ids"`
+}
+
+//ParamStock quantity is positive for receipt, sale and return, adjustment takes the signed correction,
+//without warehouse_id stock comes in at the default warehouse and goes out of the one holding the most
+type ParamStock struct {
+	Type        string `json:"type" form:"type" url:"type"`
 	Quantity    int    `json:"quantity" form:"quantity" url:"quantity"`
+	WarehouseID string `json:"warehouse_id" form:"warehouse_id" url:"warehouse_id"`
+	Reason      string `json:"reason" form:"reason" url:"reason"`
+}
+
+//ParamReorderThreshold 0 disables the low stock alert of the product
+type ParamReorderThreshold struct {
+	ReorderThreshold int `json:"reorder_threshold" form:"reorder_threshold" url:"reorder_threshold"`
+}
+
+type ParamStockTransfer struct {
+	FromWarehouse string `json:"from_warehouse" form:"from_warehouse" url:"from_warehouse"`
+	ToWarehouse   string `json:"to_warehouse" form:"to_warehouse" url:"to_warehouse"`
+	Quantity      int    `json:"quantity" form:"quantity" url:"quantity"`
+	Reason        string `json:"reason" form:"reason" url:"reason"`
+}
+
+type ParamWarehouse struct {
+	Code string `json:"code" form:"code" url:"code"`
+	Name string `json:"name" form:"name" url:"name"`
+}
+
+//ParamPriceSchedule effective_from and effective_to are read in the app timezone unless they carry an offset,
+//an empty effective_to keeps the price for good
+type ParamPriceSchedule struct {
+	Price         Decimal `json:"price" form:"price" url:"price"`
+	EffectiveFrom string  `json:"effective_from" form:"effective_from" url:"effective_from"`
+	EffectiveTo   string  `json:"effective_to" form:"effective_to" url:"effective_to"`
+}
+
+//ParamProductPrice price list entry in major units of the currency of the url
+type ParamProductPrice struct {
+	Price Decimal `json:"price" form:"price" url:"price"`
+}
+
+//ParamExchangeRate units of the quote currency bought by one unit of the base currency
+type ParamExchangeRate struct {
+	Rate Decimal `json:"rate" form:"rate" url:"rate"`
+}
+
+//ParamPromotion kind is percentage (percent), fixed (amount off every unit in currency) or buy_x_get_y
+//(buy_quantity, get_quantity), starts_at defaults to now, product_ids and category_ids limit the promotion
+//to those products and categories (sub categories included), none means every product
+type ParamPromotion struct {
+	Name        string   `json:"name" form:"name" url:"name"`
+	Kind        string   `json:"kind" form:"kind" url:"kind"`
+	Percent     int      `json:"percent" form:"percent" url:"percent"`
+	Amount      Decimal  `json:"amount" form:"amount" url:"amount"`
+	Currency    string   `json:"currency" form:"currency" url:"currency"`
+	BuyQuantity int      `json:"buy_quantity" form:"buy_quantity" url:"buy_quantity"`
+	GetQuantity int      `json:"get_quantity" form:"get_quantity" url:"get_quantity"`
+	CouponCode  string   `json:"coupon_code" form:"coupon_code" url:"coupon_code"`
+	StartsAt    string   `json:"starts_at" form:"starts_at" url:"starts_at"`
+	EndsAt      string   `json:"ends_at" form:"ends_at" url:"ends_at"`
+	Active      *bool    `json:"active" form:"active" url:"active"`
+	ProductIDs  []string `json:"product_ids" form:"product_ids" url:"product_ids"`
+	CategoryIDs []string `json:"category_ids" form:"category_ids" url:"category_ids"`
+}
+
+//ParamPricing a product or the lines of a cart, coupon optional
+type ParamPricing struct {
+	Items  []ParamPricingItem `json:"items" form:"-" url:"-"`
+	Coupon string             `json:"coupon" form:"coupon" url:"coupon"`
+}
+
+//ParamPricingItem id_variant optional, quantity defaults to 1
+type ParamPricingItem struct {
+	IDProduct string `json:"id_product"`
+	IDVariant string `json:"id_variant"`
+	Quantity  int    `json:"quantity"`
+}
+
+//ParamCartItem quantity added to the cart item of the product, or the new quantity on PUT
+type ParamCartItem struct {
+	IDProduct string `json:"id_product" form:"id_product" url:"id_product"`
+	Quantity  int    `json:"quantity" form:"quantity" url:"quantity"`
+}
+
+//ParamOrder order placed from the items of a cart, coupon optional
+type ParamOrder struct {
+	IDCart string `json:"id_cart" form:"id_cart" url:"id_cart"`
+	Coupon string `json:"coupon" form:"coupon" url:"coupon"`
+}
+
+//ParamWalletAmount deposit or withdrawal in major units of the wallet currency
+type ParamWalletAmount struct {
+	Amount Decimal `json:"amount" form:"amount" url:"amount"`
+}
+
+//ParamWalletPayment pending order paid with its total from the wallet
+type ParamWalletPayment struct {
+	IDOrder string `json:"id_order" form:"id_order" url:"id_order"`
+}
+
+//ParamUser registration, phone optional, the username can't change afterwards
+type ParamUser struct {
+	Username string `json:"username" form:"username" url:"username"`
+	Password string `json:"password" form:"password" url:"password"`
+	Email    string `json:"email" form:"email" url:"email"`
+	FullName string `json:"full_name" form:"full_name" url:"full_name"`
+	Phone    string `json:"phone" form:"phone" url:"phone"`
+}
+
+type ParamUserProfile struct {
+	Email    string `json:"email" form:"email" url:"email"`
+	FullName string `json:"full_name" form:"full_name" url:"full_name"`
+	Phone    string `json:"phone" form:"phone" url:"phone"`
+}
+
+type ParamUserPassword struct {
+	OldPassword string `json:"old_password" form:"old_password" url:"old_password"`
+	NewPassword string `json:"new_password" form:"new_password" url:"new_password"`
+}
+
+type ParamLogin struct {
+	Username string `json:"username" form:"username" url:"username"`
+	Password string `json:"password" form:"password" url:"password"`
+}
+
+//ParamRefreshToken refresh token of a login, used to get new tokens or to log out
+type ParamRefreshToken struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" url:"refresh_token"`
+}
+
+//ParamUserRoles roles replacing the roles of the user (admin, catalog-manager, viewer)
+type ParamUserRoles struct {
+	Roles []string `json:"roles" form:"roles" url:"roles"`
+}
+
+//ParamAPIKey scopes are the permissions of the key (catalog:read, catalog:write, stock:write),
+//expires_at is read like schedule times, empty never expires
+type ParamAPIKey struct {
+	Name      string   `json:"name" form:"name" url:"name"`
+	Scopes    []string `json:"scopes" form:"scopes" url:"scopes"`
+	ExpiresAt string   `json:"expires_at" form:"expires_at" url:"expires_at"`
+}
+
+//ParamReservation ttl in seconds, 0 uses the configured default
+type ParamReservation struct {
+	Quantity int `json:"quantity" form:"quantity" url:"quantity"`
+	TTL      int `json:"ttl" form:"ttl" url:"ttl"`
+}
+
+//ParamVariant price in the product currency, empty or 0 means the variant sells at the product price
+type ParamVariant struct {
+	SKU        string            `json:"sku" form:"sku" url:"sku"`
+	Attributes map[string]string `json:"attributes" form:"-" url:"-"`
+	Price      Decimal           `json:"price" form:"price" url:"price"`
+	Quantity   int               `json:"quantity" form:"quantity" url:"quantity"`
 }
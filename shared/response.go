@@ -1,9 +1,304 @@
 package shared
 
+import (
+	"encoding/json"
+	"time"
+)
+
+//Product price stays a number in major units of the product currency, price_money carries amount and currency
 type Product struct {
-	IDProduct   string `json:"id_product"`
+	IDProduct   string      `json:"id_product"`
+	ProductName string      `json:"product_name"`
+	Price       json.Number `json:"price"`
+	PriceMoney  Money       `json:"price_money"`
+	Description string      `json:"description"`
+	Quantity    int         `json:"quantity"`
+	Active      bool        `json:"active"`
+
+	//LocalPrice price in the currency asked with ?currency=, from the price list or converted
+	LocalPrice *Money `json:"local_price,omitempty"`
+
+	//OriginalPrice and FinalPrice price of one unit before and after the best running promotion without coupon
+	OriginalPrice Money             `json:"original_price"`
+	FinalPrice    Money             `json:"final_price"`
+	Promotion     *AppliedPromotion `json:"promotion,omitempty"`
+
+	//AvailableQuantity quantity minus the units held by active reservations
+	AvailableQuantity int `json:"available_quantity"`
+	ReorderThreshold  int `json:"reorder_threshold"`
+
+	//Image urls of the original and thumbnails (original, small, medium, large)
+	Image          map[string]string `json:"image,omitempty"`
+	VariantSummary *VariantSummary   `json:"variant_summary,omitempty"`
+
+	//Stock quantity per warehouse, warehouses without stock are left out
+	Stock []WarehouseStock `json:"stock"`
+}
+
+type ProductSearchResult struct {
+	Product
+	Rank      float64          `json:"rank"`
+	Highlight ProductHighlight `json:"highlight"`
+}
+
+//ProductHighlight html escaped snippets with matched words wrapped in <mark></mark>
+type ProductHighlight struct {
 	ProductName string `json:"product_name"`
-	Price       int    `json:"price"`
 	Description string `json:"description"`
+}
+
+//Category node of the category tree with its sub categories
+type Category struct {
+	IDCategory string     `json:"id_category"`
+	Name       string     `json:"name"`
+	ParentID   *string    `json:"parent_id"`
+	Children   []Category `json:"children"`
+}
+
+type Variant struct {
+	IDVariant  string            `json:"id_variant"`
+	IDProduct  string            `json:"id_product"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      json.Number       `json:"price"`
+	PriceMoney Money             `json:"price_money"`
+	Quantity   int               `json:"quantity"`
+}
+
+//VariantSummary price range and stock over every variant of a product
+type VariantSummary struct {
+	Count         int         `json:"count"`
+	MinPrice      json.Number `json:"min_price"`
+	MaxPrice      json.Number `json:"max_price"`
+	MinPriceMoney Money       `json:"min_price_money"`
+	MaxPriceMoney Money       `json:"max_price_money"`
+	TotalStock    int         `json:"total_stock"`
+}
+
+//StockMovement inventory ledger row, quantity_change is signed
+type StockMovement struct {
+	IDMovement     string    `json:"id_movement"`
+	IDProduct      string    `json:"id_product"`
+	IDWarehouse    string    `json:"id_warehouse"`
+	Type           string    `json:"type"`
+	QuantityChange int       `json:"quantity_change"`
+	QuantityAfter  int       `json:"quantity_after"`
+	Reason         string    `json:"reason"`
+	Actor          string    `json:"actor"`
+	CreatedDate    time.Time `json:"created_datetime"`
+}
+
+//PriceChange source is manual or schedule, id_schedule set for schedule changes
+type PriceChange struct {
+	IDProduct     string      `json:"id_product"`
+	OldPrice      json.Number `json:"old_price"`
+	NewPrice      json.Number `json:"new_price"`
+	OldPriceMoney Money       `json:"old_price_money"`
+	NewPriceMoney Money       `json:"new_price_money"`
+	Source        string      `json:"source"`
+	IDSchedule    *string     `json:"id_schedule"`
+	ChangedAt     time.Time   `json:"changed_at"`
+}
+
+//PriceSchedule status is pending, active, ended, skipped or cancelled
+type PriceSchedule struct {
+	IDSchedule         string       `json:"id_schedule"`
+	IDProduct          string       `json:"id_product"`
+	Price              json.Number  `json:"price"`
+	PriceMoney         Money        `json:"price_money"`
+	EffectiveFrom      time.Time    `json:"effective_from"`
+	EffectiveTo        *time.Time   `json:"effective_to"`
+	Status             string       `json:"status"`
+	PreviousPrice      *json.Number `json:"previous_price"`
+	PreviousPriceMoney *Money       `json:"previous_price_money"`
+}
+
+//ProductPrices price of a product in its own currency and its price list in other currencies
+type ProductPrices struct {
+	IDProduct string  `json:"id_product"`
+	Price     Money   `json:"price"`
+	Prices    []Money `json:"prices"`
+}
+
+//ExchangeRate units of quote bought by one unit of base
+type ExchangeRate struct {
+	Base        string    `json:"base"`
+	Quote       string    `json:"quote"`
+	Rate        string    `json:"rate"`
+	UpdatedDate time.Time `json:"updated_datetime"`
+}
+
+//Promotion percent set for percentage, amount for fixed, buy_quantity and get_quantity for buy_x_get_y
+type Promotion struct {
+	IDPromotion string     `json:"id_promotion"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	Percent     int        `json:"percent,omitempty"`
+	Amount      *Money     `json:"amount,omitempty"`
+	BuyQuantity int        `json:"buy_quantity,omitempty"`
+	GetQuantity int        `json:"get_quantity,omitempty"`
+	CouponCode  *string    `json:"coupon_code"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Active      bool       `json:"active"`
+	ProductIDs  []string   `json:"product_ids"`
+	CategoryIDs []string   `json:"category_ids"`
+}
+
+//AppliedPromotion promotion giving the discount of a price
+type AppliedPromotion struct {
+	IDPromotion string  `json:"id_promotion"`
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	CouponCode  *string `json:"coupon_code,omitempty"`
+}
+
+//PricingLine unit_price times quantity is the subtotal, total is the subtotal minus the discount
+type PricingLine struct {
+	IDProduct   string            `json:"id_product"`
+	IDVariant   string            `json:"id_variant,omitempty"`
+	ProductName string            `json:"product_name"`
+	Quantity    int               `json:"quantity"`
+	UnitPrice   Money             `json:"unit_price"`
+	Subtotal    Money             `json:"subtotal"`
+	Discount    Money             `json:"discount"`
+	Total       Money             `json:"total"`
+	Promotion   *AppliedPromotion `json:"promotion,omitempty"`
+}
+
+//PricingTotal sum of the lines priced in one currency
+type PricingTotal struct {
+	Currency string `json:"currency"`
+	Subtotal Money  `json:"subtotal"`
+	Discount Money  `json:"discount"`
+	Total    Money  `json:"total"`
+}
+
+type Pricing struct {
+	Lines  []PricingLine  `json:"lines"`
+	Totals []PricingTotal `json:"totals"`
+}
+
+//Cart items of a cart priced with the running promotions
+type Cart struct {
+	IDCart      string         `json:"id_cart"`
+	Lines       []PricingLine  `json:"lines"`
+	Totals      []PricingTotal `json:"totals"`
+	UpdatedDate time.Time      `json:"updated"`
+}
+
+//Order status is pending, paid, shipped or cancelled
+type Order struct {
+	IDOrder     string      `json:"id_order"`
+	IDCustomer  string      `json:"id_customer"`
+	Status      string      `json:"status"`
+	CouponCode  *string     `json:"coupon_code"`
+	Lines       []OrderLine `json:"lines"`
+	Subtotal    Money       `json:"subtotal"`
+	Discount    Money       `json:"discount"`
+	Total       Money       `json:"total"`
+	CreatedDate time.Time   `json:"created"`
+	PaidAt      *time.Time  `json:"paid_at"`
+	ShippedAt   *time.Time  `json:"shipped_at"`
+	CancelledAt *time.Time  `json:"cancelled_at"`
+}
+
+//OrderLine name, price and promotion of the product when the order was placed
+type OrderLine struct {
+	IDProduct   string            `json:"id_product"`
+	ProductName string            `json:"product_name"`
+	Quantity    int               `json:"quantity"`
+	UnitPrice   Money             `json:"unit_price"`
+	Subtotal    Money             `json:"subtotal"`
+	Discount    Money             `json:"discount"`
+	Total       Money             `json:"total"`
+	Promotion   *AppliedPromotion `json:"promotion,omitempty"`
+}
+
+type Wallet struct {
+	IDCustomer  string    `json:"id_customer"`
+	Balance     Money     `json:"balance"`
+	UpdatedDate time.Time `json:"updated"`
+}
+
+//WalletTransaction kind is deposit, withdrawal, payment or refund, entries are its double-entry ledger rows
+type WalletTransaction struct {
+	IDTransaction string        `json:"id_transaction"`
+	Kind          string        `json:"kind"`
+	Amount        Money         `json:"amount"`
+	BalanceAfter  Money         `json:"balance_after"`
+	IDOrder       *string       `json:"id_order,omitempty"`
+	Entries       []WalletEntry `json:"entries"`
+	CreatedDate   time.Time     `json:"created"`
+}
+
+//WalletEntry signed amount of a ledger account, positive amounts go into the account
+type WalletEntry struct {
+	Account string `json:"account"`
+	Amount  Money  `json:"amount"`
+}
+
+type User struct {
+	IDUser          string     `json:"id_user"`
+	TenantID        string     `json:"tenant_id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	FullName        string     `json:"full_name"`
+	Phone           string     `json:"phone"`
+	Active          bool       `json:"active"`
+	CreatedDate     time.Time  `json:"created"`
+	DeactivatedDate *time.Time `json:"deactivated_at"`
+}
+
+//UserRoles roles of a user and the permissions they grant
+type UserRoles struct {
+	IDUser      string   `json:"id_user"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+//APIKey key is only set in the response creating it, it can't be read again
+type APIKey struct {
+	IDKey       string     `json:"id_key"`
+	TenantID    string     `json:"tenant_id"`
+	Name        string     `json:"name"`
+	Key         string     `json:"key,omitempty"`
+	Scopes      []string   `json:"scopes"`
+	CreatedBy   *string    `json:"created_by"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedDate time.Time  `json:"created"`
+}
+
+//Token access_token goes in the Authorization: Bearer header, expires_in and refresh_expires_in in seconds
+type Token struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+//Reservation stock held for a checkout, status is active, confirmed, released or expired
+type Reservation struct {
+	IDReservation string    `json:"id_reservation"`
+	IDProduct     string    `json:"id_product"`
+	IDCustomer    string    `json:"id_customer"`
+	Quantity      int       `json:"quantity"`
+	Status        string    `json:"status"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type Warehouse struct {
+	IDWarehouse string `json:"id_warehouse"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+}
+
+//WarehouseStock quantity of a product at one warehouse
+type WarehouseStock struct {
+	IDWarehouse string `json:"id_warehouse"`
+	Code        string `json:"code"`
 	Quantity    int    `json:"quantity"`
 }
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//Local stores files below Root, served back by the http server under PublicURL
+type Local struct {
+	Root      string
+	PublicURL string
+}
+
+func NewLocal(root, publicURL string) (Local, error) {
+	if root == "" {
+		return Local{}, fmt.Errorf("local storage path is required")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return Local{}, fmt.Errorf("local storage : %w", err)
+	}
+	return Local{Root: root, PublicURL: strings.TrimSuffix(publicURL, "/")}, nil
+}
+
+func (l Local) Put(key, contentType string, data []byte) error {
+	handleErr := func(err error) error {
+		return fmt.Errorf("local storage put %s : %w", key, err)
+	}
+
+	key, err := cleanKey(key)
+	if err != nil {
+		return handleErr(err)
+	}
+	path := filepath.Join(l.Root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return handleErr(err)
+	}
+
+	//write then rename so readers never see a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return handleErr(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return handleErr(err)
+	}
+	return nil
+}
+
+func (l Local) Delete(key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return fmt.Errorf("local storage delete : %w", err)
+	}
+	path := filepath.Join(l.Root, filepath.FromSlash(key))
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("local storage delete %s : %w", key, err)
+	}
+
+	//drop the directory once its last file is gone, fails silently while it isn't empty
+	os.Remove(filepath.Dir(path))
+	return nil
+}
+
+func (l Local) URL(key string) string {
+	return l.PublicURL + "/" + strings.TrimPrefix(key, "/")
+}
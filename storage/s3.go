@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//S3Config s3 compatible bucket, Endpoint defaults to aws (https://s3.<region>.amazonaws.com),
+//PathStyle puts the bucket in the path instead of the host which is what MinIO like servers expect
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+}
+
+//S3 stores objects in an s3 compatible bucket with aws signature v4 signed requests
+type S3 struct {
+	cfg       S3Config
+	publicURL string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func NewS3(cfg S3Config, publicURL string, client *http.Client) (S3, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return S3{}, fmt.Errorf("s3 storage needs bucket, access key and secret key")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return S3{cfg: cfg, publicURL: strings.TrimSuffix(publicURL, "/"), client: client, now: time.Now}, nil
+}
+
+func (s S3) Put(key, contentType string, data []byte) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return fmt.Errorf("s3 put : %w", err)
+	}
+	if err := s.do(http.MethodPut, key, contentType, data); err != nil {
+		return fmt.Errorf("s3 put %s : %w", key, err)
+	}
+	return nil
+}
+
+func (s S3) Delete(key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return fmt.Errorf("s3 delete : %w", err)
+	}
+	if err := s.do(http.MethodDelete, key, "", nil); err != nil {
+		return fmt.Errorf("s3 delete %s : %w", key, err)
+	}
+	return nil
+}
+
+func (s S3) URL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + strings.TrimPrefix(key, "/")
+	}
+	return s.objectURL(strings.TrimPrefix(key, "/")).String()
+}
+
+func (s S3) objectURL(key string) *url.URL {
+	u, _ := url.Parse(s.cfg.Endpoint)
+	if s.cfg.PathStyle {
+		u.Path = "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = escapePath(u.Path)
+	return u
+}
+
+func (s S3) do(method, key, contentType string, data []byte) error {
+	u := s.objectURL(key)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("status %d : %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+//sign adds the aws signature version 4 authorization header
+func (s S3) sign(req *http.Request, payload []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Content-Type") != "" {
+		signed = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	}
+	headers := ""
+	for _, name := range signed {
+		headers += name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n"
+	}
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers,
+		strings.Join(signed, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKey+"/"+scope+
+		", SignedHeaders="+strings.Join(signed, ";")+", Signature="+signature)
+}
+
+//escapePath uri encodes every path segment the way signature v4 expects
+func escapePath(path string) string {
+	out := strings.Builder{}
+	for _, b := range []byte(path) {
+		if b == '/' || b == '-' || b == '_' || b == '.' || b == '~' ||
+			(b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') {
+			out.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&out, "%%%02X", b)
+	}
+	return out.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
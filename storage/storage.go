@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+//Storage object storage for uploaded files, keys are slash separated paths (products/abc/original.jpg)
+type Storage interface {
+	Put(key, contentType string, data []byte) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+type Config struct {
+	Backend   string
+	LocalPath string
+	PublicURL string
+	S3        S3Config
+}
+
+//New returns the storage of cfg.Backend, client is used by the s3 backend
+func New(cfg Config, client *http.Client) (Storage, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewLocal(cfg.LocalPath, cfg.PublicURL)
+	case BackendS3:
+		return NewS3(cfg.S3, cfg.PublicURL, client)
+	}
+	return nil, fmt.Errorf("unknown storage backend %s", cfg.Backend)
+}
+
+//cleanKey refuses keys escaping their root
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid storage key %s", key)
+	}
+	return key, nil
+}